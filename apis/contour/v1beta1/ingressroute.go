@@ -22,7 +22,9 @@ type IngressRouteSpec struct {
 	// Virtualhost appears at most once. If it is present, the object is considered
 	// to be a "root".
 	VirtualHost *VirtualHost `json:"virtualhost,omitempty"`
-	// Routes are the ingress routes. If TCPProxy is present, Routes is ignored.
+	// Routes are the ingress routes. If TCPProxy is present, Routes are
+	// ignored unless TCPProxy.PlaintextRoutes is set, in which case they
+	// are served as plaintext on port 80 only.
 	Routes []Route `json:"routes"`
 	// TCPProxy holds TCP proxy information.
 	TCPProxy *TCPProxy `json:"tcpproxy,omitempty"`
@@ -33,30 +35,54 @@ type IngressRouteSpec struct {
 type VirtualHost struct {
 	// The fully qualified domain name of the root of the ingress tree
 	// all leaves of the DAG rooted at this object relate to the fqdn
+	// +kubebuilder:validation:Pattern=^(\*|(\*\.)?[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?)*)$
 	Fqdn string `json:"fqdn"`
 	// If present describes tls properties. The CNI names that will be matched on
 	// are described in fqdn, the tls.secretName secret must contain a
 	// matching certificate
 	TLS *TLS `json:"tls,omitempty"`
+	// If present, Port is the port on which the tcpproxy of this IngressRoute
+	// accepts plain TCP connections. Port cannot be combined with TLS.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port,omitempty"`
+	// If present, Listeners names the additional listeners this vhost is
+	// served on, in place of the default HTTP and HTTPS listeners.
+	Listeners []string `json:"listeners,omitempty"`
 }
 
 // TLS describes tls properties. The CNI names that will be matched on
 // are described in fqdn, the tls.secretName secret must contain a
 // matching certificate unless tls.passthrough is set to true.
 type TLS struct {
-	// required, the name of a secret in the current namespace
+	// required, the name of a secret in the current namespace, or
+	// of a secret delegated to it, in the form namespace/name.
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?([\.\/][a-z0-9]([-a-z0-9]*[a-z0-9])?)*$
 	SecretName string `json:"secretName,omitempty"`
 	// Minimum TLS version this vhost should negotiate
+	// +kubebuilder:validation:Enum="1.3";"1.2";"1.1"
 	MinimumProtocolVersion string `json:"minimumProtocolVersion,omitempty"`
 	// If Passthrough is set to true, the SecretName will be ignored
 	// and the encrypted handshake will be passed through to the
 	// backing cluster.
 	Passthrough bool `json:"passthrough,omitempty"`
+	// OCSPStaplePolicy controls how an OCSP response is stapled to
+	// TLS handshakes for this vhost. One of "lenient" (the default),
+	// "strict", or "must-staple".
+	// +kubebuilder:validation:Enum=lenient;strict;must-staple
+	OCSPStaplePolicy string `json:"ocspStaplePolicy,omitempty"`
+	// OCSPSecretName optionally names a secret holding the OCSP response
+	// for the certificate under the key tls.ocsp-staple. If not present the
+	// response is read from the tls.ocsp-staple key of the secretName secret.
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?([\.\/][a-z0-9]([-a-z0-9]*[a-z0-9])?)*$
+	OCSPSecretName string `json:"ocspSecretName,omitempty"`
 }
 
 // Route contains the set of routes for a virtual host
 type Route struct {
 	// Match defines the prefix match
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=^\/.*$
 	Match string `json:"match"`
 	// Services are the services to proxy traffic
 	Services []Service `json:"services,omitempty"`
@@ -71,7 +97,7 @@ type Route struct {
 	PrefixRewrite string `json:"prefixRewrite,omitempty"`
 	// The timeout policy for this route
 	TimeoutPolicy *TimeoutPolicy `json:"timeoutPolicy,omitempty"`
-	// // The retry policy for this route
+	// The retry policy for this route
 	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
 }
 
@@ -81,20 +107,34 @@ type TCPProxy struct {
 	Services []Service `json:"services,omitempty"`
 	// Delegate specifies that this tcpproxy should be delegated to another IngressRoute
 	Delegate *Delegate `json:"delegate,omitempty"`
+	// HTTPSRedirect, if true, redirects plaintext requests on port 80
+	// which are not served by Routes to HTTPS.
+	HTTPSRedirect bool `json:"httpsRedirect,omitempty"`
+	// PlaintextRoutes, if true, serves the Routes of the IngressRoute
+	// as plaintext on port 80 alongside the TCP proxy.
+	PlaintextRoutes bool `json:"plaintextRoutes,omitempty"`
 }
 
 // Service defines an upstream to proxy traffic to
 type Service struct {
 	// Name is the name of Kubernetes service to proxy traffic.
 	// Names defined here will be used to look up corresponding endpoints which contain the ips to route.
+	// The name must be a DNS-1035 label.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=^[a-z]([-a-z0-9]*[a-z0-9])?$
 	Name string `json:"name"`
 	// Port (defined as Integer) to proxy traffic to since a service can have multiple defined
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
 	Port int `json:"port"`
 	// Weight defines percentage of traffic to balance traffic
+	// +kubebuilder:validation:Minimum=0
 	Weight int `json:"weight,omitempty"`
 	// HealthCheck defines optional healthchecks on the upstream service
 	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
 	// LB Algorithm to apply (see https://github.com/heptio/contour/blob/master/design/ingressroute-design.md#load-balancing)
+	// +kubebuilder:validation:Enum=RoundRobin;WeightedLeastRequest;Random
 	Strategy string `json:"strategy,omitempty"`
 	// UpstreamValidation defines how to verify the backend service's certificate
 	UpstreamValidation *UpstreamValidation `json:"validation,omitempty"`
@@ -102,15 +142,20 @@ type Service struct {
 
 // Delegate allows for delegating VHosts to other IngressRoutes
 type Delegate struct {
-	// Name of the IngressRoute
+	// Name of the IngressRoute, a DNS-1123 subdomain
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$
 	Name string `json:"name"`
-	// Namespace of the IngressRoute
+	// Namespace of the IngressRoute, a DNS-1123 label
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
 	Namespace string `json:"namespace,omitempty"`
 }
 
 // HealthCheck defines optional healthchecks on the upstream service
 type HealthCheck struct {
 	// HTTP endpoint used to perform health checks on upstream service
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=^\/.*$
 	Path string `json:"path"`
 	// The value of the host header in the HTTP health check request.
 	// If left empty (default value), the name "contour-envoy-healthcheck"
@@ -137,6 +182,7 @@ type TimeoutPolicy struct {
 type RetryPolicy struct {
 	// NumRetries is maximum allowed number of retries.
 	// If not supplied, the number of retries is zero.
+	// +kubebuilder:validation:Minimum=0
 	NumRetries int `json:"count"`
 	// PerTryTimeout specifies the timeout per retry attempt.
 	// Ignored if NumRetries is not supplied.
@@ -153,14 +199,44 @@ type UpstreamValidation struct {
 
 // Status reports the current state of the IngressRoute
 type Status struct {
+	// CurrentStatus is one of valid, warning, invalid or orphaned.
 	CurrentStatus string `json:"currentStatus"`
-	Description   string `json:"description"`
+	// Description explains the current status.
+	Description string `json:"description"`
+	// ObservedGeneration is the generation of the IngressRoute
+	// the status was computed from.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions report whether the IngressRoute is valid, and whether
+	// the delegations, TLS secret and services it refers to are resolved.
+	Conditions []Condition `json:"conditions,omitempty"`
+	// Errors are all the problems which make the IngressRoute invalid.
+	Errors []string `json:"errors,omitempty"`
+	// Warnings are the problems Contour tolerates.
+	Warnings []string `json:"warnings,omitempty"`
+	// VirtualHosts are the fqdns of the virtual hosts the IngressRoute
+	// contributes routes to.
+	VirtualHosts []string `json:"virtualhosts,omitempty"`
+}
+
+// Condition reports one aspect of the state of an IngressRoute.
+type Condition struct {
+	// Type is one of Valid, DelegationResolved, TLSReady or ServicesResolved.
+	Type string `json:"type"`
+	// Status of the condition.
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	Status string `json:"status"`
+	// Reason is a CamelCase reason for the condition's last transition.
+	Reason string `json:"reason,omitempty"`
+	// Message explains the condition.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is when the condition last changed status.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
-// IngressRoute is an Ingress CRD specificiation
+// IngressRoute is an Ingress CRD specification
 type IngressRoute struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
@@ -19,6 +19,7 @@ import (
 
 // TLSCertificateDelegationSpec defines the spec of the CRD
 type TLSCertificateDelegationSpec struct {
+	// Delegations are the secrets delegated to other namespaces.
 	Delegations []CertificateDelegation `json:"delegations"`
 }
 
@@ -27,20 +28,22 @@ type TLSCertificateDelegationSpec struct {
 type CertificateDelegation struct {
 
 	// required, the name of a secret in the current namespace.
+	// +kubebuilder:validation:Required
 	SecretName string `json:"secretName"`
 
 	// required, the namespaces the authority to reference the
-	// the secret will be delegated to.
-	// If TargetNamespaces is nil or empty, the CertificateDelegation'
+	// secret will be delegated to.
+	// If TargetNamespaces is nil or empty, the CertificateDelegation
 	// is ignored. If the TargetNamespace list contains the character, "*"
 	// the secret will be delegated to all namespaces.
+	// +kubebuilder:validation:Required
 	TargetNamespaces []string `json:"targetNamespaces"`
 }
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
-// TLSCertificateDelegation is an TLS Certificate Delegation CRD specificiation.
+// TLSCertificateDelegation is an TLS Certificate Delegation CRD specification.
 // See design/tls-certificate-delegation.md for details.
 type TLSCertificateDelegation struct {
 	metav1.TypeMeta   `json:",inline"`
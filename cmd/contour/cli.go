@@ -18,16 +18,34 @@ import (
 	"os"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/gogo/protobuf/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 type Client struct {
 	ContourAddr string
+
+	// CAFile, CertFile, and KeyFile, if supplied, secure the
+	// connection to Contour with mutual TLS.
+	CAFile   string
+	CertFile string
+	KeyFile  string
 }
 
 func (c *Client) dial() *grpc.ClientConn {
-	conn, err := grpc.Dial(c.ContourAddr, grpc.WithInsecure())
+	opt := grpc.WithInsecure()
+	files := tlsFiles{cafile: c.CAFile, certfile: c.CertFile, keyfile: c.KeyFile}
+	enabled, err := files.enabled()
+	check(err)
+	if enabled {
+		config, err := files.clientConfig()
+		check(err)
+		opt = grpc.WithTransportCredentials(credentials.NewTLS(config))
+	}
+	conn, err := grpc.Dial(c.ContourAddr, opt)
 	check(err)
 	return conn
 }
@@ -56,25 +74,70 @@ func (c *Client) RouteStream() v2.ClusterDiscoveryService_StreamClustersClient {
 	return stream
 }
 
+// Fetch fetches the resources of typeURL once, using the unary
+// variant of the xDS API.
+func (c *Client) Fetch(typeURL string, resources []string) *v2.DiscoveryResponse {
+	conn := c.dial()
+	defer conn.Close()
+
+	ctx := context.Background()
+	req := &v2.DiscoveryRequest{
+		TypeUrl:       typeURL,
+		ResourceNames: resources,
+	}
+	var resp *v2.DiscoveryResponse
+	var err error
+	switch typeURL {
+	case cache.ClusterType:
+		resp, err = v2.NewClusterDiscoveryServiceClient(conn).FetchClusters(ctx, req)
+	case cache.EndpointType:
+		resp, err = v2.NewEndpointDiscoveryServiceClient(conn).FetchEndpoints(ctx, req)
+	case cache.ListenerType:
+		resp, err = v2.NewListenerDiscoveryServiceClient(conn).FetchListeners(ctx, req)
+	case cache.RouteType:
+		resp, err = v2.NewRouteDiscoveryServiceClient(conn).FetchRoutes(ctx, req)
+	case cache.SecretType:
+		resp, err = discovery.NewSecretDiscoveryServiceClient(conn).FetchSecrets(ctx, req)
+	}
+	check(err)
+	return resp
+}
+
 type stream interface {
 	Send(*v2.DiscoveryRequest) error
 	Recv() (*v2.DiscoveryResponse, error)
 }
 
+// fetchonce writes the resources of typeURL to stdout.
+func fetchonce(c *Client, typeURL string, resources []string) {
+	m := proto.TextMarshaler{
+		Compact:   false,
+		ExpandAny: true,
+	}
+	err := m.Marshal(os.Stdout, c.Fetch(typeURL, resources))
+	check(err)
+}
+
 func watchstream(st stream, typeURL string, resources []string) {
 	m := proto.TextMarshaler{
 		Compact:   false,
 		ExpandAny: true,
 	}
+	var version, nonce string
 	for {
+		// each request after the first acknowledges the
+		// version and nonce of the last response.
 		req := &v2.DiscoveryRequest{
 			TypeUrl:       typeURL,
 			ResourceNames: resources,
+			VersionInfo:   version,
+			ResponseNonce: nonce,
 		}
 		err := st.Send(req)
 		check(err)
 		resp, err := st.Recv()
 		check(err)
+		version, nonce = resp.VersionInfo, resp.Nonce
 		err = m.Marshal(os.Stdout, resp)
 		check(err)
 	}
@@ -16,6 +16,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
@@ -26,17 +27,24 @@ import (
 	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	clientset "github.com/heptio/contour/apis/generated/clientset/versioned"
 	contourinformers "github.com/heptio/contour/apis/generated/informers/externalversions"
+	"github.com/heptio/contour/internal/als"
 	"github.com/heptio/contour/internal/contour"
 	"github.com/heptio/contour/internal/debug"
 	"github.com/heptio/contour/internal/envoy"
 	"github.com/heptio/contour/internal/grpc"
 	"github.com/heptio/contour/internal/httpsvc"
 	"github.com/heptio/contour/internal/k8s"
+	"github.com/heptio/contour/internal/lrs"
 	"github.com/heptio/contour/internal/metrics"
+	"github.com/heptio/contour/internal/webhook"
 	"github.com/heptio/contour/internal/workgroup"
+	"github.com/heptio/contour/internal/xdsstatus"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	grpcapi "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	"k8s.io/apimachinery/pkg/runtime"
 	coreinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -55,6 +63,12 @@ func main() {
 	bootstrap.Flag("admin-port", "Envoy admin interface port").IntVar(&config.AdminPort)
 	bootstrap.Flag("xds-address", "xDS gRPC API address").StringVar(&config.XDSAddress)
 	bootstrap.Flag("xds-port", "xDS gRPC API port").IntVar(&config.XDSGRPCPort)
+	bootstrap.Flag("dns-lookup-family", "DNS IP address resolution policy for the xDS and stats clusters").Default("auto").EnumVar(&config.DNSLookupFamily, "auto", "v4", "v6")
+	bootstrap.Flag("ads", "Fetch clusters and listeners over the aggregated discovery service of the xDS gRPC API").BoolVar(&config.ADS)
+	bootstrap.Flag("load-reporting", "Send load reports to the load reporting service of the xDS gRPC API").BoolVar(&config.LoadReporting)
+	bootstrap.Flag("envoy-cafile", "CA bundle file Envoy uses to verify the xDS gRPC API certificate").StringVar(&config.GrpcCABundle)
+	bootstrap.Flag("envoy-cert-file", "Client certificate file Envoy presents to the xDS gRPC API").StringVar(&config.GrpcClientCert)
+	bootstrap.Flag("envoy-key-file", "Client key file Envoy presents to the xDS gRPC API").StringVar(&config.GrpcClientKey)
 
 	// Get the running namespace passed via ENV var from the Kubernetes Downward API
 	config.Namespace = getEnv("CONTOUR_NAMESPACE", "heptio-contour")
@@ -62,6 +76,10 @@ func main() {
 	cli := app.Command("cli", "A CLI client for the Heptio Contour Kubernetes ingress controller.")
 	var client Client
 	cli.Flag("contour", "contour host:port.").Default("127.0.0.1:8001").StringVar(&client.ContourAddr)
+	once := cli.Flag("once", "Fetch the resources once rather than watching them.").Bool()
+	cli.Flag("cafile", "CA bundle file used to verify the contour certificate.").StringVar(&client.CAFile)
+	cli.Flag("cert-file", "Client certificate file presented to contour.").StringVar(&client.CertFile)
+	cli.Flag("key-file", "Client key file presented to contour.").StringVar(&client.KeyFile)
 
 	var resources []string
 	cds := cli.Command("cds", "watch services.")
@@ -75,13 +93,60 @@ func main() {
 	sds := cli.Command("sds", "watch secrets.")
 	sds.Arg("resources", "SDS resource filter").StringsVar(&resources)
 
+	var er explainRequest
+	explainCmd := app.Command("explain", "Explain how Envoy routes a request, as JSON.")
+	explainCmd.Flag("debug-address", "contour debug http endpoint host:port.").Default("127.0.0.1:6060").StringVar(&er.debugAddr)
+	explainCmd.Flag("scheme", "Scheme of the request.").Default("http").EnumVar(&er.scheme, "http", "https")
+	explainCmd.Flag("host", "Host of the request.").StringVar(&er.host)
+	explainCmd.Flag("path", "Path of the request.").Default("/").StringVar(&er.path)
+	explainCmd.Flag("listener", "Name of the additional listener which received the request.").StringVar(&er.listener)
+	explainCmd.Flag("header", "Header of the request, as \"Name: value\". May be repeated").Short('H').StringsVar(&er.headers)
+
+	var ro renderOptions
+	renderCmd := app.Command("render", "Render the Envoy configuration of Kubernetes manifests, without a cluster.")
+	renderCmd.Arg("files", "Manifests of Ingress, IngressRoute, Service, Secret, and TLSCertificateDelegation objects, - for stdin. Defaults to stdin.").StringsVar(&ro.files)
+	renderCmd.Flag("namespace", "Namespace of objects without one.").Short('n').Default("default").StringVar(&ro.namespace)
+	renderCmd.Flag("output", "Output format.").Short('o').Default("yaml").EnumVar(&ro.format, "yaml", "json")
+	renderCmd.Flag("redact-secrets", "Replace the private keys of secrets, use --no-redact-secrets to include them.").Default("true").BoolVar(&ro.redact)
+	renderCmd.Flag("ingress-class-name", "Contour IngressClass name").StringVar(&ro.ingressClass)
+	renderCmd.Flag("ingressroute-root-namespaces", "Restrict contour to searching these namespaces for root ingress routes").StringVar(&ro.rootNamespaces)
+	renderCmd.Flag("stats-address", "Envoy /stats interface address").Default("0.0.0.0").StringVar(&ro.statsAddress)
+	renderCmd.Flag("stats-port", "Envoy /stats interface port").Default("8002").IntVar(&ro.statsPort)
+	renderCmd.Flag("envoy-service-http-port", "Kubernetes Service port for HTTP requests").Default("8080").IntVar(&ro.HTTPPort)
+	renderCmd.Flag("envoy-service-https-port", "Kubernetes Service port for HTTPS requests").Default("8443").IntVar(&ro.HTTPSPort)
+	renderCmd.Flag("envoy-admin-port", "Envoy admin interface port").Default("9001").IntVar(&ro.adminPort)
+	renderCmd.Flag("xds-port", "xDS gRPC API port").Default("8001").IntVar(&ro.xdsPort)
+	renderCmd.Flag("envoy-listener", "Additional Envoy listener, name=NAME,port=PORT[,address=ADDRESS][,protocol=http|https][,proxy-protocol=BOOL][,access-log=PATH]. May be repeated").StringsVar(&ro.listeners)
+	renderAds := renderCmd.Flag("ads", "Render the configuration for contour serve --ads").Bool()
+
+	var vo validateOptions
+	validateCmd := app.Command("validate", "Check the IngressRoutes of Kubernetes manifests for problems.")
+	validateCmd.Arg("files", "Manifests of Ingress, IngressRoute, Service, Secret, and TLSCertificateDelegation objects, - for stdin. Defaults to stdin.").StringsVar(&vo.files)
+	validateCmd.Flag("namespace", "Namespace of objects without one.").Short('n').Default("default").StringVar(&vo.namespace)
+	validateCmd.Flag("ingress-class-name", "Contour IngressClass name").StringVar(&vo.ingressClass)
+	validateCmd.Flag("ingressroute-root-namespaces", "Restrict contour to searching these namespaces for root ingress routes").StringVar(&vo.rootNamespaces)
+	validateCmd.Flag("stats-port", "Envoy /stats interface port").Default("8002").IntVar(&vo.statsPort)
+	validateCmd.Flag("envoy-service-http-port", "Kubernetes Service port for HTTP requests").Default("8080").IntVar(&vo.httpPort)
+	validateCmd.Flag("envoy-service-https-port", "Kubernetes Service port for HTTPS requests").Default("8443").IntVar(&vo.httpsPort)
+	validateCmd.Flag("envoy-admin-port", "Envoy admin interface port").Default("9001").IntVar(&vo.adminPort)
+	validateCmd.Flag("xds-port", "xDS gRPC API port").Default("8001").IntVar(&vo.xdsPort)
+	validateCmd.Flag("envoy-listener", "Additional Envoy listener, name=NAME,port=PORT[,address=ADDRESS][,protocol=http|https][,proxy-protocol=BOOL][,access-log=PATH]. May be repeated").StringsVar(&vo.listeners)
+	validateCluster := validateCmd.Flag("cluster", "Validate the manifests against the objects of a cluster").Bool()
+	validateInCluster := validateCmd.Flag("incluster", "use in cluster configuration.").Bool()
+	validateKubeconfig := validateCmd.Flag("kubeconfig", "path to kubeconfig (if not in running inside a cluster)").Default(filepath.Join(os.Getenv("HOME"), ".kube", "config")).String()
+
 	serve := app.Command("serve", "Serve xDS API traffic")
 	inCluster := serve.Flag("incluster", "use in cluster configuration.").Bool()
 	kubeconfig := serve.Flag("kubeconfig", "path to kubeconfig (if not in running inside a cluster)").Default(filepath.Join(os.Getenv("HOME"), ".kube", "config")).String()
 	xdsAddr := serve.Flag("xds-address", "xDS gRPC API address").Default("127.0.0.1").String()
 	xdsPort := serve.Flag("xds-port", "xDS gRPC API port").Default("8001").Int()
+	var xdsTLS tlsFiles
+	serve.Flag("contour-cafile", "CA bundle file used to verify the client certificates of the xDS gRPC API").StringVar(&xdsTLS.cafile)
+	serve.Flag("contour-cert-file", "Certificate file of the xDS gRPC API").StringVar(&xdsTLS.certfile)
+	serve.Flag("contour-key-file", "Key file of the xDS gRPC API").StringVar(&xdsTLS.keyfile)
 	statsAddress := serve.Flag("stats-address", "Envoy /stats interface address").Default("0.0.0.0").String()
 	statsPort := serve.Flag("stats-port", "Envoy /stats interface port").Default("8002").Int()
+	adminPort := serve.Flag("envoy-admin-port", "Envoy admin interface port, which IngressRoutes cannot claim").Default("9001").Int()
 
 	ch := contour.CacheHandler{
 		FieldLogger: log.WithField("context", "CacheHandler"),
@@ -128,15 +193,46 @@ func main() {
 	serve.Flag("http-address", "address the metrics http endpoint will bind to").Default("0.0.0.0").StringVar(&metricsvc.Addr)
 	serve.Flag("http-port", "port the metrics http endpoint will bind to").Default("8000").IntVar(&metricsvc.Port)
 
+	// the REST xDS API is served, with the TLS configuration of
+	// the xDS gRPC API, when a port is supplied.
+	restsvc := httpsvc.Service{
+		FieldLogger: log.WithField("context", "restsvc"),
+	}
+	serve.Flag("xds-rest-address", "REST xDS API address").Default("127.0.0.1").StringVar(&restsvc.Addr)
+	serve.Flag("xds-rest-port", "REST xDS API port, enables the REST xDS API").IntVar(&restsvc.Port)
+
+	// the validating admission webhook is served over HTTPS
+	// when a certificate and key are supplied.
+	webhooksvc := httpsvc.Service{
+		FieldLogger: log.WithField("context", "webhooksvc"),
+	}
+	serve.Flag("webhook-address", "address the validating admission webhook will bind to").Default("0.0.0.0").StringVar(&webhooksvc.Addr)
+	serve.Flag("webhook-port", "port the validating admission webhook will bind to").Default("9443").IntVar(&webhooksvc.Port)
+	serve.Flag("webhook-cert-file", "Certificate file of the validating admission webhook, enables the webhook").StringVar(&webhooksvc.CertFile)
+	serve.Flag("webhook-key-file", "Key file of the validating admission webhook, enables the webhook").StringVar(&webhooksvc.KeyFile)
+
 	serve.Flag("envoy-http-access-log", "Envoy HTTP access log").Default(contour.DEFAULT_HTTP_ACCESS_LOG).StringVar(&ch.HTTPAccessLog)
 	serve.Flag("envoy-https-access-log", "Envoy HTTPS access log").Default(contour.DEFAULT_HTTPS_ACCESS_LOG).StringVar(&ch.HTTPSAccessLog)
+	accessLogFormat := serve.Flag("envoy-access-log-format", "Envoy access log format; envoy, json, or a custom Envoy format string").Default("envoy").String()
+	serve.Flag("envoy-grpc-access-log", "Stream Envoy HTTP access logs to the access log service on the xDS gRPC port").BoolVar(&ch.GRPCAccessLog)
+	alsMetrics := serve.Flag("access-log-service-metrics", "Export access logs streamed from Envoy as Prometheus metrics").Bool()
+	alsFile := serve.Flag("access-log-service-file", "Write access logs streamed from Envoy as JSON lines to this path, - for stdout").String()
+	accessLogFields := serve.Flag("envoy-access-log-json-fields", "Comma separated fields of JSON access log entries, each a predefined field name or name=%OPERATOR%").Default(strings.Join(envoy.DefaultJSONAccessLogFields, ",")).String()
 	serve.Flag("envoy-service-http-address", "Kubernetes Service address for HTTP requests").Default("0.0.0.0").StringVar(&ch.HTTPAddress)
 	serve.Flag("envoy-service-https-address", "Kubernetes Service address for HTTPS requests").Default("0.0.0.0").StringVar(&ch.HTTPSAddress)
 	serve.Flag("envoy-service-http-port", "Kubernetes Service port for HTTP requests").Default("8080").IntVar(&ch.HTTPPort)
 	serve.Flag("envoy-service-https-port", "Kubernetes Service port for HTTPS requests").Default("8443").IntVar(&ch.HTTPSPort)
 	serve.Flag("use-proxy-protocol", "Use PROXY protocol for all listeners").BoolVar(&ch.UseProxyProto)
+	serve.Flag("envoy-xff-num-trusted-hops", "Number of proxies in front of Envoy whose X-Forwarded-For entries are trusted").Uint32Var(&ch.XffNumTrustedHops)
+	serve.Flag("envoy-skip-xff-append", "Do not append the downstream address to X-Forwarded-For").BoolVar(&ch.SkipXffAppend)
+	var trustedCIDRFlags []string
+	serve.Flag("envoy-trusted-cidr", "Network of a trusted proxy in front of Envoy, requests from it trust one more X-Forwarded-For entry. May be repeated").StringsVar(&trustedCIDRFlags)
+	ads := serve.Flag("ads", "Have Envoy fetch endpoints, routes, and secrets over the aggregated discovery service, requires contour bootstrap --ads").Bool()
+	serve.Flag("envoy-dns-lookup-family", "DNS IP address resolution policy for ExternalName service clusters").Default("auto").EnumVar(&ch.DNSLookupFamily, "auto", "v4", "v6")
 	serve.Flag("ingress-class-name", "Contour IngressClass name").StringVar(&reh.IngressClass)
 	serve.Flag("ingressroute-root-namespaces", "Restrict contour to searching these namespaces for root ingress routes").StringVar(&ingressrouteRootNamespaceFlag)
+	var listenerFlags []string
+	serve.Flag("envoy-listener", "Additional Envoy listener, name=NAME,port=PORT[,address=ADDRESS][,protocol=http|https][,proxy-protocol=BOOL][,access-log=PATH]. May be repeated").StringsVar(&listenerFlags)
 
 	// TODO(youngnick) remove these for 0.14, see #1141
 	// The following flags are no-ops, and the variables are used to print a message that they don't do anything
@@ -149,22 +245,60 @@ func main() {
 	args := os.Args[1:]
 	switch kingpin.MustParse(app.Parse(args)) {
 	case bootstrap.FullCommand():
+		files := tlsFiles{cafile: config.GrpcCABundle, certfile: config.GrpcClientCert, keyfile: config.GrpcClientKey}
+		_, err := files.enabled()
+		check(err)
 		writeBootstrapConfig(&config, *path)
 	case cds.FullCommand():
+		if *once {
+			fetchonce(&client, cache.ClusterType, resources)
+			break
+		}
 		stream := client.ClusterStream()
 		watchstream(stream, cache.ClusterType, resources)
 	case eds.FullCommand():
+		if *once {
+			fetchonce(&client, cache.EndpointType, resources)
+			break
+		}
 		stream := client.EndpointStream()
 		watchstream(stream, cache.EndpointType, resources)
 	case lds.FullCommand():
+		if *once {
+			fetchonce(&client, cache.ListenerType, resources)
+			break
+		}
 		stream := client.ListenerStream()
 		watchstream(stream, cache.ListenerType, resources)
 	case rds.FullCommand():
+		if *once {
+			fetchonce(&client, cache.RouteType, resources)
+			break
+		}
 		stream := client.RouteStream()
 		watchstream(stream, cache.RouteType, resources)
 	case sds.FullCommand():
+		if *once {
+			fetchonce(&client, cache.SecretType, resources)
+			break
+		}
 		stream := client.RouteStream()
 		watchstream(stream, cache.SecretType, resources)
+	case explainCmd.FullCommand():
+		check(explain(os.Stdout, &er))
+	case renderCmd.FullCommand():
+		ro.ListenerVisitorConfig.ADS = *renderAds
+		ro.ClusterVisitorConfig.ADS = *renderAds
+		check(render(os.Stdout, os.Stdin, log, &ro))
+	case validateCmd.FullCommand():
+		var snapshot []runtime.Object
+		if *validateCluster {
+			client, contourClient := newClient(*validateKubeconfig, *validateInCluster)
+			var err error
+			snapshot, err = clusterSnapshot(client, contourClient)
+			check(err)
+		}
+		check(validate(os.Stdout, os.Stdin, log, &vo, snapshot))
 	case serve.FullCommand():
 		log.Infof("args: %v", args)
 		var g workgroup.Group
@@ -191,6 +325,56 @@ func main() {
 
 		ch.ListenerCache = contour.NewListenerCache(*statsAddress, *statsPort)
 		reh.IngressRouteRootNamespaces = parseRootNamespaces(ingressrouteRootNamespaceFlag)
+		reh.ReservedPorts = reservedPorts(ch.HTTPPort, ch.HTTPSPort, *statsPort, *adminPort, *xdsPort)
+
+		format, err := parseAccessLogFormat(*accessLogFormat, *accessLogFields)
+		check(err)
+		ch.AccessLogFormat = format
+
+		sinks, alsFileCloser, err := accessLogSinks(metrics, *alsMetrics, *alsFile)
+		check(err)
+		if alsFileCloser != nil {
+			// close the access log file once the workgroup stops.
+			g.Add(func(stop <-chan struct{}) error {
+				<-stop
+				return alsFileCloser.Close()
+			})
+		}
+		if ch.GRPCAccessLog && len(sinks) == 0 {
+			check(fmt.Errorf("--envoy-grpc-access-log requires --access-log-service-metrics or --access-log-service-file"))
+		}
+		var alh grpc.AccessLogHandler
+		if len(sinks) > 0 {
+			alh = sinks
+		}
+
+		// Envoy sends load reports for the clusters of Services
+		// which opt in with the contour.heptio.com/load-reporting annotation.
+		loadReports := &lrs.Aggregator{Metrics: metrics}
+		debugsvc.LoadReports = loadReports
+
+		// Envoy's acknowledgements of each xDS response are tracked,
+		// and the IngressRoutes named by rejected responses marked invalid.
+		xdsStatus := &xdsstatus.Tracker{
+			Metrics:  metrics,
+			Reporter: &ch,
+		}
+		debugsvc.XDSStatus = xdsStatus
+
+		ch.ListenerVisitorConfig.ADS = *ads
+		ch.ClusterVisitorConfig.ADS = *ads
+
+		trustedCIDRs, err := parseTrustedCIDRs(trustedCIDRFlags)
+		check(err)
+		ch.TrustedCIDRs = trustedCIDRs
+
+		additional, err := parseAdditionalListeners(listenerFlags)
+		check(err)
+		ch.AdditionalListeners = additional
+		for _, al := range additional {
+			reh.Listeners = append(reh.Listeners, al.ListenerConfig)
+			reh.ReservedPorts = append(reh.ReservedPorts, al.Port)
+		}
 
 		client, contourClient := newClient(*kubeconfig, *inCluster)
 
@@ -221,9 +405,52 @@ func main() {
 		ch.Metrics = metrics
 		reh.Metrics = metrics
 
+		// Resource types in xDS v2.
+		resources := map[string]grpc.Resource{
+			ch.ClusterCache.TypeURL():  &ch.ClusterCache,
+			ch.RouteCache.TypeURL():    &ch.RouteCache,
+			ch.ListenerCache.TypeURL(): &ch.ListenerCache,
+			et.TypeURL():               et,
+			ch.SecretCache.TypeURL():   &ch.SecretCache,
+		}
+
 		g.Add(debugsvc.Start)
 		g.Add(metricsvc.Start)
 
+		// the status of IngressRoutes is rewritten when
+		// Envoy rejects, or stops rejecting, a response.
+		g.Add(ch.RefreshStatus)
+
+		xdsTLSEnabled, err := xdsTLS.enabled()
+		check(err)
+
+		if restsvc.Port != 0 {
+			// Envoys configured to poll for their resources
+			// fetch them from the REST xDS API.
+			if xdsTLSEnabled {
+				restsvc.TLSConfig, err = xdsTLS.serverConfig()
+				check(err)
+			} else {
+				log.Warn("serving the REST xDS API without TLS, set --contour-cafile, --contour-cert-file, and --contour-key-file to require mutual TLS")
+			}
+			restsvc.Handle("/v2/", grpc.NewRESTHandler(log.WithField("context", "rest"), resources))
+			g.Add(restsvc.Start)
+		}
+
+		switch {
+		case webhooksvc.CertFile == "" && webhooksvc.KeyFile == "":
+			// webhook disabled
+		case webhooksvc.CertFile == "" || webhooksvc.KeyFile == "":
+			check(fmt.Errorf("--webhook-cert-file and --webhook-key-file must both be supplied to enable the validating admission webhook"))
+		default:
+			webhooksvc.Handle("/validate", &webhook.Handler{
+				Cache:             &reh.KubernetesCache,
+				ValidIngressClass: reh.ValidIngressClass,
+				FieldLogger:       log.WithField("context", "webhook"),
+			})
+			g.Add(webhooksvc.Start)
+		}
+
 		g.Add(func(stop <-chan struct{}) error {
 			log := log.WithField("context", "grpc")
 			addr := net.JoinHostPort(*xdsAddr, strconv.Itoa(*xdsPort))
@@ -232,13 +459,20 @@ func main() {
 				return err
 			}
 
-			s := grpc.NewAPI(log, map[string]grpc.Resource{
-				ch.ClusterCache.TypeURL():  &ch.ClusterCache,
-				ch.RouteCache.TypeURL():    &ch.RouteCache,
-				ch.ListenerCache.TypeURL(): &ch.ListenerCache,
-				et.TypeURL():               et,
-				ch.SecretCache.TypeURL():   &ch.SecretCache,
-			})
+			var opts []grpcapi.ServerOption
+			if xdsTLSEnabled {
+				// Envoy must present a certificate signed by
+				// the CA, connections without one are rejected.
+				config, err := xdsTLS.serverConfig()
+				if err != nil {
+					return err
+				}
+				opts = append(opts, grpcapi.Creds(credentials.NewTLS(config)))
+			} else {
+				log.Warn("serving the xDS gRPC API without TLS, set --contour-cafile, --contour-cert-file, and --contour-key-file to require mutual TLS")
+			}
+
+			s := grpc.NewAPI(log, resources, alh, loadReports, xdsStatus, opts...)
 			log.Println("started")
 			defer log.Println("stopped")
 			return s.Serve(l)
@@ -293,6 +527,29 @@ func check(err error) {
 	}
 }
 
+// accessLogSinks returns the sinks of the access log service; Prometheus
+// metrics if metricsSink is set, and a JSON lines file if path is set.
+// If a file was opened, it is returned for the caller to close.
+func accessLogSinks(m *metrics.Metrics, metricsSink bool, path string) (als.Sinks, io.Closer, error) {
+	var sinks als.Sinks
+	if metricsSink {
+		sinks = append(sinks, &als.Metrics{Metrics: m})
+	}
+	switch path {
+	case "":
+		// no file sink
+	case "-":
+		sinks = append(sinks, als.NewJSONWriter(os.Stdout))
+	default:
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(sinks, als.NewJSONWriter(f)), f, nil
+	}
+	return sinks, nil, nil
+}
+
 func parseRootNamespaces(rn string) []string {
 	if rn == "" {
 		return nil
@@ -304,6 +561,121 @@ func parseRootNamespaces(rn string) []string {
 	return ns
 }
 
+// parseAccessLogFormat parses the values of the --envoy-access-log-format
+// and --envoy-access-log-json-fields flags.
+func parseAccessLogFormat(format, fields string) (envoy.AccessLogFormat, error) {
+	switch format {
+	case "", "envoy":
+		return envoy.AccessLogFormat{}, nil
+	case "json":
+		var names []string
+		for _, f := range strings.Split(fields, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				names = append(names, f)
+			}
+		}
+		if len(names) == 0 {
+			return envoy.AccessLogFormat{}, fmt.Errorf("--envoy-access-log-json-fields: at least one field is required")
+		}
+		m, err := envoy.ParseJSONAccessLogFields(names)
+		if err != nil {
+			return envoy.AccessLogFormat{}, fmt.Errorf("--envoy-access-log-json-fields: %v", err)
+		}
+		return envoy.AccessLogFormat{JSONFields: m}, nil
+	default:
+		return envoy.AccessLogFormat{Format: format}, nil
+	}
+}
+
+// parseTrustedCIDRs parses the values of the --envoy-trusted-cidr flag.
+func parseTrustedCIDRs(flags []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, f := range flags {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(f))
+		if err != nil {
+			return nil, fmt.Errorf("--envoy-trusted-cidr %q: %v", f, err)
+		}
+		networks = append(networks, n)
+	}
+	return networks, nil
+}
+
+// parseAdditionalListeners parses the values of the --envoy-listener flag.
+func parseAdditionalListeners(flags []string) ([]contour.AdditionalListener, error) {
+	var listeners []contour.AdditionalListener
+	names := make(map[string]bool)
+	ports := make(map[int]bool)
+	for _, f := range flags {
+		al, err := parseAdditionalListener(f)
+		if err != nil {
+			return nil, fmt.Errorf("--envoy-listener %q: %v", f, err)
+		}
+		if names[al.Name] {
+			return nil, fmt.Errorf("--envoy-listener %q: duplicate name %q", f, al.Name)
+		}
+		if ports[al.Port] {
+			return nil, fmt.Errorf("--envoy-listener %q: duplicate port %d", f, al.Port)
+		}
+		names[al.Name] = true
+		ports[al.Port] = true
+		listeners = append(listeners, al)
+	}
+	return listeners, nil
+}
+
+// parseAdditionalListener parses a comma separated list of key=value
+// pairs describing an additional listener.
+func parseAdditionalListener(s string) (contour.AdditionalListener, error) {
+	var al contour.AdditionalListener
+	for _, kv := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(kv), "=", 2)
+		if len(kv) != 2 {
+			return al, fmt.Errorf("expected key=value, found %q", kv[0])
+		}
+		key, value := kv[0], kv[1]
+		switch key {
+		case "name":
+			al.Name = value
+		case "address":
+			al.Address = value
+		case "port":
+			port, err := strconv.Atoi(value)
+			if err != nil || port < 1 || port > 65535 {
+				return al, fmt.Errorf("port must be in the range 1-65535, found %q", value)
+			}
+			al.Port = port
+		case "protocol":
+			switch value {
+			case "http":
+				al.Secure = false
+			case "https":
+				al.Secure = true
+			default:
+				return al, fmt.Errorf("protocol must be http or https, found %q", value)
+			}
+		case "proxy-protocol":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return al, fmt.Errorf("proxy-protocol must be a boolean, found %q", value)
+			}
+			al.UseProxyProto = b
+		case "access-log":
+			al.AccessLog = value
+		default:
+			return al, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	switch {
+	case al.Name == "":
+		return al, fmt.Errorf("name is required")
+	case al.Name == contour.ENVOY_HTTP_LISTENER, al.Name == contour.ENVOY_HTTPS_LISTENER, strings.HasPrefix(al.Name, contour.ENVOY_TCP_LISTENER_PREFIX):
+		return al, fmt.Errorf("name %q is reserved", al.Name)
+	case al.Port == 0:
+		return al, fmt.Errorf("port is required")
+	}
+	return al, nil
+}
+
 func getEnv(key, fallback string) string {
 	value, exists := os.LookupEnv(key)
 	if !exists {
@@ -14,8 +14,17 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/heptio/contour/internal/contour"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/heptio/contour/internal/envoy"
+	"github.com/heptio/contour/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func TestParseRootNamespaces(t *testing.T) {
@@ -50,3 +59,222 @@ func TestParseRootNamespaces(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAdditionalListener(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    contour.AdditionalListener
+		wantErr bool
+	}{
+		"http": {
+			input: "name=internal,port=9080",
+			want: contour.AdditionalListener{
+				ListenerConfig: dag.ListenerConfig{
+					Name: "internal",
+					Port: 9080,
+				},
+			},
+		},
+		"https, all options": {
+			input: "name=alt-https, address=10.0.0.1, port=9443, protocol=https, proxy-protocol=true, access-log=/tmp/alt.log",
+			want: contour.AdditionalListener{
+				ListenerConfig: dag.ListenerConfig{
+					Name:    "alt-https",
+					Address: "10.0.0.1",
+					Port:    9443,
+					Secure:  true,
+				},
+				UseProxyProto: true,
+				AccessLog:     "/tmp/alt.log",
+			},
+		},
+		"missing name": {
+			input:   "port=9080",
+			wantErr: true,
+		},
+		"missing port": {
+			input:   "name=internal",
+			wantErr: true,
+		},
+		"port out of range": {
+			input:   "name=internal,port=70000",
+			wantErr: true,
+		},
+		"unknown protocol": {
+			input:   "name=internal,port=9080,protocol=udp",
+			wantErr: true,
+		},
+		"reserved name": {
+			input:   "name=ingress_https,port=9443",
+			wantErr: true,
+		},
+		"unknown key": {
+			input:   "name=internal,port=9080,colour=blue",
+			wantErr: true,
+		},
+		"malformed": {
+			input:   "name=internal,port",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseAdditionalListener(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseAdditionalListenersDuplicates(t *testing.T) {
+	_, err := parseAdditionalListeners([]string{"name=a,port=9080", "name=a,port=9081"})
+	if err == nil {
+		t.Fatal("expected duplicate name to be rejected")
+	}
+	_, err = parseAdditionalListeners([]string{"name=a,port=9080", "name=b,port=9080"})
+	if err == nil {
+		t.Fatal("expected duplicate port to be rejected")
+	}
+}
+
+func TestParseTrustedCIDRs(t *testing.T) {
+	tests := map[string]struct {
+		input   []string
+		want    []string
+		wantErr bool
+	}{
+		"empty": {
+			input: nil,
+			want:  nil,
+		},
+		"ipv4 and ipv6": {
+			input: []string{"10.0.0.0/8", " 192.168.1.1/24", "fd00::/8"},
+			want:  []string{"10.0.0.0/8", "192.168.1.0/24", "fd00::/8"},
+		},
+		"not a cidr": {
+			input:   []string{"10.0.0.1"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseTrustedCIDRs(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			var networks []string
+			for _, n := range got {
+				networks = append(networks, n.String())
+			}
+			if !reflect.DeepEqual(networks, tc.want) {
+				t.Fatalf("expected: %q, got: %q", tc.want, networks)
+			}
+		})
+	}
+}
+
+func TestParseAccessLogFormat(t *testing.T) {
+	tests := map[string]struct {
+		format  string
+		fields  string
+		want    envoy.AccessLogFormat
+		wantErr bool
+	}{
+		"envoy": {
+			format: "envoy",
+			fields: "authority",
+			want:   envoy.AccessLogFormat{},
+		},
+		"json": {
+			format: "json",
+			fields: "authority, response_flags,tenant=%REQ(X-TENANT)%",
+			want: envoy.AccessLogFormat{
+				JSONFields: map[string]string{
+					"authority":      "%REQ(:AUTHORITY)%",
+					"response_flags": "%RESPONSE_FLAGS%",
+					"tenant":         "%REQ(X-TENANT)%",
+				},
+			},
+		},
+		"json, unknown field": {
+			format:  "json",
+			fields:  "authority,colour",
+			wantErr: true,
+		},
+		"json, no fields": {
+			format:  "json",
+			fields:  "",
+			wantErr: true,
+		},
+		"custom": {
+			format: "[%START_TIME%] %UPSTREAM_CLUSTER%",
+			want: envoy.AccessLogFormat{
+				Format: "[%START_TIME%] %UPSTREAM_CLUSTER%",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseAccessLogFormat(tc.format, tc.fields)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAccessLogSinks(t *testing.T) {
+	m := metrics.NewMetrics(prometheus.NewRegistry())
+	dir, err := ioutil.TempDir("", "contour")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.log")
+	tests := map[string]struct {
+		metrics bool
+		path    string
+		want    int
+	}{
+		"none":          {want: 0},
+		"metrics":       {metrics: true, want: 1},
+		"stdout":        {path: "-", want: 1},
+		"metrics, file": {metrics: true, path: path, want: 2},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sinks, closer, err := accessLogSinks(m, tc.metrics, tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sinks) != tc.want {
+				t.Fatalf("expected %d sinks, got: %d", tc.want, len(sinks))
+			}
+			if (closer != nil) != (tc.path == path) {
+				t.Fatalf("expected the file to be closable: %v, got: %v", tc.path == path, closer != nil)
+			}
+			if closer != nil {
+				if err := closer.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// explainRequest holds the flags of contour explain.
+type explainRequest struct {
+	debugAddr string
+	scheme    string
+	host      string
+	path      string
+	listener  string
+	headers   []string
+}
+
+// url returns the URL of the /debug/explain endpoint for r.
+func (r *explainRequest) url() string {
+	q := url.Values{}
+	for k, v := range map[string]string{
+		"scheme":   r.scheme,
+		"host":     r.host,
+		"path":     r.path,
+		"listener": r.listener,
+	} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	for _, h := range r.headers {
+		q.Add("header", h)
+	}
+	addr := r.debugAddr
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return strings.TrimSuffix(addr, "/") + "/debug/explain?" + q.Encode()
+}
+
+// explain writes contour's explanation of how r is routed to w.
+func explain(w io.Writer, r *explainRequest) error {
+	resp, err := http.Get(r.url())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
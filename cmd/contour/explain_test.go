@@ -0,0 +1,52 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestExplainRequestURL(t *testing.T) {
+	tests := map[string]struct {
+		req  explainRequest
+		want string
+	}{
+		"defaults": {
+			req: explainRequest{
+				debugAddr: "127.0.0.1:6060",
+				scheme:    "http",
+				path:      "/",
+			},
+			want: "http://127.0.0.1:6060/debug/explain?path=%2F&scheme=http",
+		},
+		"all options": {
+			req: explainRequest{
+				debugAddr: "http://contour:6060/",
+				scheme:    "https",
+				host:      "kuard.example.com",
+				path:      "/api",
+				listener:  "internal",
+				headers:   []string{"X-Foo: bar", "X-Foo: baz"},
+			},
+			want: "http://contour:6060/debug/explain?header=X-Foo%3A+bar&header=X-Foo%3A+baz&host=kuard.example.com&listener=internal&path=%2Fapi&scheme=https",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.req.url()
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/ghodss/yaml"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	contourscheme "github.com/heptio/contour/apis/generated/clientset/versioned/scheme"
+	"github.com/heptio/contour/internal/contour"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/heptio/contour/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// renderScheme decodes the Kubernetes and Contour objects read by
+// contour render.
+var renderScheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(scheme.AddToScheme(renderScheme))
+	utilruntime.Must(contourscheme.AddToScheme(renderScheme))
+}
+
+// redacted replaces the private keys of rendered secrets.
+const redacted = "REDACTED"
+
+// renderOptions holds the flags of contour render.
+type renderOptions struct {
+	files     []string
+	namespace string
+	format    string
+	redact    bool
+
+	ingressClass   string
+	rootNamespaces string
+	statsAddress   string
+	statsPort      int
+	adminPort      int
+	xdsPort        int
+	listeners      []string
+
+	contour.ListenerVisitorConfig
+	contour.ClusterVisitorConfig
+}
+
+// renderOutput is the output of contour render.
+type renderOutput struct {
+	Listeners []json.RawMessage `json:"listeners"`
+	Routes    []json.RawMessage `json:"routes"`
+	Clusters  []json.RawMessage `json:"clusters"`
+	Secrets   []json.RawMessage `json:"secrets"`
+	Statuses  []renderStatus    `json:"statuses"`
+}
+
+type renderStatus struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Description string `json:"description"`
+	Vhost       string `json:"vhost,omitempty"`
+}
+
+// nopNotifier ignores changes to the objects read by contour render;
+// the DAG is built once they have all been read.
+type nopNotifier struct{}
+
+func (nopNotifier) OnChange(*dag.Builder) {}
+
+// newResourceEventHandler returns a ResourceEventHandler which builds
+// the DAG of the objects added to it as contour serve would.
+func newResourceEventHandler(log logrus.FieldLogger, ingressClass, rootNamespaces string, additional []contour.AdditionalListener, reservedPorts ...int) *contour.ResourceEventHandler {
+	reh := contour.ResourceEventHandler{
+		IngressClass: ingressClass,
+		Notifier:     nopNotifier{},
+		Metrics:      metrics.NewMetrics(prometheus.NewRegistry()),
+		FieldLogger:  log,
+	}
+	reh.IngressRouteRootNamespaces = parseRootNamespaces(rootNamespaces)
+	reh.ReservedPorts = reservedPorts
+	for _, al := range additional {
+		reh.Listeners = append(reh.Listeners, al.ListenerConfig)
+		reh.ReservedPorts = append(reh.ReservedPorts, al.Port)
+	}
+	return &reh
+}
+
+// reservedPorts returns the ports IngressRoutes cannot claim for plain
+// TCP proxying: those of Envoy's listeners and admin interface, and the
+// xDS gRPC port, which also serves ALS and LRS, as Envoy may share the
+// network namespace of Contour. Envoy would fail to bind a listener on
+// any of them, and reject the whole LDS response.
+func reservedPorts(httpPort, httpsPort, statsPort, adminPort, xdsPort int) []int {
+	return []int{httpPort, httpsPort, statsPort, adminPort, xdsPort}
+}
+
+// render reads the objects of opts.files, or stdin if there are none,
+// and writes the resources Contour would send to Envoy, and the status
+// of each IngressRoute, to w.
+func render(w io.Writer, stdin io.Reader, log logrus.FieldLogger, opts *renderOptions) error {
+	objs, err := readManifests(opts.files, stdin, opts.namespace)
+	if err != nil {
+		return err
+	}
+
+	additional, err := parseAdditionalListeners(opts.listeners)
+	if err != nil {
+		return err
+	}
+
+	reh := newResourceEventHandler(log, opts.ingressClass, opts.rootNamespaces, additional, reservedPorts(opts.HTTPPort, opts.HTTPSPort, opts.statsPort, opts.adminPort, opts.xdsPort)...)
+	for _, o := range objs {
+		reh.OnAdd(o.Object)
+	}
+	d := reh.Build()
+
+	ch := contour.CacheHandler{
+		ListenerVisitorConfig: opts.ListenerVisitorConfig,
+		ClusterVisitorConfig:  opts.ClusterVisitorConfig,
+		ListenerCache:         contour.NewListenerCache(opts.statsAddress, opts.statsPort),
+	}
+	ch.AdditionalListeners = additional
+	ch.UpdateCaches(d)
+
+	var out renderOutput
+	for _, r := range []struct {
+		values []proto.Message
+		out    *[]json.RawMessage
+	}{
+		{ch.ListenerCache.Contents(nil), &out.Listeners},
+		{ch.RouteCache.Contents(nil), &out.Routes},
+		{ch.ClusterCache.Contents(nil), &out.Clusters},
+		{ch.SecretCache.Contents(nil), &out.Secrets},
+	} {
+		*r.out = []json.RawMessage{}
+		for _, v := range r.values {
+			if s, ok := v.(*auth.Secret); ok && opts.redact {
+				v = redactSecret(s)
+			}
+			buf, err := marshalResource(v)
+			if err != nil {
+				return err
+			}
+			*r.out = append(*r.out, buf)
+		}
+	}
+	out.Statuses = renderStatuses(d.Statuses())
+
+	buf, err := json.MarshalIndent(&out, "", "  ")
+	if err != nil {
+		return err
+	}
+	switch opts.format {
+	case "yaml":
+		if buf, err = yaml.JSONToYAML(buf); err != nil {
+			return err
+		}
+	default:
+		buf = append(buf, '\n')
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// manifestObject is an object read from a manifest, and where it was
+// read from.
+type manifestObject struct {
+	runtime.Object
+	file string
+	line int
+}
+
+// readManifests reads the objects of each of files, or stdin if there
+// are none.
+func readManifests(files []string, stdin io.Reader, namespace string) ([]*manifestObject, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var objs []*manifestObject
+	for _, file := range files {
+		o, err := readObjects(file, stdin, namespace)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, o...)
+	}
+	return objs, nil
+}
+
+// readObjects reads the objects of the YAML or JSON manifests in file,
+// or stdin if file is "-". Objects without a namespace are placed in
+// namespace, objects of kinds which are not known are skipped.
+func readObjects(file string, stdin io.Reader, namespace string) ([]*manifestObject, error) {
+	r := stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	decoder := serializer.NewCodecFactory(renderScheme).UniversalDeserializer()
+	var decode func(doc []byte) ([]runtime.Object, error)
+	decode = func(doc []byte) ([]runtime.Object, error) {
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if list, ok := obj.(*v1.List); ok {
+			var objs []runtime.Object
+			for _, item := range list.Items {
+				o, err := decode(item.Raw)
+				if err != nil {
+					return nil, err
+				}
+				objs = append(objs, o...)
+			}
+			return objs, nil
+		}
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		if m.GetNamespace() == "" {
+			m.SetNamespace(namespace)
+		}
+		return []runtime.Object{obj}, nil
+	}
+
+	docs, err := splitDocuments(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	var objs []*manifestObject
+	for _, d := range docs {
+		doc, err := utilyaml.ToJSON(d.data)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, d.line, err)
+		}
+		// documents holding only comments decode to null.
+		if doc = bytes.TrimSpace(doc); len(doc) == 0 || string(doc) == "null" {
+			continue
+		}
+		o, err := decode(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, d.line, err)
+		}
+		for _, obj := range o {
+			objs = append(objs, &manifestObject{Object: obj, file: file, line: d.line})
+		}
+	}
+	return objs, nil
+}
+
+// document is a YAML document, and the line of r it starts on.
+type document struct {
+	line int
+	data []byte
+}
+
+// splitDocuments splits the YAML stream r into documents at each
+// "---" separator. The line of a document is that of its first line
+// which is neither blank nor a comment.
+func splitDocuments(r io.Reader) ([]document, error) {
+	var docs []document
+	var doc document
+	sc := bufio.NewScanner(r)
+	// secrets and configmaps can hold long lines.
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Bytes()
+		if bytes.HasPrefix(line, []byte("---")) && len(bytes.TrimSpace(line[3:])) == 0 {
+			docs = append(docs, doc)
+			doc = document{}
+			continue
+		}
+		if trimmed := bytes.TrimSpace(line); doc.line == 0 && len(trimmed) > 0 && trimmed[0] != '#' {
+			doc.line = n
+		}
+		doc.data = append(doc.data, line...)
+		doc.data = append(doc.data, '\n')
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return append(docs, doc), nil
+}
+
+// redactSecret returns a copy of s without its private key.
+func redactSecret(s *auth.Secret) *auth.Secret {
+	s = proto.Clone(s).(*auth.Secret)
+	if tc := s.GetTlsCertificate(); tc != nil && tc.PrivateKey != nil {
+		tc.PrivateKey = &core.DataSource{
+			Specifier: &core.DataSource_InlineString{
+				InlineString: redacted,
+			},
+		}
+	}
+	return s
+}
+
+func marshalResource(pb proto.Message) (json.RawMessage, error) {
+	m := jsonpb.Marshaler{OrigName: true}
+	s, err := m.MarshalToString(pb)
+	return json.RawMessage(s), err
+}
+
+// renderStatuses returns statuses sorted by IngressRoute and vhost.
+func renderStatuses(statuses []dag.Status) []renderStatus {
+	rs := []renderStatus{}
+	for _, s := range statuses {
+		rs = append(rs, renderStatus{
+			Namespace:   s.Object.Namespace,
+			Name:        s.Object.Name,
+			Status:      s.Status,
+			Description: s.Description,
+			Vhost:       s.Vhost,
+		})
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		a, b := rs[i], rs[j]
+		switch {
+		case a.Namespace != b.Namespace:
+			return a.Namespace < b.Namespace
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		default:
+			return a.Vhost < b.Vhost
+		}
+	})
+	return rs
+}
@@ -0,0 +1,208 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+)
+
+const renderManifests = `
+# a document holding only comments
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: kuard
+spec:
+  ports:
+  - port: 8080
+    protocol: TCP
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: secret
+type: kubernetes.io/tls
+data:
+  tls.crt: Y2VydGlmaWNhdGU=
+  tls.key: a2V5
+---
+apiVersion: contour.heptio.com/v1beta1
+kind: IngressRoute
+metadata:
+  name: root
+spec:
+  virtualhost:
+    fqdn: kuard.example.com
+    tls:
+      secretName: secret
+  routes:
+  - match: /
+    services:
+    - name: kuard
+      port: 8080
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: contour.heptio.com/v1beta1
+  kind: IngressRoute
+  metadata:
+    name: nofqdn
+    namespace: teama
+  spec:
+    virtualhost:
+      fqdn: ""
+---
+apiVersion: example.com/v1
+kind: Unknown
+metadata:
+  name: ignored
+`
+
+func TestRender(t *testing.T) {
+	tests := map[string]struct {
+		redact bool
+		want   string
+	}{
+		"redacted": {
+			redact: true,
+			want:   `{"inline_string":"REDACTED"}`,
+		},
+		"not redacted": {
+			redact: false,
+			want:   `{"inline_bytes":"a2V5"}`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			log := logrus.New()
+			log.Out = ioutil.Discard
+			opts := renderOptions{
+				namespace:    "default",
+				format:       "json",
+				redact:       tc.redact,
+				statsAddress: "0.0.0.0",
+				statsPort:    8002,
+			}
+			var buf bytes.Buffer
+			checkErr(t, render(&buf, strings.NewReader(renderManifests), log, &opts))
+
+			var got struct {
+				Listeners []struct {
+					Name string `json:"name"`
+				} `json:"listeners"`
+				Routes []struct {
+					Name         string `json:"name"`
+					VirtualHosts []struct {
+						Domains []string `json:"domains"`
+					} `json:"virtual_hosts"`
+				} `json:"routes"`
+				Clusters []struct {
+					Name string `json:"name"`
+				} `json:"clusters"`
+				Secrets []struct {
+					TLSCertificate struct {
+						PrivateKey json.RawMessage `json:"private_key"`
+					} `json:"tls_certificate"`
+				} `json:"secrets"`
+				Statuses []renderStatus `json:"statuses"`
+			}
+			checkErr(t, json.Unmarshal(buf.Bytes(), &got))
+
+			var listeners []string
+			for _, l := range got.Listeners {
+				listeners = append(listeners, l.Name)
+			}
+			if diff := cmp.Diff([]string{"ingress_http", "ingress_https", "stats-health"}, listeners); diff != "" {
+				t.Fatalf("listeners: %s", diff)
+			}
+			if len(got.Routes) != 2 || got.Routes[0].Name != "ingress_http" || got.Routes[0].VirtualHosts[0].Domains[0] != "kuard.example.com" {
+				t.Fatalf("routes: expected ingress_http with kuard.example.com, got %+v", got.Routes)
+			}
+			if len(got.Clusters) != 1 || got.Clusters[0].Name != "default/kuard/8080/da39a3ee5e" {
+				t.Fatalf("clusters: expected default/kuard/8080/da39a3ee5e, got %+v", got.Clusters)
+			}
+			if len(got.Secrets) != 1 {
+				t.Fatalf("secrets: expected 1, got %d", len(got.Secrets))
+			}
+			if diff := cmp.Diff(tc.want, string(got.Secrets[0].TLSCertificate.PrivateKey)); diff != "" {
+				t.Fatalf("private key: %s", diff)
+			}
+
+			wantStatuses := []renderStatus{{
+				Namespace:   "default",
+				Name:        "root",
+				Status:      "valid",
+				Description: "valid IngressRoute",
+				Vhost:       "kuard.example.com",
+			}, {
+				Namespace:   "teama",
+				Name:        "nofqdn",
+				Status:      "invalid",
+				Description: "Spec.VirtualHost.Fqdn must be specified",
+			}}
+			if diff := cmp.Diff(wantStatuses, got.Statuses); diff != "" {
+				t.Fatalf("statuses: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSplitDocuments(t *testing.T) {
+	tests := map[string]struct {
+		stream string
+		want   []document
+	}{
+		"empty": {
+			stream: "",
+			want:   []document{{}},
+		},
+		"single document": {
+			stream: "kind: Service\n",
+			want:   []document{{line: 1, data: []byte("kind: Service\n")}},
+		},
+		"comments and separators": {
+			stream: "---\n# comment\n\nkind: Service\n---  \n# only a comment\n---\nkind: Secret\n",
+			want: []document{
+				{},
+				{line: 4, data: []byte("# comment\n\nkind: Service\n")},
+				{data: []byte("# only a comment\n")},
+				{line: 8, data: []byte("kind: Secret\n")},
+			},
+		},
+		"separator prefix": {
+			stream: "kind: Service\n----\n",
+			want:   []document{{line: 1, data: []byte("kind: Service\n----\n")}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := splitDocuments(strings.NewReader(tc.stream))
+			checkErr(t, err)
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(document{})); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
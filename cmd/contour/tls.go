@@ -0,0 +1,90 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/heptio/contour/internal/envoy"
+)
+
+// tlsFiles holds the paths of a CA bundle, certificate, and key
+// used to secure the xDS gRPC API with mutual TLS.
+type tlsFiles struct {
+	cafile, certfile, keyfile string
+}
+
+// enabled returns true if TLS is configured, or an error if only
+// some of the files were supplied.
+func (f *tlsFiles) enabled() (bool, error) {
+	switch {
+	case f.cafile == "" && f.certfile == "" && f.keyfile == "":
+		return false, nil
+	case f.cafile == "" || f.certfile == "" || f.keyfile == "":
+		return false, errors.New("a CA file, certificate file, and key file must all be supplied to enable TLS")
+	default:
+		return true, nil
+	}
+}
+
+// serverConfig returns a *tls.Config which presents the certificate
+// and requires clients to present a certificate signed by the CA.
+func (f *tlsFiles) serverConfig() (*tls.Config, error) {
+	cert, pool, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// clientConfig returns a *tls.Config which presents the certificate
+// and requires the server to present a certificate for
+// envoy.XDSServerName signed by the CA.
+func (f *tlsFiles) clientConfig() (*tls.Config, error) {
+	cert, pool, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   envoy.XDSServerName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func (f *tlsFiles) load() (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(f.certfile, f.keyfile)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	ca, err := ioutil.ReadFile(f.cafile)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return tls.Certificate{}, nil, fmt.Errorf("no certificates found in %s", f.cafile)
+	}
+	return cert, pool, nil
+}
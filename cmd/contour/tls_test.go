@@ -0,0 +1,236 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/heptio/contour/internal/contour"
+	"github.com/heptio/contour/internal/envoy"
+	cgrpc "github.com/heptio/contour/internal/grpc"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestTLSFilesEnabled(t *testing.T) {
+	tests := map[string]struct {
+		files   tlsFiles
+		want    bool
+		wantErr bool
+	}{
+		"none": {
+			files: tlsFiles{},
+			want:  false,
+		},
+		"all": {
+			files: tlsFiles{cafile: "ca.crt", certfile: "tls.crt", keyfile: "tls.key"},
+			want:  true,
+		},
+		"missing ca": {
+			files:   tlsFiles{certfile: "tls.crt", keyfile: "tls.key"},
+			wantErr: true,
+		},
+		"missing key": {
+			files:   tlsFiles{cafile: "ca.crt", certfile: "tls.crt"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.files.enabled()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestXDSMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "contour")
+	checkErr(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newCertificate(t, nil, nil, "ca")
+	writeCertificate(t, dir, "ca", ca)
+	writeCertificate(t, dir, "contour", newCertificate(t, ca.cert, ca.key, envoy.XDSServerName))
+	writeCertificate(t, dir, "envoy", newCertificate(t, ca.cert, ca.key, "envoy"))
+	writeCertificate(t, dir, "other", newCertificate(t, nil, nil, "other"))
+
+	server := tlsFiles{
+		cafile:   filepath.Join(dir, "ca.crt"),
+		certfile: filepath.Join(dir, "contour.crt"),
+		keyfile:  filepath.Join(dir, "contour.key"),
+	}
+	config, err := server.serverConfig()
+	checkErr(t, err)
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	var ch contour.CacheHandler
+	srv := cgrpc.NewAPI(log, map[string]cgrpc.Resource{
+		ch.ClusterCache.TypeURL(): &ch.ClusterCache,
+	}, nil, nil, nil, grpc.Creds(credentials.NewTLS(config)))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	checkErr(t, err)
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(l)
+	}()
+	defer func() {
+		srv.Stop()
+		<-done
+	}()
+
+	fetch := func(t *testing.T, opt grpc.DialOption) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		cc, err := grpc.DialContext(ctx, l.Addr().String(), opt)
+		checkErr(t, err)
+		defer cc.Close()
+		_, err = v2.NewClusterDiscoveryServiceClient(cc).FetchClusters(ctx, &v2.DiscoveryRequest{
+			TypeUrl: cache.ClusterType,
+		})
+		return err
+	}
+
+	clientTLS := func(t *testing.T, name string) grpc.DialOption {
+		files := tlsFiles{
+			cafile:   filepath.Join(dir, "ca.crt"),
+			certfile: filepath.Join(dir, name+".crt"),
+			keyfile:  filepath.Join(dir, name+".key"),
+		}
+		config, err := files.clientConfig()
+		checkErr(t, err)
+		return grpc.WithTransportCredentials(credentials.NewTLS(config))
+	}
+
+	tests := map[string]struct {
+		opt     func(*testing.T) grpc.DialOption
+		wantErr bool
+	}{
+		"client certificate signed by the ca": {
+			opt: func(t *testing.T) grpc.DialOption {
+				return clientTLS(t, "envoy")
+			},
+		},
+		"client certificate signed by another ca": {
+			opt: func(t *testing.T) grpc.DialOption {
+				return clientTLS(t, "other")
+			},
+			wantErr: true,
+		},
+		"no client certificate": {
+			opt: func(t *testing.T) grpc.DialOption {
+				pool := x509.NewCertPool()
+				pool.AddCert(ca.cert)
+				return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+					RootCAs:    pool,
+					ServerName: envoy.XDSServerName,
+				}))
+			},
+			wantErr: true,
+		},
+		"plaintext": {
+			opt: func(t *testing.T) grpc.DialOption {
+				return grpc.WithInsecure()
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := fetch(t, tc.opt(t))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+type certificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newCertificate returns a certificate for name signed by parent,
+// or a self signed CA certificate if parent is nil.
+func newCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, name string) certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	checkErr(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	checkErr(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	checkErr(t, err)
+	cert, err := x509.ParseCertificate(der)
+	checkErr(t, err)
+	return certificate{cert: cert, key: key}
+}
+
+// writeCertificate writes c to name.crt and name.key in dir.
+func writeCertificate(t *testing.T, dir, name string, c certificate) {
+	t.Helper()
+	key, err := x509.MarshalECPrivateKey(c.key)
+	checkErr(t, err)
+	write := func(path, typ string, der []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+		checkErr(t, ioutil.WriteFile(filepath.Join(dir, path), data, 0600))
+	}
+	write(name+".crt", "CERTIFICATE", c.cert.Raw)
+	write(name+".key", "EC PRIVATE KEY", key)
+}
+
+func checkErr(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
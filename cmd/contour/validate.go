@@ -0,0 +1,183 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	clientset "github.com/heptio/contour/apis/generated/clientset/versioned"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// validateOptions holds the flags of contour validate.
+type validateOptions struct {
+	files     []string
+	namespace string
+
+	ingressClass   string
+	rootNamespaces string
+	listeners      []string
+	httpPort       int
+	httpsPort      int
+	statsPort      int
+	adminPort      int
+	xdsPort        int
+}
+
+// problem is a non valid status of an IngressRoute read from a manifest.
+type problem struct {
+	file        string
+	line        int
+	namespace   string
+	name        string
+	status      string
+	description string
+}
+
+func (p problem) String() string {
+	return fmt.Sprintf("%s:%d: IngressRoute %s/%s: %s: %s", p.file, p.line, p.namespace, p.name, p.status, p.description)
+}
+
+// validate reads the objects of opts.files, or stdin if there are none,
+// and writes the problems found with each IngressRoute they declare
+// to w. The objects of snapshot, if any, are added first so manifests
+// may be validated against the objects of a cluster. An error is
+// returned if any problems were found.
+func validate(w io.Writer, stdin io.Reader, log logrus.FieldLogger, opts *validateOptions, snapshot []runtime.Object) error {
+	objs, err := readManifests(opts.files, stdin, opts.namespace)
+	if err != nil {
+		return err
+	}
+
+	additional, err := parseAdditionalListeners(opts.listeners)
+	if err != nil {
+		return err
+	}
+
+	reh := newResourceEventHandler(log, opts.ingressClass, opts.rootNamespaces, additional, reservedPorts(opts.httpPort, opts.httpsPort, opts.statsPort, opts.adminPort, opts.xdsPort)...)
+	reh.Strict = true
+	for _, o := range snapshot {
+		reh.OnAdd(o)
+	}
+	locations := make(map[string]*manifestObject)
+	for _, o := range objs {
+		reh.OnAdd(o.Object)
+		if ir, ok := o.Object.(*ingressroutev1.IngressRoute); ok {
+			locations[ir.Namespace+"/"+ir.Name] = o
+		}
+	}
+
+	problems := validationProblems(reh.Build().Statuses(), locations)
+	for _, p := range problems {
+		fmt.Fprintln(w, p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("problems found: %d", len(problems))
+	}
+	return nil
+}
+
+// validationProblems returns the statuses which are not valid of the
+// IngressRoutes read from manifests, ordered by location.
+func validationProblems(statuses []dag.Status, locations map[string]*manifestObject) []problem {
+	seen := make(map[problem]bool)
+	var problems []problem
+	for _, st := range statuses {
+		if st.Status == dag.StatusValid {
+			continue
+		}
+		o, ok := locations[st.Object.Namespace+"/"+st.Object.Name]
+		if !ok {
+			// not read from a manifest, part of the snapshot.
+			continue
+		}
+		p := problem{
+			file:        o.file,
+			line:        o.line,
+			namespace:   st.Object.Namespace,
+			name:        st.Object.Name,
+			status:      st.Status,
+			description: st.Description,
+		}
+		if seen[p] {
+			// an ingressroute reached by more than one
+			// delegation chain may be given the same status
+			// more than once.
+			continue
+		}
+		seen[p] = true
+		problems = append(problems, p)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		switch {
+		case a.file != b.file:
+			return a.file < b.file
+		case a.line != b.line:
+			return a.line < b.line
+		default:
+			return a.description < b.description
+		}
+	})
+	return problems
+}
+
+// clusterSnapshot returns the objects of a cluster which contour serve
+// builds its DAG from.
+func clusterSnapshot(client *kubernetes.Clientset, contourClient *clientset.Clientset) ([]runtime.Object, error) {
+	var objs []runtime.Object
+	services, err := client.CoreV1().Services("").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range services.Items {
+		objs = append(objs, &services.Items[i])
+	}
+	secrets, err := client.CoreV1().Secrets("").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets.Items {
+		objs = append(objs, &secrets.Items[i])
+	}
+	ingresses, err := client.ExtensionsV1beta1().Ingresses("").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range ingresses.Items {
+		objs = append(objs, &ingresses.Items[i])
+	}
+	ingressroutes, err := contourClient.ContourV1beta1().IngressRoutes("").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range ingressroutes.Items {
+		objs = append(objs, &ingressroutes.Items[i])
+	}
+	delegations, err := contourClient.ContourV1beta1().TLSCertificateDelegations("").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range delegations.Items {
+		objs = append(objs, &delegations.Items[i])
+	}
+	return objs, nil
+}
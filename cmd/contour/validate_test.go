@@ -0,0 +1,209 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const validateManifests = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: kuard
+spec:
+  ports:
+  - port: 8080
+---
+apiVersion: contour.heptio.com/v1beta1
+kind: IngressRoute
+metadata:
+  name: root
+spec:
+  virtualhost:
+    fqdn: kuard.example.com
+  routes:
+  - match: /
+    services:
+    - name: kuard
+      port: 8080
+  - match: /api
+    delegate:
+      name: api
+---
+# api has a typo in its timeout
+apiVersion: contour.heptio.com/v1beta1
+kind: IngressRoute
+metadata:
+  name: api
+spec:
+  routes:
+  - match: /api
+    timeoutPolicy:
+      request: 10 seconds
+    services:
+    - name: kuard
+      port: 8080
+---
+apiVersion: contour.heptio.com/v1beta1
+kind: IngressRoute
+metadata:
+  name: missing
+spec:
+  virtualhost:
+    fqdn: missing.example.com
+  routes:
+  - match: /
+    services:
+    - name: nginx
+      port: 80
+`
+
+const validateDelegate = `
+apiVersion: contour.heptio.com/v1beta1
+kind: IngressRoute
+metadata:
+  name: api
+spec:
+  routes:
+  - match: /api
+    services:
+    - name: kuard
+      port: 8080
+`
+
+const validateAdminPort = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: postgres
+spec:
+  ports:
+  - port: 5432
+---
+apiVersion: contour.heptio.com/v1beta1
+kind: IngressRoute
+metadata:
+  name: admin
+spec:
+  virtualhost:
+    fqdn: admin.example.com
+    port: 9001
+  tcpproxy:
+    services:
+    - name: postgres
+      port: 5432
+`
+
+func TestValidate(t *testing.T) {
+	snapshot := []runtime.Object{
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kuard",
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Protocol: "TCP",
+					Port:     8080,
+				}},
+			},
+		},
+		&ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "root",
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: "kuard.example.com",
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/api",
+					Delegate: &ingressroutev1.Delegate{
+						Name: "api",
+					},
+				}},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		manifests string
+		snapshot  []runtime.Object
+		want      string
+		wantErr   string
+	}{
+		"problems": {
+			manifests: validateManifests,
+			want: `-:27: IngressRoute default/api: warning: valid IngressRoute with warnings: route "/api": timeoutPolicy.request "10 seconds" is not a valid duration
+-:40: IngressRoute default/missing: warning: valid IngressRoute with warnings: route "/": service default/nginx/80: not found
+`,
+			wantErr: "problems found: 2",
+		},
+		"delegate without its root": {
+			manifests: validateDelegate,
+			want: `-:2: IngressRoute default/api: orphaned: this IngressRoute is not part of a delegation chain from a root IngressRoute
+`,
+			wantErr: "problems found: 1",
+		},
+		"port of envoy's admin interface": {
+			manifests: validateAdminPort,
+			want: `-:10: IngressRoute default/admin: invalid: port 9001 is reserved
+`,
+			wantErr: "problems found: 1",
+		},
+		"delegate validated against a cluster": {
+			manifests: validateDelegate,
+			snapshot:  snapshot,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			log := logrus.New()
+			log.Out = ioutil.Discard
+			opts := validateOptions{
+				namespace: "default",
+				httpPort:  8080,
+				httpsPort: 8443,
+				statsPort: 8002,
+				adminPort: 9001,
+				xdsPort:   8001,
+			}
+			var buf bytes.Buffer
+			err := validate(&buf, strings.NewReader(tc.manifests), log, &opts, tc.snapshot)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// crdgen writes the CustomResourceDefinitions of Contour's custom
+// resources, generated from the types of apis/contour/v1beta1, to stdout.
+// It is run from the root of the repository by make crds.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/heptio/contour/internal/crd"
+)
+
+func main() {
+	if err := crd.Generate(os.Stdout, "apis/contour/v1beta1", crd.Definitions); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package als provides sinks for the access log entries Envoy
+// streams to Contour's access log service.
+package als
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	accesslogdata "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v2"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/heptio/contour/internal/grpc"
+	"github.com/heptio/contour/internal/metrics"
+)
+
+// Sinks passes each batch of access log entries to every sink in turn.
+type Sinks []grpc.AccessLogHandler
+
+// HTTPAccessLogs implements grpc.AccessLogHandler.
+func (s Sinks) HTTPAccessLogs(logName string, entries []*accesslogdata.HTTPAccessLogEntry) {
+	for _, sink := range s {
+		sink.HTTPAccessLogs(logName, entries)
+	}
+}
+
+// TCPAccessLogs implements grpc.AccessLogHandler.
+func (s Sinks) TCPAccessLogs(logName string, entries []*accesslogdata.TCPAccessLogEntry) {
+	for _, sink := range s {
+		sink.TCPAccessLogs(logName, entries)
+	}
+}
+
+// DefaultMaxVHosts is the default number of distinct vhost
+// label values recorded by a Metrics sink.
+const DefaultMaxVHosts = 1000
+
+// OtherVHost is the vhost label value of requests whose authority
+// was not recorded because MaxVHosts distinct vhosts have been seen.
+const OtherVHost = "other"
+
+// Metrics records HTTP access log entries as Prometheus metrics
+// labelled by vhost and upstream cluster. They are not labelled by
+// route: Envoy 1.10's access log entries do not name the route, and
+// labelling by request path would let clients create a series per URL.
+type Metrics struct {
+	*metrics.Metrics
+
+	// MaxVHosts bounds the number of distinct vhost label values, as
+	// the authority of a request is chosen by the client.
+	// If not set, defaults to DefaultMaxVHosts.
+	MaxVHosts int
+
+	mu     sync.Mutex
+	vhosts map[string]bool
+}
+
+// HTTPAccessLogs implements grpc.AccessLogHandler.
+func (m *Metrics) HTTPAccessLogs(_ string, entries []*accesslogdata.HTTPAccessLogEntry) {
+	for _, e := range entries {
+		var duration time.Duration
+		if d := e.GetCommonProperties().GetTimeToLastDownstreamTxByte(); d != nil {
+			duration = *d
+		}
+		m.ObserveAccessLogRequest(
+			m.vhost(e.GetRequest().GetAuthority()),
+			e.GetCommonProperties().GetUpstreamCluster(),
+			e.GetResponse().GetResponseCode().GetValue(),
+			duration,
+		)
+	}
+}
+
+// TCPAccessLogs implements grpc.AccessLogHandler.
+// TCP access log entries carry no vhost or response code,
+// so they are not recorded.
+func (m *Metrics) TCPAccessLogs(string, []*accesslogdata.TCPAccessLogEntry) {}
+
+// vhost returns the vhost label value for authority.
+func (m *Metrics) vhost(authority string) string {
+	vhost := strings.ToLower(authority)
+	if host, _, err := net.SplitHostPort(vhost); err == nil {
+		vhost = host
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.vhosts[vhost] {
+		return vhost
+	}
+	max := m.MaxVHosts
+	if max == 0 {
+		max = DefaultMaxVHosts
+	}
+	if len(m.vhosts) >= max {
+		return OtherVHost
+	}
+	if m.vhosts == nil {
+		m.vhosts = make(map[string]bool)
+	}
+	m.vhosts[vhost] = true
+	return vhost
+}
+
+// JSONWriter writes each access log entry to an io.Writer as a
+// single line JSON object. The object has a log_name field, and
+// either an http or a tcp field holding the entry.
+type JSONWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+	m  jsonpb.Marshaler
+}
+
+// NewJSONWriter returns a JSONWriter which writes to w.
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	return &JSONWriter{
+		w: w,
+		m: jsonpb.Marshaler{OrigName: true},
+	}
+}
+
+// HTTPAccessLogs implements grpc.AccessLogHandler.
+func (jw *JSONWriter) HTTPAccessLogs(logName string, entries []*accesslogdata.HTTPAccessLogEntry) {
+	for _, e := range entries {
+		jw.write(logName, "http", e)
+	}
+}
+
+// TCPAccessLogs implements grpc.AccessLogHandler.
+func (jw *JSONWriter) TCPAccessLogs(logName string, entries []*accesslogdata.TCPAccessLogEntry) {
+	for _, e := range entries {
+		jw.write(logName, "tcp", e)
+	}
+}
+
+func (jw *JSONWriter) write(logName, kind string, entry proto.Message) {
+	var buf bytes.Buffer
+	name, _ := json.Marshal(logName)
+	buf.WriteString(`{"log_name":`)
+	buf.Write(name)
+	buf.WriteString(`,"` + kind + `":`)
+	if err := jw.m.Marshal(&buf, entry); err != nil {
+		// entries received from Envoy always marshal,
+		// drop any which do not.
+		return
+	}
+	buf.WriteString("}\n")
+
+	jw.mu.Lock()
+	defer jw.mu.Unlock()
+	_, _ = jw.w.Write(buf.Bytes())
+}
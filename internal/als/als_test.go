@@ -0,0 +1,93 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package als
+
+import (
+	"bytes"
+	"testing"
+
+	accesslogdata "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/heptio/contour/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsVHost(t *testing.T) {
+	tests := map[string]struct {
+		seen      []string
+		authority string
+		want      string
+	}{
+		"hostname": {
+			authority: "example.com",
+			want:      "example.com",
+		},
+		"hostname and port": {
+			authority: "Example.com:8080",
+			want:      "example.com",
+		},
+		"ipv6 address and port": {
+			authority: "[::1]:8080",
+			want:      "::1",
+		},
+		"limit reached": {
+			seen:      []string{"a.example.com", "b.example.com"},
+			authority: "c.example.com",
+			want:      OtherVHost,
+		},
+		"limit reached, already seen": {
+			seen:      []string{"a.example.com", "b.example.com"},
+			authority: "b.example.com",
+			want:      "b.example.com",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &Metrics{
+				Metrics:   metrics.NewMetrics(prometheus.NewRegistry()),
+				MaxVHosts: 2,
+			}
+			for _, s := range tc.seen {
+				m.vhost(s)
+			}
+			got := m.vhost(tc.authority)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	jw := NewJSONWriter(&buf)
+	jw.HTTPAccessLogs("ingress_http", []*accesslogdata.HTTPAccessLogEntry{{
+		Request: &accesslogdata.HTTPRequestProperties{
+			Authority: "example.com",
+		},
+	}})
+	jw.TCPAccessLogs("ingress_https", []*accesslogdata.TCPAccessLogEntry{{
+		CommonProperties: &accesslogdata.AccessLogCommon{
+			UpstreamCluster: "default/kuard/443/da39a3ee5e",
+		},
+	}})
+
+	want := `{"log_name":"ingress_http","http":{"request":{"authority":"example.com"}}}
+{"log_name":"ingress_https","tcp":{"common_properties":{"upstream_cluster":"default/kuard/443/da39a3ee5e"}}}
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatal(diff)
+	}
+}
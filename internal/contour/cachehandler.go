@@ -17,6 +17,12 @@
 package contour
 
 import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
 	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/k8s"
 	"github.com/heptio/contour/internal/metrics"
@@ -27,6 +33,7 @@ import (
 // CacheHandler manages the state of xDS caches.
 type CacheHandler struct {
 	ListenerVisitorConfig
+	ClusterVisitorConfig
 	ListenerCache
 	RouteCache
 	ClusterCache
@@ -35,33 +42,231 @@ type CacheHandler struct {
 	IngressRouteStatus *k8s.IngressRouteStatus
 	logrus.FieldLogger
 	*metrics.Metrics
+
+	mu         sync.Mutex
+	last       statusable     // the last DAG
+	rejections map[string]int // errors of rejected responses, by number of streams
+	refresh    chan struct{}  // signalled when rejections change
+
+	writeMu sync.Mutex // serialises writes of IngressRoute status
+
+	buildMu   sync.Mutex  // serialises calls to OnChange
+	ocspTimer *time.Timer // rebuilds the DAG when an OCSP response goes stale
 }
 
+// statusRefreshDelay is how long RefreshStatus waits after the
+// rejections of responses change before writing the status of
+// IngressRoutes, so that the rejection of the same response by
+// a fleet of Envoys results in a single round of writes.
+var statusRefreshDelay = time.Second
+
 type statusable interface {
 	Statuses() []dag.Status
+	IngressRouteStatus(dag.Status) ingressroutev1.Status
 }
 
 func (ch *CacheHandler) OnChange(b *dag.Builder) {
+	ch.buildMu.Lock()
+	defer ch.buildMu.Unlock()
 	timer := prometheus.NewTimer(ch.CacheHandlerOnUpdateSummary)
 	defer timer.ObserveDuration()
 	dag := b.Build()
 	ch.setIngressRouteStatus(dag)
-	ch.updateSecrets(dag)
-	ch.updateListeners(dag)
-	ch.updateRoutes(dag)
-	ch.updateClusters(dag)
+	ch.UpdateCaches(dag)
 	ch.updateIngressRouteMetric(dag)
+	ch.updateCertificateMetric(dag)
+	ch.scheduleOCSPRebuild(b, nextOCSPUpdate(dag, time.Now()))
+}
+
+// scheduleOCSPRebuild arranges for the DAG to be rebuilt from b at next,
+// when a stapled OCSP response goes stale, so that the response stops
+// being stapled, or the vhost stops being served, without waiting for
+// an unrelated change. A zero next cancels any pending rebuild.
+func (ch *CacheHandler) scheduleOCSPRebuild(b *dag.Builder, next time.Time) {
+	if ch.ocspTimer != nil {
+		ch.ocspTimer.Stop()
+		ch.ocspTimer = nil
+	}
+	if next.IsZero() {
+		return
+	}
+	ch.ocspTimer = time.AfterFunc(time.Until(next), func() {
+		ch.WithField("next_update", next.UTC().Format(time.RFC3339)).Info("OCSP response is stale, rebuilding")
+		ch.OnChange(b)
+	})
+}
+
+// UpdateCaches replaces the contents of the caches with the resources
+// generated from root. Unlike OnChange it neither writes the status of
+// IngressRoutes nor records metrics.
+func (ch *CacheHandler) UpdateCaches(root dag.Visitable) {
+	ch.updateFleets(root)
+	// update clusters before the listeners and routes which
+	// refer to them, so the notifications of an aggregated
+	// stream arrive in the order Envoy expects.
+	ch.updateSecrets(root)
+	ch.updateClusters(root)
+	ch.updateListeners(root)
+	ch.updateRoutes(root)
 }
 
 func (ch *CacheHandler) setIngressRouteStatus(st statusable) {
-	for _, s := range st.Statuses() {
-		err := ch.IngressRouteStatus.SetStatus(s.Status, s.Description, s.Object)
+	ch.mu.Lock()
+	ch.last = st
+	ch.mu.Unlock()
+	ch.writeIngressRouteStatus()
+}
+
+// writeIngressRouteStatus writes the status of each IngressRoute
+// of the last DAG.
+func (ch *CacheHandler) writeIngressRouteStatus() {
+	ch.writeMu.Lock()
+	defer ch.writeMu.Unlock()
+
+	// take a snapshot of the statuses so the API server is
+	// not written to while ch.mu is held.
+	type update struct {
+		status ingressroutev1.Status
+		object *ingressroutev1.IngressRoute
+	}
+	var updates []update
+	ch.mu.Lock()
+	if ch.last != nil {
+		for _, s := range ch.last.Statuses() {
+			s.Status, s.Description = ch.rejectedStatus(s)
+			updates = append(updates, update{status: ch.last.IngressRouteStatus(s), object: s.Object})
+		}
+	}
+	ch.mu.Unlock()
+
+	for _, u := range updates {
+		err := ch.IngressRouteStatus.SetStatus(u.status, u.object)
 		if err != nil {
 			ch.Errorf("Error Setting Status of IngressRoute: ", err)
 		}
 	}
 }
 
+// Rejected marks the valid IngressRoutes whose virtual hosts are
+// named in message, the error Envoy returned when it rejected a
+// response, as invalid. Their status is written by RefreshStatus.
+func (ch *CacheHandler) Rejected(typeURL, message string) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.rejections == nil {
+		ch.rejections = make(map[string]int)
+	}
+	ch.rejections[message]++
+	ch.queueStatusRefresh()
+}
+
+// Resolved restores the status of the IngressRoutes marked invalid
+// by Rejected once no stream is rejecting responses with message.
+// Their status is written by RefreshStatus.
+func (ch *CacheHandler) Resolved(typeURL, message string) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.rejections[message] == 0 {
+		return
+	}
+	ch.rejections[message]--
+	if ch.rejections[message] > 0 {
+		return
+	}
+	delete(ch.rejections, message)
+	ch.queueStatusRefresh()
+}
+
+// queueStatusRefresh signals RefreshStatus without waiting for it.
+// ch.mu must be held.
+func (ch *CacheHandler) queueStatusRefresh() {
+	if ch.refresh == nil {
+		ch.refresh = make(chan struct{}, 1)
+	}
+	select {
+	case ch.refresh <- struct{}{}:
+	default:
+		// a refresh is already queued.
+	}
+}
+
+// RefreshStatus fulfills the g.Start contract. It writes the status
+// of the IngressRoutes of the last DAG statusRefreshDelay after the
+// rejections of responses change, and returns when stop is closed.
+func (ch *CacheHandler) RefreshStatus(stop <-chan struct{}) error {
+	ch.mu.Lock()
+	if ch.refresh == nil {
+		ch.refresh = make(chan struct{}, 1)
+	}
+	refresh := ch.refresh
+	ch.mu.Unlock()
+
+	for {
+		select {
+		case <-refresh:
+		case <-stop:
+			return nil
+		}
+		// changes queued while waiting are written
+		// together by the next round.
+		select {
+		case <-time.After(statusRefreshDelay):
+		case <-stop:
+			return nil
+		}
+		ch.writeIngressRouteStatus()
+	}
+}
+
+// rejectedStatus returns the status and description of s, taking
+// into account the errors of rejected responses. ch.mu must be held.
+func (ch *CacheHandler) rejectedStatus(s dag.Status) (string, string) {
+	if s.Vhost == "" || (s.Status != dag.StatusValid && s.Status != dag.StatusWarning) {
+		return s.Status, s.Description
+	}
+	var messages []string
+	for message := range ch.rejections {
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+	for _, message := range messages {
+		if mentionsHost(message, s.Vhost) {
+			return dag.StatusInvalid, "Envoy rejected the configuration of this virtual host: " + message
+		}
+	}
+	return s.Status, s.Description
+}
+
+// mentionsHost returns true if message contains host as a
+// whole hostname, not as part of a longer one.
+func mentionsHost(message, host string) bool {
+	isHostChar := func(i int) bool {
+		if i < 0 || i >= len(message) {
+			return false
+		}
+		c := message[i]
+		return c == '.' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+	for i := 0; ; {
+		j := strings.Index(message[i:], host)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(host)
+		if !isHostChar(start-1) && (!isHostChar(end) || message[end] == '.' && !isHostChar(end+1)) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func (ch *CacheHandler) updateFleets(root dag.Visitable) {
+	fleets := visitFleets(root)
+	ch.SecretCache.SetFleets(fleets)
+	ch.ListenerCache.SetFleets(fleets)
+	ch.RouteCache.SetFleets(fleets)
+}
+
 func (ch *CacheHandler) updateSecrets(root dag.Visitable) {
 	secrets := visitSecrets(root)
 	ch.SecretCache.Update(secrets)
@@ -78,7 +283,7 @@ func (ch *CacheHandler) updateRoutes(root dag.Visitable) {
 }
 
 func (ch *CacheHandler) updateClusters(root dag.Visitable) {
-	clusters := visitClusters(root)
+	clusters := visitClusters(root, &ch.ClusterVisitorConfig)
 	ch.ClusterCache.Update(clusters)
 }
 
@@ -96,7 +301,7 @@ func calculateIngressRouteMetric(st statusable) metrics.IngressRouteMetric {
 
 	for _, v := range st.Statuses() {
 		switch v.Status {
-		case dag.StatusValid:
+		case dag.StatusValid, dag.StatusWarning:
 			metricValid[metrics.Meta{VHost: v.Vhost, Namespace: v.Object.GetNamespace()}]++
 		case dag.StatusInvalid:
 			metricInvalid[metrics.Meta{VHost: v.Vhost, Namespace: v.Object.GetNamespace()}]++
@@ -118,3 +323,78 @@ func calculateIngressRouteMetric(st statusable) metrics.IngressRouteMetric {
 		Root:     metricRoots,
 	}
 }
+
+func (ch *CacheHandler) updateCertificateMetric(root dag.Visitable) {
+	expiry, ocsp := calculateCertificateMetric(root)
+	ch.Metrics.SetCertificateExpiryMetric(expiry)
+	ch.Metrics.SetOCSPResponseMetric(ocsp)
+}
+
+// nextOCSPUpdate returns the earliest next update time, after now, of
+// the OCSP responses of the secure virtual hosts of root, or the zero
+// time if none will go stale.
+func nextOCSPUpdate(root dag.Visitable, now time.Time) time.Time {
+	var next time.Time
+	var visit func(dag.Vertex)
+	visit = func(vertex dag.Vertex) {
+		switch svh := vertex.(type) {
+		case *dag.SecureVirtualHost:
+			if svh.Secret == nil || svh.Secret.OCSP == nil {
+				return
+			}
+			t := svh.Secret.OCSP.NextUpdate
+			if t.IsZero() || !t.After(now) {
+				return
+			}
+			if next.IsZero() || t.Before(next) {
+				next = t
+			}
+		default:
+			vertex.Visit(visit)
+		}
+	}
+	root.Visit(visit)
+	return next
+}
+
+// calculateCertificateMetric returns the expiry time, in seconds since
+// the epoch, of each certificate attached to a secure virtual host, and
+// the state of any OCSP response supplied for those certificates.
+func calculateCertificateMetric(root dag.Visitable) (map[metrics.CertificateMeta]int64, map[metrics.CertificateMeta]metrics.OCSPResponseMetric) {
+	expiry := make(map[metrics.CertificateMeta]int64)
+	ocsp := make(map[metrics.CertificateMeta]metrics.OCSPResponseMetric)
+	var visit func(dag.Vertex)
+	visit = func(vertex dag.Vertex) {
+		switch svh := vertex.(type) {
+		case *dag.SecureVirtualHost:
+			if svh.Secret == nil {
+				// tls passthrough
+				return
+			}
+			m := metrics.CertificateMeta{
+				Name:      svh.Secret.Name(),
+				Namespace: svh.Secret.Namespace(),
+				VHost:     svh.VirtualHost.Name,
+			}
+			if resp := svh.Secret.OCSP; resp != nil {
+				var next int64
+				if !resp.NextUpdate.IsZero() {
+					next = resp.NextUpdate.Unix()
+				}
+				ocsp[m] = metrics.OCSPResponseMetric{
+					NextUpdate: next,
+					Stale:      resp.Stale,
+				}
+			}
+			cert, err := svh.Secret.Certificate()
+			if err != nil || cert == nil {
+				return
+			}
+			expiry[m] = cert.NotAfter.Unix()
+		default:
+			vertex.Visit(visit)
+		}
+	}
+	root.Visit(visit)
+	return expiry, ocsp
+}
@@ -14,12 +14,17 @@
 package contour
 
 import (
+	"io/ioutil"
 	"reflect"
 	"testing"
+	"time"
 
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/apis/generated/clientset/versioned/fake"
 	"github.com/heptio/contour/internal/dag"
+	"github.com/heptio/contour/internal/k8s"
 	"github.com/heptio/contour/internal/metrics"
+	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -549,3 +554,215 @@ func TestIngressRouteMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestMentionsHost(t *testing.T) {
+	tests := map[string]struct {
+		message string
+		host    string
+		want    bool
+	}{
+		"mentioned": {
+			message: "Only unique values for domains are permitted. Duplicate entry of domain example.com",
+			host:    "example.com",
+			want:    true,
+		},
+		"mentioned at end of sentence": {
+			message: "invalid domain example.com.",
+			host:    "example.com",
+			want:    true,
+		},
+		"not mentioned": {
+			message: "Duplicate entry of domain example.org",
+			host:    "example.com",
+			want:    false,
+		},
+		"subdomain mentioned": {
+			message: "Duplicate entry of domain www.example.com",
+			host:    "example.com",
+			want:    false,
+		},
+		"longer host mentioned first": {
+			message: "Duplicate entry of domain www.example.com and example.com",
+			host:    "example.com",
+			want:    true,
+		},
+		"host is the suffix of another": {
+			message: "Duplicate entry of domain ba.com",
+			host:    "a.com",
+			want:    false,
+		},
+		"host is the prefix of another": {
+			message: "Duplicate entry of domain a.com.au",
+			host:    "a.com",
+			want:    false,
+		},
+		"host is hyphenated into another": {
+			message: "Duplicate entry of domain b-a.com",
+			host:    "a.com",
+			want:    false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := mentionsHost(tc.message, tc.host)
+			if got != tc.want {
+				t.Fatalf("expected: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRejectedStatus(t *testing.T) {
+	ch := CacheHandler{
+		rejections: map[string]int{
+			"Duplicate entry of domain example.com": 1,
+		},
+	}
+	tests := map[string]struct {
+		status   dag.Status
+		wantStat string
+		wantDesc string
+	}{
+		"valid, rejected": {
+			status:   dag.Status{Status: dag.StatusValid, Description: "valid IngressRoute", Vhost: "example.com"},
+			wantStat: dag.StatusInvalid,
+			wantDesc: "Envoy rejected the configuration of this virtual host: Duplicate entry of domain example.com",
+		},
+		"valid, not rejected": {
+			status:   dag.Status{Status: dag.StatusValid, Description: "valid IngressRoute", Vhost: "example.org"},
+			wantStat: dag.StatusValid,
+			wantDesc: "valid IngressRoute",
+		},
+		"valid, suffix of a rejected vhost": {
+			status:   dag.Status{Status: dag.StatusValid, Description: "valid IngressRoute", Vhost: "e.com"},
+			wantStat: dag.StatusValid,
+			wantDesc: "valid IngressRoute",
+		},
+		"orphaned": {
+			status:   dag.Status{Status: dag.StatusOrphaned, Description: "this IngressRoute is not part of a delegation chain from a root IngressRoute"},
+			wantStat: dag.StatusOrphaned,
+			wantDesc: "this IngressRoute is not part of a delegation chain from a root IngressRoute",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			stat, desc := ch.rejectedStatus(tc.status)
+			if stat != tc.wantStat || desc != tc.wantDesc {
+				t.Fatalf("expected: %q %q, got: %q %q", tc.wantStat, tc.wantDesc, stat, desc)
+			}
+		})
+	}
+}
+
+func TestRefreshStatus(t *testing.T) {
+	defer func(d time.Duration) { statusRefreshDelay = d }(statusRefreshDelay)
+	statusRefreshDelay = 10 * time.Millisecond
+
+	ir := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "example",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn: "example.com",
+			},
+		},
+	}
+	var b dag.Builder
+	b.Insert(ir)
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	client := fake.NewSimpleClientset(ir)
+	ch := CacheHandler{
+		IngressRouteStatus: &k8s.IngressRouteStatus{Client: client},
+		FieldLogger:        log,
+		last:               b.Build(),
+	}
+
+	// a fleet of Envoys rejecting the same response
+	// does not write the status once per Envoy, nor
+	// does it wait for the status to be written.
+	for i := 0; i < 3; i++ {
+		ch.Rejected("", "Duplicate entry of domain example.com")
+	}
+	if n := len(client.Actions()); n != 0 {
+		t.Fatalf("expected no writes before RefreshStatus, got %d", n)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- ch.RefreshStatus(stop)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(client.Actions()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the status to be written")
+		}
+		time.Sleep(statusRefreshDelay)
+	}
+	time.Sleep(5 * statusRefreshDelay)
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	actions := client.Actions()
+	if len(actions) != 1 || actions[0].GetVerb() != "patch" {
+		t.Fatalf("expected a single patch, got %v", actions)
+	}
+}
+
+func TestNextOCSPUpdate(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	secure := func(name string, ocsp *dag.OCSPResponse) *dag.SecureVirtualHost {
+		return &dag.SecureVirtualHost{
+			VirtualHost: dag.VirtualHost{Name: name},
+			Secret:      &dag.Secret{OCSP: ocsp},
+		}
+	}
+	tests := map[string]struct {
+		root dag.Visitable
+		want time.Time
+	}{
+		"no ocsp responses": {
+			root: &dag.Listener{
+				Port:         443,
+				VirtualHosts: virtualhosts(secure("a.example.com", nil)),
+			},
+		},
+		"earliest next update": {
+			root: &dag.Listener{
+				Port: 443,
+				VirtualHosts: virtualhosts(
+					secure("a.example.com", &dag.OCSPResponse{NextUpdate: now.Add(2 * time.Hour)}),
+					secure("b.example.com", &dag.OCSPResponse{NextUpdate: now.Add(time.Hour)}),
+				),
+			},
+			want: now.Add(time.Hour),
+		},
+		"stale and open ended responses are ignored": {
+			root: &dag.Listener{
+				Port: 443,
+				VirtualHosts: virtualhosts(
+					secure("a.example.com", &dag.OCSPResponse{NextUpdate: now.Add(-time.Hour), Stale: true}),
+					secure("b.example.com", &dag.OCSPResponse{}),
+				),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := nextOCSPUpdate(tc.root, now)
+			if !got.Equal(tc.want) {
+				t.Fatalf("expected: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
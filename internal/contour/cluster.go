@@ -18,6 +18,7 @@ import (
 	"sync"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/gogo/protobuf/proto"
 	"github.com/heptio/contour/internal/dag"
@@ -26,53 +27,30 @@ import (
 
 // ClusterCache manages the contents of the gRPC CDS cache.
 type ClusterCache struct {
-	mu      sync.Mutex
-	values  map[string]*v2.Cluster
-	waiters []chan int
-	last    int
-}
+	Cond
 
-// Register registers ch to receive a value when Notify is called.
-// The value of last is the count of the times Notify has been called on this Cache.
-// It functions of a sequence counter, if the value of last supplied to Register
-// is less than the Cache's internal counter, then the caller has missed at least
-// one notification and will fire immediately.
-//
-// Sends by the broadcaster to ch must not block, therefor ch must have a capacity
-// of at least 1.
-func (c *ClusterCache) Register(ch chan int, last int) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	mu     sync.Mutex
+	values map[string]*v2.Cluster
 
-	if last < c.last {
-		// notify this channel immediately
-		ch <- c.last
-		return
-	}
-	c.waiters = append(c.waiters, ch)
+	// versions of values, computed on demand and by Update.
+	versions map[string]string
 }
 
 // Update replaces the contents of the cache with the supplied map.
+// Only the waiters interested in the clusters which changed are notified.
 func (c *ClusterCache) Update(v map[string]*v2.Cluster) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	old := c.currentVersions()
 	c.values = v
-	c.notify()
+	c.versions = nil
+	c.notifyChanged(changedNames(old, c.currentVersions()))
 }
 
-// notify notifies all registered waiters that an event has occurred.
-func (c *ClusterCache) notify() {
-	c.last++
-
-	for _, ch := range c.waiters {
-		ch <- c.last
-	}
-	c.waiters = c.waiters[:0]
-}
-
-// Contents returns a copy of the cache's contents.
-func (c *ClusterCache) Contents() []proto.Message {
+// Contents returns a copy of the cache's contents. Every node
+// is sent the same clusters.
+func (c *ClusterCache) Contents(_ *core.Node) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	var values []proto.Message
@@ -83,7 +61,26 @@ func (c *ClusterCache) Contents() []proto.Message {
 	return values
 }
 
-func (c *ClusterCache) Query(names []string) []proto.Message {
+// Versions returns the version of each cluster, keyed by name.
+func (c *ClusterCache) Versions(_ *core.Node) map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currentVersions()
+}
+
+// currentVersions returns the versions of the cache's contents,
+// computing them if needed. c.mu must be held.
+func (c *ClusterCache) currentVersions() map[string]string {
+	if c.versions == nil {
+		c.versions = make(map[string]string, len(c.values))
+		for name, v := range c.values {
+			c.versions[name] = resourceVersion(v)
+		}
+	}
+	return c.versions
+}
+
+func (c *ClusterCache) Query(_ *core.Node, names []string) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	var values []proto.Message
@@ -109,14 +106,31 @@ func (c clusterByName) Less(i, j int) bool { return c[i].(*v2.Cluster).Name < c[
 
 func (*ClusterCache) TypeURL() string { return cache.ClusterType }
 
+// ClusterVisitorConfig holds configuration parameters for visitClusters.
+type ClusterVisitorConfig struct {
+	// DNSLookupFamily is the DNS IP address resolution policy for
+	// clusters discovered via DNS, such as those for ExternalName
+	// services; one of auto, v4, or v6.
+	// If not set, defaults to auto.
+	DNSLookupFamily string
+
+	// ADS configures clusters discovered via EDS to fetch their
+	// endpoints over Envoy's aggregated discovery stream.
+	// If not set, defaults to false.
+	ADS bool
+}
+
 type clusterVisitor struct {
+	*ClusterVisitorConfig
+
 	clusters map[string]*v2.Cluster
 }
 
 // visitCluster produces a map of *v2.Clusters.
-func visitClusters(root dag.Vertex) map[string]*v2.Cluster {
+func visitClusters(root dag.Vertex, cvc *ClusterVisitorConfig) map[string]*v2.Cluster {
 	cv := clusterVisitor{
-		clusters: make(map[string]*v2.Cluster),
+		ClusterVisitorConfig: cvc,
+		clusters:             make(map[string]*v2.Cluster),
 	}
 	cv.visit(root)
 	return cv.clusters
@@ -125,16 +139,16 @@ func visitClusters(root dag.Vertex) map[string]*v2.Cluster {
 func (v *clusterVisitor) visit(vertex dag.Vertex) {
 	if cluster, ok := vertex.(*dag.Cluster); ok {
 		switch cluster.Upstream.(type) {
-		case *dag.HTTPService:
-			name := envoy.Clustername(cluster)
-			if _, ok := v.clusters[name]; !ok {
-				c := envoy.Cluster(cluster)
-				v.clusters[c.Name] = c
-			}
-		case *dag.TCPService:
+		case *dag.HTTPService, *dag.TCPService:
 			name := envoy.Clustername(cluster)
 			if _, ok := v.clusters[name]; !ok {
 				c := envoy.Cluster(cluster)
+				if t, ok := c.ClusterDiscoveryType.(*v2.Cluster_Type); ok && t.Type == v2.Cluster_STRICT_DNS {
+					c.DnsLookupFamily = envoy.DNSLookupFamily(v.DNSLookupFamily)
+				}
+				if v.ADS && c.EdsClusterConfig != nil {
+					c.EdsClusterConfig.EdsConfig = envoy.ADSConfigSource()
+				}
 				v.clusters[c.Name] = c
 			}
 		default:
@@ -20,6 +20,7 @@ import (
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	"github.com/gogo/protobuf/proto"
 	"github.com/google/go-cmp/cmp"
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
@@ -76,7 +77,7 @@ func TestClusterCacheContents(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var cc ClusterCache
 			cc.Update(tc.contents)
-			got := cc.Contents()
+			got := cc.Contents(nil)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -173,7 +174,7 @@ func TestClusterCacheQuery(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var cc ClusterCache
 			cc.Update(tc.contents)
-			got := cc.Query(tc.query)
+			got := cc.Query(nil, tc.query)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -183,6 +184,7 @@ func TestClusterCacheQuery(t *testing.T) {
 
 func TestClusterVisit(t *testing.T) {
 	tests := map[string]struct {
+		ClusterVisitorConfig
 		objs []interface{}
 		want map[string]*v2.Cluster
 	}{
@@ -226,6 +228,45 @@ func TestClusterVisit(t *testing.T) {
 					CommonLbConfig: envoy.ClusterCommonLBConfig(),
 				}),
 		},
+		"single unnamed service, ads": {
+			ClusterVisitorConfig: ClusterVisitorConfig{
+				ADS: true,
+			},
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						Backend: &v1beta1.IngressBackend{
+							ServiceName: "kuard",
+							ServicePort: intstr.FromInt(443),
+						},
+					},
+				},
+				service("default", "kuard",
+					v1.ServicePort{
+						Protocol:   "TCP",
+						Port:       443,
+						TargetPort: intstr.FromInt(8443),
+					},
+				),
+			},
+			want: clustermap(
+				&v2.Cluster{
+					Name:                 "default/kuard/443/da39a3ee5e",
+					AltStatName:          "default_kuard_443",
+					ClusterDiscoveryType: envoy.ClusterDiscoveryType(v2.Cluster_EDS),
+					EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+						EdsConfig:   envoy.ADSConfigSource(),
+						ServiceName: "default/kuard",
+					},
+					ConnectTimeout: 250 * time.Millisecond,
+					LbPolicy:       v2.Cluster_ROUND_ROBIN,
+					CommonLbConfig: envoy.ClusterCommonLBConfig(),
+				}),
+		},
 		"single named service": {
 			objs: []interface{}{
 				&v1beta1.Ingress{
@@ -854,6 +895,96 @@ func TestClusterVisit(t *testing.T) {
 					CommonLbConfig: envoy.ClusterCommonLBConfig(),
 				}),
 		},
+		"externalname service": {
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						Backend: &v1beta1.IngressBackend{
+							ServiceName: "kuard",
+							ServicePort: intstr.FromInt(80),
+						},
+					},
+				},
+				externalnameservice("default", "kuard", "foo.io",
+					v1.ServicePort{
+						Protocol:   "TCP",
+						Port:       80,
+						TargetPort: intstr.FromInt(8080),
+					},
+				),
+			},
+			want: clustermap(externalnamecluster("default/kuard/80/da39a3ee5e", "default/kuard/", "default_kuard_80", "foo.io", 80, v2.Cluster_AUTO)),
+		},
+		"externalname service, ipv6 dns lookup family": {
+			ClusterVisitorConfig: ClusterVisitorConfig{
+				DNSLookupFamily: "v6",
+			},
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						Backend: &v1beta1.IngressBackend{
+							ServiceName: "kuard",
+							ServicePort: intstr.FromInt(80),
+						},
+					},
+				},
+				externalnameservice("default", "kuard", "foo.io",
+					v1.ServicePort{
+						Protocol:   "TCP",
+						Port:       80,
+						TargetPort: intstr.FromInt(8080),
+					},
+				),
+			},
+			want: clustermap(externalnamecluster("default/kuard/80/da39a3ee5e", "default/kuard/", "default_kuard_80", "foo.io", 80, v2.Cluster_V6_ONLY)),
+		},
+		"eds service ignores dns lookup family": {
+			ClusterVisitorConfig: ClusterVisitorConfig{
+				DNSLookupFamily: "v6",
+			},
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						Backend: &v1beta1.IngressBackend{
+							ServiceName: "kuard",
+							ServicePort: intstr.FromInt(443),
+						},
+					},
+				},
+				service("default", "kuard",
+					v1.ServicePort{
+						Protocol:   "TCP",
+						Port:       443,
+						TargetPort: intstr.FromInt(8443),
+					},
+				),
+			},
+			want: clustermap(
+				&v2.Cluster{
+					Name:                 "default/kuard/443/da39a3ee5e",
+					AltStatName:          "default_kuard_443",
+					ClusterDiscoveryType: envoy.ClusterDiscoveryType(v2.Cluster_EDS),
+					EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+						EdsConfig:   envoy.ConfigSource("contour"),
+						ServiceName: "default/kuard",
+					},
+					ConnectTimeout: 250 * time.Millisecond,
+					LbPolicy:       v2.Cluster_ROUND_ROBIN,
+					CommonLbConfig: envoy.ClusterCommonLBConfig(),
+				}),
+		},
 	}
 
 	for name, tc := range tests {
@@ -867,7 +998,7 @@ func TestClusterVisit(t *testing.T) {
 				reh.OnAdd(o)
 			}
 			root := reh.Build()
-			got := visitClusters(root)
+			got := visitClusters(root, &tc.ClusterVisitorConfig)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -892,6 +1023,46 @@ func serviceWithAnnotations(ns, name string, annotations map[string]string, port
 	}
 }
 
+func externalnameservice(ns, name, externalname string, ports ...v1.ServicePort) *v1.Service {
+	s := service(ns, name, ports...)
+	s.Spec.Type = v1.ServiceTypeExternalName
+	s.Spec.ExternalName = externalname
+	return s
+}
+
+func externalnamecluster(name, servicename, statName, externalName string, port int, family v2.Cluster_DnsLookupFamily) *v2.Cluster {
+	return &v2.Cluster{
+		Name:                 name,
+		ClusterDiscoveryType: envoy.ClusterDiscoveryType(v2.Cluster_STRICT_DNS),
+		AltStatName:          statName,
+		ConnectTimeout:       250 * time.Millisecond,
+		LbPolicy:             v2.Cluster_ROUND_ROBIN,
+		CommonLbConfig:       envoy.ClusterCommonLBConfig(),
+		DnsLookupFamily:      family,
+		LoadAssignment: &v2.ClusterLoadAssignment{
+			ClusterName: servicename,
+			Endpoints: []endpoint.LocalityLbEndpoints{{
+				LbEndpoints: []endpoint.LbEndpoint{{
+					HostIdentifier: &endpoint.LbEndpoint_Endpoint{
+						Endpoint: &endpoint.Endpoint{
+							Address: &core.Address{
+								Address: &core.Address_SocketAddress{
+									SocketAddress: &core.SocketAddress{
+										Address: externalName,
+										PortSpecifier: &core.SocketAddress_PortValue{
+											PortValue: uint32(port),
+										},
+									},
+								},
+							},
+						},
+					},
+				}},
+			}},
+		},
+	}
+}
+
 func clustermap(clusters ...*v2.Cluster) map[string]*v2.Cluster {
 	m := make(map[string]*v2.Cluster)
 	for _, c := range clusters {
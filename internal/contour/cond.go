@@ -20,10 +20,21 @@ import "sync"
 //
 // Unlike sync.Cond, Cond communciates with waiters via channels registered by
 // the waiters. This permits goroutines to wait on Cond events using select.
+//
+// Waiters may register interest in a set of named resources, in which case
+// they are only notified when one of those resources changes.
 type Cond struct {
 	mu      sync.Mutex
-	waiters []chan int
+	waiters map[chan int][]string // registered channels and their hints
 	last    int
+
+	// any is the value of last when any resource last changed,
+	// all is the value of last when every resource last changed,
+	// and changed holds the value of last when each named resource
+	// last changed.
+	any     int
+	all     int
+	changed map[string]int
 }
 
 // Register registers ch to receive a value when Notify is called.
@@ -32,28 +43,107 @@ type Cond struct {
 // is less than the Conds internal counter, then the caller has missed at least
 // one notification and will fire immediately.
 //
+// If hints are supplied, ch is only notified when one of the named resources
+// changes. Registering ch again replaces its hints.
+//
 // Sends by the broadcaster to ch must not block, therefor ch must have a capacity
-// of at least 1.
-func (c *Cond) Register(ch chan int, last int) {
+// of at least 1. If ch already holds a value the send is dropped, the waiter
+// has yet to observe the earlier notification.
+func (c *Cond) Register(ch chan int, last int, hints ...string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if last < c.last {
+	if c.missed(last, hints) {
 		// notify this channel immediately
-		ch <- c.last
+		delete(c.waiters, ch)
+		send(ch, c.last)
 		return
 	}
-	c.waiters = append(c.waiters, ch)
+	if c.waiters == nil {
+		c.waiters = make(map[chan int][]string)
+	}
+	c.waiters[ch] = hints
+}
+
+// missed returns true if a waiter interested in hints, which last
+// observed last, has missed a notification. c.mu must be held.
+func (c *Cond) missed(last int, hints []string) bool {
+	if last < c.all {
+		return true
+	}
+	if len(hints) == 0 {
+		return last < c.any
+	}
+	for _, h := range hints {
+		if last < c.changed[h] {
+			return true
+		}
+	}
+	return false
 }
 
 // Notify notifies all registered waiters that an event has ocured.
-func (c *Cond) Notify() {
+// If names are supplied, they are the names of the resources which
+// changed and only the waiters interested in them are notified.
+func (c *Cond) Notify(names ...string) {
+	if len(names) == 0 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.last++
+		c.any, c.all = c.last, c.last
+		for ch := range c.waiters {
+			send(ch, c.last)
+			delete(c.waiters, ch)
+		}
+		return
+	}
+	c.notifyChanged(names)
+}
+
+// notifyChanged advances the counter and notifies the waiters interested
+// in names. If names is empty, nothing changed and no waiter is notified.
+func (c *Cond) notifyChanged(names []string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.last++
+	if len(names) == 0 {
+		return
+	}
+	c.any = c.last
+	if c.changed == nil {
+		c.changed = make(map[string]int)
+	}
+	for _, name := range names {
+		c.changed[name] = c.last
+	}
+	for ch, hints := range c.waiters {
+		if interested(hints, names) {
+			send(ch, c.last)
+			delete(c.waiters, ch)
+		}
+	}
+}
+
+// interested returns true if a waiter which registered hints is
+// interested in a change to names.
+func interested(hints, names []string) bool {
+	if len(hints) == 0 {
+		return true
+	}
+	for _, h := range hints {
+		for _, n := range names {
+			if h == n {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	for _, ch := range c.waiters {
-		ch <- c.last
+// send sends v to ch unless ch already holds a value.
+func send(ch chan int, v int) {
+	select {
+	case ch <- v:
+	default:
 	}
-	c.waiters = c.waiters[:0]
 }
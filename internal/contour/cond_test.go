@@ -55,3 +55,73 @@ func TestCondRegisterAfterNotifyWithCorrectSequenceShouldNotBroadcast(t *testing
 	default:
 	}
 }
+
+func TestCondNotifyNamesShouldOnlyBroadcastToInterested(t *testing.T) {
+	var c Cond
+	a, b, all := make(chan int, 1), make(chan int, 1), make(chan int, 1)
+	c.Register(a, 0, "a")
+	c.Register(b, 0, "b")
+	c.Register(all, 0)
+	c.Notify("a")
+	for name, ch := range map[string]chan int{"a": a, "all": all} {
+		select {
+		case v := <-ch:
+			if v != 1 {
+				t.Fatal(name, "was notified with the wrong sequence number", v)
+			}
+		default:
+			t.Fatal(name, "was not notified")
+		}
+	}
+	select {
+	case v := <-b:
+		t.Fatal("b was notified of a change to a with seq", v)
+	default:
+	}
+}
+
+func TestCondRegisterAfterUnrelatedNotifyShouldNotBroadcast(t *testing.T) {
+	var c Cond
+	ch := make(chan int, 1)
+	c.Notify("a")
+	c.Register(ch, 1, "b")
+	c.Notify("a")
+	c.Register(ch, 0, "b")
+	select {
+	case v := <-ch:
+		t.Fatal("ch was notified of a change to a with seq", v)
+	default:
+	}
+
+	c.Notify()
+	select {
+	case v := <-ch:
+		if v != 3 {
+			t.Fatal("ch was notified with the wrong sequence number", v)
+		}
+	default:
+		t.Fatal("ch was not notified of a change to every name")
+	}
+}
+
+func TestCondRegisterShouldReplaceHints(t *testing.T) {
+	var c Cond
+	ch := make(chan int, 1)
+	c.Register(ch, 0, "a")
+	c.Register(ch, 0, "b")
+	c.Notify("a")
+	select {
+	case v := <-ch:
+		t.Fatal("ch was notified with replaced hints, seq", v)
+	default:
+	}
+	c.Notify("b")
+	select {
+	case v := <-ch:
+		if v != 2 {
+			t.Fatal("ch was notified with the wrong sequence number", v)
+		}
+	default:
+		t.Fatal("ch was not notified")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"sync"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/gogo/protobuf/proto"
@@ -71,13 +72,20 @@ func (e *EndpointsTranslator) OnDelete(obj interface{}) {
 	}
 }
 
-func (e *EndpointsTranslator) Contents() []proto.Message {
+// Contents returns the cluster load assignments of every service.
+// Every node is sent the same cluster load assignments.
+func (e *EndpointsTranslator) Contents(_ *core.Node) []proto.Message {
 	values := e.clusterLoadAssignmentCache.Contents()
 	sort.Stable(clusterLoadAssignmentsByName(values))
 	return values
 }
 
-func (e *EndpointsTranslator) Query(names []string) []proto.Message {
+// Versions returns the version of each cluster load assignment, keyed by name.
+func (e *EndpointsTranslator) Versions(_ *core.Node) map[string]string {
+	return e.clusterLoadAssignmentCache.Versions()
+}
+
+func (e *EndpointsTranslator) Query(_ *core.Node, names []string) []proto.Message {
 	e.clusterLoadAssignmentCache.mu.Lock()
 	defer e.clusterLoadAssignmentCache.mu.Unlock()
 	var values []proto.Message
@@ -129,7 +137,10 @@ func (e *EndpointsTranslator) recomputeClusterLoadAssignment(oldep, newep *v1.En
 		return
 	}
 
-	defer e.Notify()
+	// only the streams interested in the cluster load assignments
+	// which changed are notified.
+	var changed []string
+	defer func() { e.notifyChanged(changed) }()
 
 	if oldep == nil {
 		oldep = &v1.Endpoints{
@@ -173,7 +184,9 @@ func (e *EndpointsTranslator) recomputeClusterLoadAssignment(oldep, newep *v1.En
 
 	// iterate all the defined clusters and add or update them.
 	for _, a := range clas {
-		e.Add(a)
+		if e.Add(a) {
+			changed = append(changed, a.ClusterName)
+		}
 	}
 
 	// iterate over the ports in the old spec, remove any that are not
@@ -188,34 +201,49 @@ func (e *EndpointsTranslator) recomputeClusterLoadAssignment(oldep, newep *v1.En
 			portname := p.Name
 			if _, ok := clas[portname]; !ok {
 				// port is not present in the list added / updated, so remove it
-				e.Remove(servicename(oldep.ObjectMeta, portname))
+				name := servicename(oldep.ObjectMeta, portname)
+				if e.Remove(name) {
+					changed = append(changed, name)
+				}
 			}
 		}
 	}
 }
 
 type clusterLoadAssignmentCache struct {
-	mu      sync.Mutex
-	entries map[string]*v2.ClusterLoadAssignment
+	mu       sync.Mutex
+	entries  map[string]*v2.ClusterLoadAssignment
+	versions map[string]string
 }
 
 // Add adds an entry to the cache. If a ClusterLoadAssignment with the same
-// name exists, it is replaced.
-func (c *clusterLoadAssignmentCache) Add(a *v2.ClusterLoadAssignment) {
+// name exists, it is replaced. Add returns true if the contents of the
+// entry changed.
+func (c *clusterLoadAssignmentCache) Add(a *v2.ClusterLoadAssignment) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if c.entries == nil {
 		c.entries = make(map[string]*v2.ClusterLoadAssignment)
+		c.versions = make(map[string]string)
 	}
+	v := resourceVersion(a)
+	_, ok := c.entries[a.ClusterName]
+	changed := !ok || c.versions[a.ClusterName] != v
 	c.entries[a.ClusterName] = a
+	c.versions[a.ClusterName] = v
+	return changed
 }
 
 // Remove removes the named entry from the cache. If the entry
 // is not present in the cache, the operation is a no-op.
-func (c *clusterLoadAssignmentCache) Remove(name string) {
+// Remove returns true if the entry was present.
+func (c *clusterLoadAssignmentCache) Remove(name string) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	_, ok := c.entries[name]
 	delete(c.entries, name)
+	delete(c.versions, name)
+	return ok
 }
 
 // Contents returns a copy of the contents of the cache.
@@ -229,6 +257,17 @@ func (c *clusterLoadAssignmentCache) Contents() []proto.Message {
 	return values
 }
 
+// Versions returns the version of each entry, keyed by name.
+func (c *clusterLoadAssignmentCache) Versions() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	versions := make(map[string]string, len(c.versions))
+	for name, v := range c.versions {
+		versions[name] = v
+	}
+	return versions
+}
+
 // servicename returns the name of the cluster this meta and port
 // refers to. The CDS name of the cluster may include additional suffixes
 // but these are not known to EDS.
@@ -52,7 +52,7 @@ func TestEndpointsTranslatorContents(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var et EndpointsTranslator
 			et.entries = tc.contents
-			got := et.Contents()
+			got := et.Contents(nil)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -110,7 +110,7 @@ func TestEndpointCacheQuery(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var et EndpointsTranslator
 			et.entries = tc.contents
-			got := et.Query(tc.query)
+			got := et.Query(nil, tc.query)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -160,7 +160,7 @@ func TestEndpointsTranslatorAddEndpoints(t *testing.T) {
 				FieldLogger: log,
 			}
 			et.OnAdd(tc.ep)
-			got := et.Contents()
+			got := et.Contents(nil)
 			if !reflect.DeepEqual(tc.want, got) {
 				t.Fatalf("got: %v, want: %v", got, tc.want)
 			}
@@ -245,7 +245,7 @@ func TestEndpointsTranslatorRemoveEndpoints(t *testing.T) {
 			}
 			tc.setup(et)
 			et.OnDelete(tc.ep)
-			got := et.Contents()
+			got := et.Contents(nil)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -309,7 +309,7 @@ func TestEndpointsTranslatorRecomputeClusterLoadAssignment(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var et EndpointsTranslator
 			et.recomputeClusterLoadAssignment(tc.oldep, tc.newep)
-			got := et.Contents()
+			got := et.Contents(nil)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -330,7 +330,7 @@ func TestEndpointsTranslatorScaleToZeroEndpoints(t *testing.T) {
 	want := []proto.Message{
 		clusterloadassignment("default/simple", envoy.LBEndpoint("192.168.183.24", 8080)),
 	}
-	got := et.Contents()
+	got := et.Contents(nil)
 
 	if diff := cmp.Diff(want, got); diff != "" {
 		t.Fatal(diff)
@@ -342,7 +342,7 @@ func TestEndpointsTranslatorScaleToZeroEndpoints(t *testing.T) {
 
 	// Assert endpoints are removed
 	want = nil
-	got = et.Contents()
+	got = et.Contents(nil)
 
 	if diff := cmp.Diff(want, got); diff != "" {
 		t.Fatal(diff)
@@ -0,0 +1,299 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/heptio/contour/internal/envoy"
+)
+
+// An ExplainRequest describes a HTTP request to be routed.
+type ExplainRequest struct {
+	// Scheme is http or https. Defaults to http.
+	Scheme string `json:"scheme"`
+
+	// Host is the host of the request. Defaults to the
+	// Host header, ignoring any port.
+	Host string `json:"host"`
+
+	// Path is the path of the request. Defaults to /.
+	Path string `json:"path"`
+
+	// Headers of the request.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Listener, if set, is the name of the additional
+	// listener which received the request.
+	Listener string `json:"listener,omitempty"`
+}
+
+// An Explanation describes how Envoy routes an ExplainRequest.
+type Explanation struct {
+	Request ExplainRequest `json:"request"`
+
+	// RouteConfiguration is the name of the route configuration
+	// used by the listener which received the request.
+	RouteConfiguration string `json:"route_configuration,omitempty"`
+
+	// VirtualHost is the fqdn of the matched virtual host.
+	VirtualHost string `json:"virtual_host,omitempty"`
+
+	// Routes are the prefixes of the matched virtual host's
+	// routes, in the order Envoy tries them.
+	Routes []string `json:"routes,omitempty"`
+
+	// Route is the matched route, if any.
+	Route *ExplainedRoute `json:"route,omitempty"`
+
+	// TCPProxy is set if the connection is proxied, without
+	// being decrypted, to the clusters of a TLS passthrough vhost.
+	TCPProxy []ExplainedCluster `json:"tcpproxy,omitempty"`
+
+	// Reason explains why the request was not routed.
+	Reason string `json:"reason,omitempty"`
+}
+
+// An ExplainedRoute describes the route which matched a request.
+type ExplainedRoute struct {
+	Prefix string `json:"prefix"`
+
+	// Sources are the objects which declared the route; an
+	// Ingress, or the delegation chain from a root IngressRoute.
+	Sources []ExplainedSource `json:"sources,omitempty"`
+
+	// HTTPSRedirect is true if the request is redirected to HTTPS
+	// rather than forwarded to the clusters of the route.
+	HTTPSRedirect bool `json:"https_redirect"`
+
+	PrefixRewrite string `json:"prefix_rewrite,omitempty"`
+	Websocket     bool   `json:"websocket,omitempty"`
+
+	// Clusters the request is forwarded to, and their weights.
+	Clusters    []ExplainedCluster `json:"clusters,omitempty"`
+	TotalWeight uint32             `json:"total_weight,omitempty"`
+}
+
+// An ExplainedSource identifies a Kubernetes object.
+type ExplainedSource struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// An ExplainedCluster describes an Envoy cluster.
+type ExplainedCluster struct {
+	Name    string `json:"name"`
+	Service string `json:"service,omitempty"`
+	Weight  uint32 `json:"weight,omitempty"`
+}
+
+// Explain returns an Explanation of how Envoy routes req using the
+// configuration generated from d. Virtual hosts and routes are matched
+// against the route configurations returned by visitRoutes so their
+// ordering is the same as Envoy's.
+func Explain(d *dag.DAG, req ExplainRequest) *Explanation {
+	req = normalise(req)
+	e := &Explanation{Request: req}
+
+	if req.Scheme == "https" {
+		if svh, ok := lookupVirtualHost(d, req, req.Host).(*dag.SecureVirtualHost); ok && svh.TCPProxy != nil {
+			e.VirtualHost = svh.Name
+			for _, c := range svh.TCPProxy.Clusters {
+				e.TCPProxy = append(e.TCPProxy, explainCluster(c, uint32(c.Weight)))
+			}
+			return e
+		}
+	}
+
+	name := ENVOY_HTTP_LISTENER
+	if req.Scheme == "https" {
+		name = ENVOY_HTTPS_LISTENER
+	}
+	if req.Listener != "" {
+		name = req.Listener
+	}
+	rc, ok := visitRoutes(d)[name]
+	if !ok {
+		e.Reason = fmt.Sprintf("no route configuration named %q", name)
+		return e
+	}
+	e.RouteConfiguration = rc.Name
+
+	vhost, ok := matchVirtualHost(rc.VirtualHosts, req.Host)
+	if !ok {
+		e.Reason = fmt.Sprintf("no virtual host matches host %q", req.Host)
+		return e
+	}
+	// the name of a vhost may be hashed, its first domain is
+	// always the fqdn.
+	e.VirtualHost = vhost.Domains[0]
+
+	var matched *route.Route
+	for i, r := range vhost.Routes {
+		prefix := r.Match.GetPrefix()
+		e.Routes = append(e.Routes, prefix)
+		if matched == nil && strings.HasPrefix(req.Path, prefix) {
+			matched = &vhost.Routes[i]
+		}
+	}
+	if matched == nil {
+		e.Reason = fmt.Sprintf("no route matches path %q", req.Path)
+		return e
+	}
+	e.Route = explainRoute(d, lookupVirtualHost(d, req, e.VirtualHost), matched)
+	return e
+}
+
+// normalise fills in the defaults of req.
+func normalise(req ExplainRequest) ExplainRequest {
+	req.Scheme = strings.ToLower(req.Scheme)
+	if req.Scheme == "" {
+		req.Scheme = "http"
+	}
+	if req.Host == "" {
+		for k, v := range req.Headers {
+			if strings.EqualFold(k, "host") || k == ":authority" {
+				req.Host = v
+			}
+		}
+	}
+	if host, _, err := net.SplitHostPort(req.Host); err == nil {
+		req.Host = host
+	}
+	req.Host = strings.ToLower(req.Host)
+	if req.Path == "" {
+		req.Path = "/"
+	}
+	return req
+}
+
+// matchVirtualHost returns the vhost whose domains match host,
+// falling back to the wildcard vhost as Envoy does.
+func matchVirtualHost(vhosts []route.VirtualHost, host string) (route.VirtualHost, bool) {
+	for _, vh := range vhosts {
+		for _, d := range vh.Domains {
+			if d == host {
+				return vh, true
+			}
+		}
+	}
+	for _, vh := range vhosts {
+		for _, d := range vh.Domains {
+			if d == "*" {
+				return vh, true
+			}
+		}
+	}
+	return route.VirtualHost{}, false
+}
+
+// lookupVirtualHost returns the vhost called name of the listener
+// which received req; a *dag.SecureVirtualHost for https requests,
+// otherwise a *dag.VirtualHost. It returns nil if there is none.
+func lookupVirtualHost(d *dag.DAG, req ExplainRequest, name string) dag.Vertex {
+	var vhost dag.Vertex
+	d.Visit(func(v dag.Vertex) {
+		l, ok := v.(*dag.Listener)
+		if !ok || l.Name != req.Listener {
+			return
+		}
+		switch vh := l.VirtualHosts[name].(type) {
+		case *dag.VirtualHost:
+			if req.Scheme == "http" {
+				vhost = vh
+			}
+		case *dag.SecureVirtualHost:
+			if req.Scheme == "https" {
+				vhost = vh
+			}
+		}
+	})
+	return vhost
+}
+
+// explainRoute returns an ExplainedRoute for the Envoy route r
+// generated from the vhost vertex of d.
+func explainRoute(d *dag.DAG, vertex dag.Vertex, r *route.Route) *ExplainedRoute {
+	er := &ExplainedRoute{
+		Prefix: r.Match.GetPrefix(),
+	}
+
+	// the prefix of each route of a vhost is unique.
+	var dr *dag.Route
+	if vertex != nil {
+		vertex.Visit(func(v dag.Vertex) {
+			if v, ok := v.(*dag.Route); ok && v.Prefix == er.Prefix {
+				dr = v
+			}
+		})
+	}
+	clusters := make(map[string]*dag.Cluster)
+	if dr != nil {
+		for _, s := range d.Sources(dr) {
+			er.Sources = append(er.Sources, ExplainedSource{
+				Kind:      s.Kind,
+				Namespace: s.Namespace,
+				Name:      s.Name,
+			})
+		}
+		er.PrefixRewrite = dr.PrefixRewrite
+		er.Websocket = dr.Websocket
+		for _, c := range dr.Clusters {
+			clusters[envoy.Clustername(c)] = c
+		}
+	}
+
+	switch action := r.Action.(type) {
+	case *route.Route_Redirect:
+		er.HTTPSRedirect = action.Redirect.GetHttpsRedirect()
+	case *route.Route_Route:
+		switch cs := action.Route.ClusterSpecifier.(type) {
+		case *route.RouteAction_Cluster:
+			er.Clusters = append(er.Clusters, explainCluster(clusters[cs.Cluster], 1))
+			er.Clusters[0].Name = cs.Cluster
+			er.TotalWeight = 1
+		case *route.RouteAction_WeightedClusters:
+			for _, wc := range cs.WeightedClusters.Clusters {
+				c := explainCluster(clusters[wc.Name], wc.Weight.GetValue())
+				c.Name = wc.Name
+				er.Clusters = append(er.Clusters, c)
+			}
+			er.TotalWeight = cs.WeightedClusters.TotalWeight.GetValue()
+		}
+	}
+	return er
+}
+
+// explainCluster returns an ExplainedCluster for c, which may be nil.
+func explainCluster(c *dag.Cluster, weight uint32) ExplainedCluster {
+	ec := ExplainedCluster{
+		Weight: weight,
+	}
+	if c == nil {
+		return ec
+	}
+	ec.Name = envoy.Clustername(c)
+	switch s := c.Upstream.(type) {
+	case *dag.HTTPService:
+		ec.Service = fmt.Sprintf("%s/%s:%d", s.Namespace, s.Name, s.Port)
+	case *dag.TCPService:
+		ec.Service = fmt.Sprintf("%s/%s:%d", s.Namespace, s.Name, s.Port)
+	}
+	return ec
+}
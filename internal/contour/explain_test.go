@@ -0,0 +1,335 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestExplain(t *testing.T) {
+	reh := ResourceEventHandler{
+		FieldLogger: testLogger(t),
+		Notifier:    new(nullNotifier),
+		Metrics:     metrics.NewMetrics(prometheus.NewRegistry()),
+	}
+	for _, o := range []interface{}{
+		service("default", "kuard", v1.ServicePort{Protocol: "TCP", Port: 8080}),
+		service("default", "kuarder", v1.ServicePort{Protocol: "TCP", Port: 8080}),
+		service("teama", "kuard", v1.ServicePort{Protocol: "TCP", Port: 8080}),
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret",
+				Namespace: "default",
+			},
+			Data: secretdata("certificate", "key"),
+		},
+		&v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "wildcard",
+				Namespace: "default",
+			},
+			Spec: v1beta1.IngressSpec{
+				Backend: backend("kuard", intstr.FromInt(8080)),
+			},
+		},
+		&ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "root",
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: "kuard.example.com",
+					TLS: &ingressroutev1.TLS{
+						SecretName: "secret",
+					},
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name:   "kuard",
+						Port:   8080,
+						Weight: 90,
+					}, {
+						Name:   "kuarder",
+						Port:   8080,
+						Weight: 10,
+					}},
+				}, {
+					Match: "/api",
+					Delegate: &ingressroutev1.Delegate{
+						Name:      "api",
+						Namespace: "teama",
+					},
+				}, {
+					Match:          "/insecure",
+					PermitInsecure: true,
+					Services: []ingressroutev1.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				}},
+			},
+		},
+		&ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "api",
+				Namespace: "teama",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				Routes: []ingressroutev1.Route{{
+					Match: "/api",
+					Services: []ingressroutev1.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				}},
+			},
+		},
+		&ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "passthrough",
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: "tcp.example.com",
+					TLS: &ingressroutev1.TLS{
+						Passthrough: true,
+					},
+				},
+				TCPProxy: &ingressroutev1.TCPProxy{
+					Services: []ingressroutev1.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				},
+			},
+		},
+	} {
+		reh.OnAdd(o)
+	}
+	d := reh.Build()
+
+	root := ExplainedSource{Kind: "IngressRoute", Namespace: "default", Name: "root"}
+	kuard := ExplainedCluster{
+		Name:    "default/kuard/8080/da39a3ee5e",
+		Service: "default/kuard:8080",
+		Weight:  1,
+	}
+	routes := []string{"/insecure", "/api", "/"}
+
+	tests := map[string]struct {
+		req  ExplainRequest
+		want *Explanation
+	}{
+		"redirected to https": {
+			req: ExplainRequest{
+				Host: "kuard.example.com",
+				Path: "/foo",
+			},
+			want: &Explanation{
+				Request: ExplainRequest{
+					Scheme: "http",
+					Host:   "kuard.example.com",
+					Path:   "/foo",
+				},
+				RouteConfiguration: "ingress_http",
+				VirtualHost:        "kuard.example.com",
+				Routes:             routes,
+				Route: &ExplainedRoute{
+					Prefix:        "/",
+					Sources:       []ExplainedSource{root},
+					HTTPSRedirect: true,
+				},
+			},
+		},
+		"weighted clusters": {
+			req: ExplainRequest{
+				Scheme: "https",
+				Host:   "kuard.example.com",
+			},
+			want: &Explanation{
+				Request: ExplainRequest{
+					Scheme: "https",
+					Host:   "kuard.example.com",
+					Path:   "/",
+				},
+				RouteConfiguration: "ingress_https",
+				VirtualHost:        "kuard.example.com",
+				Routes:             routes,
+				Route: &ExplainedRoute{
+					Prefix:  "/",
+					Sources: []ExplainedSource{root},
+					Clusters: []ExplainedCluster{{
+						Name:    "default/kuard/8080/da39a3ee5e",
+						Service: "default/kuard:8080",
+						Weight:  90,
+					}, {
+						Name:    "default/kuarder/8080/da39a3ee5e",
+						Service: "default/kuarder:8080",
+						Weight:  10,
+					}},
+					TotalWeight: 100,
+				},
+			},
+		},
+		"delegated, host from header": {
+			req: ExplainRequest{
+				Scheme:  "HTTPS",
+				Path:    "/api/v1",
+				Headers: map[string]string{"Host": "Kuard.Example.com:443"},
+			},
+			want: &Explanation{
+				Request: ExplainRequest{
+					Scheme:  "https",
+					Host:    "kuard.example.com",
+					Path:    "/api/v1",
+					Headers: map[string]string{"Host": "Kuard.Example.com:443"},
+				},
+				RouteConfiguration: "ingress_https",
+				VirtualHost:        "kuard.example.com",
+				Routes:             routes,
+				Route: &ExplainedRoute{
+					Prefix: "/api",
+					Sources: []ExplainedSource{
+						root,
+						{Kind: "IngressRoute", Namespace: "teama", Name: "api"},
+					},
+					Clusters: []ExplainedCluster{{
+						Name:    "teama/kuard/8080/da39a3ee5e",
+						Service: "teama/kuard:8080",
+						Weight:  1,
+					}},
+					TotalWeight: 1,
+				},
+			},
+		},
+		"insecure route": {
+			req: ExplainRequest{
+				Host: "kuard.example.com",
+				Path: "/insecure",
+			},
+			want: &Explanation{
+				Request: ExplainRequest{
+					Scheme: "http",
+					Host:   "kuard.example.com",
+					Path:   "/insecure",
+				},
+				RouteConfiguration: "ingress_http",
+				VirtualHost:        "kuard.example.com",
+				Routes:             routes,
+				Route: &ExplainedRoute{
+					Prefix:      "/insecure",
+					Sources:     []ExplainedSource{root},
+					Clusters:    []ExplainedCluster{kuard},
+					TotalWeight: 1,
+				},
+			},
+		},
+		"wildcard": {
+			req: ExplainRequest{
+				Host: "other.example.com",
+				Path: "/",
+			},
+			want: &Explanation{
+				Request: ExplainRequest{
+					Scheme: "http",
+					Host:   "other.example.com",
+					Path:   "/",
+				},
+				RouteConfiguration: "ingress_http",
+				VirtualHost:        "*",
+				Routes:             []string{"/"},
+				Route: &ExplainedRoute{
+					Prefix: "/",
+					Sources: []ExplainedSource{
+						{Kind: "Ingress", Namespace: "default", Name: "wildcard"},
+					},
+					Clusters:    []ExplainedCluster{kuard},
+					TotalWeight: 1,
+				},
+			},
+		},
+		"no virtual host": {
+			req: ExplainRequest{
+				Scheme: "https",
+				Host:   "other.example.com",
+				Path:   "/",
+			},
+			want: &Explanation{
+				Request: ExplainRequest{
+					Scheme: "https",
+					Host:   "other.example.com",
+					Path:   "/",
+				},
+				RouteConfiguration: "ingress_https",
+				Reason:             `no virtual host matches host "other.example.com"`,
+			},
+		},
+		"tls passthrough": {
+			req: ExplainRequest{
+				Scheme: "https",
+				Host:   "tcp.example.com",
+				Path:   "/",
+			},
+			want: &Explanation{
+				Request: ExplainRequest{
+					Scheme: "https",
+					Host:   "tcp.example.com",
+					Path:   "/",
+				},
+				VirtualHost: "tcp.example.com",
+				TCPProxy: []ExplainedCluster{{
+					Name:    "default/kuard/8080/da39a3ee5e",
+					Service: "default/kuard:8080",
+				}},
+			},
+		},
+		"unknown listener": {
+			req: ExplainRequest{
+				Host:     "kuard.example.com",
+				Path:     "/",
+				Listener: "internal",
+			},
+			want: &Explanation{
+				Request: ExplainRequest{
+					Scheme:   "http",
+					Host:     "kuard.example.com",
+					Path:     "/",
+					Listener: "internal",
+				},
+				Reason: `no route configuration named "internal"`,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Explain(d, tc.req)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/heptio/contour/internal/envoy"
+)
+
+// FleetMetadataKey is the field of an Envoy's node metadata which
+// names its fleet. Envoys without it belong to the fleet named by
+// their cluster, the --service-cluster flag of Envoy.
+const FleetMetadataKey = "fleet"
+
+// NodeFleet returns the fleet of node.
+func NodeFleet(node *core.Node) string {
+	if node == nil {
+		return ""
+	}
+	if md := node.Metadata; md != nil {
+		if v, ok := md.Fields[FleetMetadataKey]; ok {
+			return v.GetStringValue()
+		}
+	}
+	return node.Cluster
+}
+
+// Fleets records the fleets of Envoys which listeners, virtual hosts,
+// and secrets are restricted to. Those absent are sent to every Envoy.
+type Fleets struct {
+	Listeners map[string][]string // keyed by listener name
+	Hosts     map[string][]string // keyed by fqdn
+	Secrets   map[string][]string // keyed by secret name
+}
+
+// fleet returns the fleet of node and true if the contents of the
+// caches sent to node are restricted by f. A nil node, an internal
+// caller, is never restricted.
+func (f *Fleets) fleet(node *core.Node) (string, bool) {
+	if node == nil || len(f.Listeners)+len(f.Hosts)+len(f.Secrets) == 0 {
+		return "", false
+	}
+	return NodeFleet(node), true
+}
+
+// allowed returns true if the item called name of restrictions
+// may be sent to the Envoys of fleet.
+func allowed(restrictions map[string][]string, name, fleet string) bool {
+	fleets, ok := restrictions[name]
+	if !ok {
+		return true
+	}
+	for _, f := range fleets {
+		if f == fleet {
+			return true
+		}
+	}
+	return false
+}
+
+// filterChainAllowed returns true if every server name of fc may be
+// sent to the Envoys of fleet.
+func (f *Fleets) filterChainAllowed(fc listener.FilterChain, fleet string) bool {
+	if fc.FilterChainMatch == nil {
+		return true
+	}
+	for _, name := range fc.FilterChainMatch.ServerNames {
+		if !allowed(f.Hosts, name, fleet) {
+			return false
+		}
+	}
+	return true
+}
+
+type fleetVisitor struct {
+	fleets Fleets
+
+	// secrets used by a vhost that is not restricted.
+	unrestricted map[string]bool
+}
+
+// visitFleets returns the fleets each listener, virtual host, and
+// secret of root is restricted to. A secret is only restricted if
+// every vhost which uses it is restricted.
+func visitFleets(root dag.Vertex) Fleets {
+	fv := fleetVisitor{
+		fleets: Fleets{
+			Listeners: make(map[string][]string),
+			Hosts:     make(map[string][]string),
+			Secrets:   make(map[string][]string),
+		},
+		unrestricted: make(map[string]bool),
+	}
+	fv.visit(root)
+	for name := range fv.unrestricted {
+		delete(fv.fleets.Secrets, name)
+	}
+	return fv.fleets
+}
+
+func (v *fleetVisitor) visit(vertex dag.Vertex) {
+	switch vh := vertex.(type) {
+	case *dag.VirtualHost:
+		if len(vh.Fleets) > 0 {
+			v.fleets.Hosts[vh.Name] = vh.Fleets
+		}
+	case *dag.SecureVirtualHost:
+		if len(vh.Fleets) > 0 {
+			v.fleets.Hosts[vh.Name] = vh.Fleets
+		}
+		if vh.Secret == nil {
+			return
+		}
+		name := envoy.Secretname(vh.Secret)
+		if len(vh.Fleets) == 0 {
+			v.unrestricted[name] = true
+			return
+		}
+		v.fleets.Secrets[name] = union(v.fleets.Secrets[name], vh.Fleets)
+	case *dag.TCPListener:
+		if len(vh.Fleets) > 0 {
+			v.fleets.Listeners[tcpListenerName(vh.Port)] = vh.Fleets
+		}
+	default:
+		vertex.Visit(v.visit)
+	}
+}
+
+// union returns the sorted union of the sorted slices a and b.
+func union(a, b []string) []string {
+	var u []string
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || len(a) > 0 && a[0] < b[0]:
+			u, a = append(u, a[0]), a[1:]
+		case len(a) == 0 || b[0] < a[0]:
+			u, b = append(u, b[0]), b[1:]
+		default:
+			u, a, b = append(u, a[0]), a[1:], b[1:]
+		}
+	}
+	return u
+}
+
+// routeConfiguration returns rc without the virtual hosts which
+// may not be sent to the Envoys of fleet.
+func (f *Fleets) routeConfiguration(rc *v2.RouteConfiguration, fleet string) *v2.RouteConfiguration {
+	var vhosts []route.VirtualHost
+	for _, vh := range rc.VirtualHosts {
+		// the name of a vhost may be hashed, its first
+		// domain is always the fqdn.
+		if len(vh.Domains) == 0 || allowed(f.Hosts, vh.Domains[0], fleet) {
+			vhosts = append(vhosts, vh)
+		}
+	}
+	if len(vhosts) == len(rc.VirtualHosts) {
+		return rc
+	}
+	filtered := *rc
+	filtered.VirtualHosts = vhosts
+	return &filtered
+}
+
+// listener returns l without the filter chains which may not be
+// sent to the Envoys of fleet, or nil if l may not be sent to them.
+func (f *Fleets) listener(l *v2.Listener, fleet string) *v2.Listener {
+	if !allowed(f.Listeners, l.Name, fleet) {
+		return nil
+	}
+	var fcs []listener.FilterChain
+	for _, fc := range l.FilterChains {
+		if f.filterChainAllowed(fc, fleet) {
+			fcs = append(fcs, fc)
+		}
+	}
+	switch len(fcs) {
+	case len(l.FilterChains):
+		return l
+	case 0:
+		// a listener without filter chains is invalid.
+		return nil
+	}
+	filtered := *l
+	filtered.FilterChains = fcs
+	return &filtered
+}
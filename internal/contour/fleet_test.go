@@ -0,0 +1,204 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"testing"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/google/go-cmp/cmp"
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeFleet(t *testing.T) {
+	tests := map[string]struct {
+		node *core.Node
+		want string
+	}{
+		"nil": {
+			node: nil,
+			want: "",
+		},
+		"cluster": {
+			node: &core.Node{Cluster: "edge"},
+			want: "edge",
+		},
+		"metadata": {
+			node: &core.Node{
+				Cluster: "edge",
+				Metadata: &types.Struct{
+					Fields: map[string]*types.Value{
+						FleetMetadataKey: {Kind: &types.Value_StringValue{StringValue: "internal"}},
+					},
+				},
+			},
+			want: "internal",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NodeFleet(tc.node)
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestUnion(t *testing.T) {
+	got := union([]string{"a", "c", "d"}, []string{"b", "c", "e"})
+	want := []string{"a", "b", "c", "d", "e"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestCachesRestrictedByFleet(t *testing.T) {
+	ingressroute := func(name string, annotations map[string]string) *ingressroutev1.IngressRoute {
+		return &ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: name + ".example.com",
+					TLS: &ingressroutev1.TLS{
+						SecretName: name,
+					},
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+	secret := func(name string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Data: secretdata(name+" certificate", name+" key"),
+		}
+	}
+
+	reh := ResourceEventHandler{
+		FieldLogger: testLogger(t),
+		Notifier:    new(nullNotifier),
+		Metrics:     metrics.NewMetrics(prometheus.NewRegistry()),
+	}
+	for _, o := range []interface{}{
+		service("default", "kuard", v1.ServicePort{Protocol: "TCP", Port: 8080}),
+		secret("internal"),
+		secret("public"),
+		ingressroute("internal", map[string]string{"contour.heptio.com/fleet": "internal"}),
+		ingressroute("public", nil),
+	} {
+		reh.OnAdd(o)
+	}
+	root := reh.Build()
+
+	var lc ListenerCache
+	var rc RouteCache
+	var sc SecretCache
+	fleets := visitFleets(root)
+	lc.SetFleets(fleets)
+	rc.SetFleets(fleets)
+	sc.SetFleets(fleets)
+	lc.Update(visitListeners(root, new(ListenerVisitorConfig)))
+	rc.Update(visitRoutes(root))
+	sc.Update(visitSecrets(root))
+
+	hosts := func(node *core.Node) []string {
+		var hosts []string
+		for _, m := range rc.Contents(node) {
+			for _, vh := range m.(*v2.RouteConfiguration).VirtualHosts {
+				hosts = append(hosts, "rds "+vh.Domains[0])
+			}
+		}
+		for _, m := range lc.Query(node, []string{ENVOY_HTTPS_LISTENER}) {
+			for _, fc := range m.(*v2.Listener).FilterChains {
+				hosts = append(hosts, "lds "+fc.FilterChainMatch.ServerNames[0])
+			}
+		}
+		return hosts
+	}
+	secrets := func(node *core.Node) []proto.Message {
+		return sc.Contents(node)
+	}
+
+	internal := &core.Node{
+		Cluster: "edge",
+		Metadata: &types.Struct{
+			Fields: map[string]*types.Value{
+				FleetMetadataKey: {Kind: &types.Value_StringValue{StringValue: "internal"}},
+			},
+		},
+	}
+	edge := &core.Node{Cluster: "edge"}
+
+	all := []string{
+		"rds internal.example.com", "rds public.example.com",
+		"rds internal.example.com", "rds public.example.com",
+		"lds internal.example.com", "lds public.example.com",
+	}
+	if diff := cmp.Diff(all, hosts(internal)); diff != "" {
+		t.Fatalf("internal fleet: %s", diff)
+	}
+	if diff := cmp.Diff(all, hosts(nil)); diff != "" {
+		t.Fatalf("no node: %s", diff)
+	}
+	if diff := cmp.Diff([]string{
+		"rds public.example.com",
+		"rds public.example.com",
+		"lds public.example.com",
+	}, hosts(edge)); diff != "" {
+		t.Fatalf("edge fleet: %s", diff)
+	}
+
+	if got := len(secrets(internal)); got != 2 {
+		t.Fatalf("internal fleet: expected 2 secrets, got %d", got)
+	}
+	if got := len(secrets(edge)); got != 1 {
+		t.Fatalf("edge fleet: expected 1 secret, got %d", got)
+	}
+	for name := range sc.Versions(internal) {
+		_, want := sc.Versions(edge)[name]
+		if got := len(sc.Query(edge, []string{name})) == 1; got != want {
+			t.Fatalf("edge fleet: query %q: expected %v, got %v", name, want, got)
+		}
+	}
+
+	// the versions of each fleet follow its own contents.
+	if rc.Versions(edge)[ENVOY_HTTPS_LISTENER] == rc.Versions(internal)[ENVOY_HTTPS_LISTENER] {
+		t.Fatal("expected the edge and internal fleets to have different versions of ingress_https")
+	}
+	if diff := cmp.Diff(rc.Versions(nil), rc.Versions(internal)); diff != "" {
+		t.Fatalf("internal fleet: %s", diff)
+	}
+}
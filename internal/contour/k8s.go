@@ -59,7 +59,7 @@ type Notifier interface {
 func (reh *ResourceEventHandler) OnAdd(obj interface{}) {
 	timer := prometheus.NewTimer(reh.ResourceEventHandlerSummary.With(prometheus.Labels{"op": "OnAdd"}))
 	defer timer.ObserveDuration()
-	if !reh.validIngressClass(obj) {
+	if !reh.ValidIngressClass(obj) {
 		return
 	}
 	reh.WithField("op", "add").Debugf("%T", obj)
@@ -68,7 +68,7 @@ func (reh *ResourceEventHandler) OnAdd(obj interface{}) {
 }
 
 func (reh *ResourceEventHandler) OnUpdate(oldObj, newObj interface{}) {
-	oldValid, newValid := reh.validIngressClass(oldObj), reh.validIngressClass(newObj)
+	oldValid, newValid := reh.ValidIngressClass(oldObj), reh.ValidIngressClass(newObj)
 	switch {
 	case !oldValid && !newValid:
 		// the old object did not match the ingress class, nor does
@@ -107,12 +107,12 @@ func (reh *ResourceEventHandler) update() {
 	reh.OnChange(&reh.Builder)
 }
 
-// validIngressClass returns true iff:
+// ValidIngressClass returns true iff:
 //
 // 1. obj is not of type *v1beta1.Ingress or ingressroutev1.IngressRoute.
 // 2. obj has no ingress.class annotation.
 // 2. obj's ingress.class annotation matches d.IngressClass.
-func (reh *ResourceEventHandler) validIngressClass(obj interface{}) bool {
+func (reh *ResourceEventHandler) ValidIngressClass(obj interface{}) bool {
 	switch i := obj.(type) {
 	case *ingressroutev1.IngressRoute:
 		class, ok := getIngressClassAnnotation(i.Annotations)
@@ -14,11 +14,17 @@
 package contour
 
 import (
+	"fmt"
+	"net"
+	"reflect"
 	"sort"
 	"sync"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/gogo/protobuf/proto"
 	"github.com/heptio/contour/internal/dag"
@@ -28,6 +34,7 @@ import (
 const (
 	ENVOY_HTTP_LISTENER            = "ingress_http"
 	ENVOY_HTTPS_LISTENER           = "ingress_https"
+	ENVOY_TCP_LISTENER_PREFIX      = "ingress_tcp"
 	DEFAULT_HTTP_ACCESS_LOG        = "/dev/stdout"
 	DEFAULT_HTTP_LISTENER_ADDRESS  = "0.0.0.0"
 	DEFAULT_HTTP_LISTENER_PORT     = 8080
@@ -66,6 +73,58 @@ type ListenerVisitorConfig struct {
 	// V1 or V2 preamble.
 	// If not set, defaults to false.
 	UseProxyProto bool
+
+	// AccessLogFormat is the format of the access logs of all
+	// listeners.
+	// If not set, defaults to Envoy's default text format.
+	AccessLogFormat envoy.AccessLogFormat
+
+	// GRPCAccessLog configures the HTTP connection manager of
+	// every listener to also stream its access log entries to
+	// Contour's access log service.
+	// If not set, defaults to false.
+	GRPCAccessLog bool
+
+	// ADS configures listeners to fetch their routes and secrets
+	// over Envoy's aggregated discovery stream.
+	// If not set, defaults to false.
+	ADS bool
+
+	// XffNumTrustedHops is the number of proxies in front of Envoy
+	// whose entries in the X-Forwarded-For header are trusted when
+	// determining the client address.
+	// If not set, defaults to 0; the address of the downstream
+	// connection is the client address.
+	XffNumTrustedHops uint32
+
+	// SkipXffAppend stops Envoy appending the address of the
+	// downstream connection to the X-Forwarded-For header.
+	// If not set, defaults to false.
+	SkipXffAppend bool
+
+	// TrustedCIDRs are the networks of proxies in front of Envoy.
+	// Requests which arrive from these networks trust one more
+	// X-Forwarded-For entry than XffNumTrustedHops.
+	TrustedCIDRs []*net.IPNet
+
+	// AdditionalListeners describes the named listeners,
+	// beyond the default HTTP and HTTPS listeners, which
+	// root IngressRoutes may bind to.
+	AdditionalListeners []AdditionalListener
+}
+
+// AdditionalListener describes an additional named Envoy listener
+// which root IngressRoutes may bind to.
+type AdditionalListener struct {
+	dag.ListenerConfig
+
+	// UseProxyProto configures the listener to expect a PROXY
+	// V1 or V2 preamble.
+	UseProxyProto bool
+
+	// AccessLog is the access log path for this listener.
+	// If not set, defaults to DEFAULT_HTTP_ACCESS_LOG.
+	AccessLog string
 }
 
 // httpAddress returns the port for the HTTP (non TLS)
@@ -124,11 +183,21 @@ func (lvc *ListenerVisitorConfig) httpsAccessLog() string {
 
 // ListenerCache manages the contents of the gRPC LDS cache.
 type ListenerCache struct {
+	Cond
+
 	mu           sync.Mutex
 	values       map[string]*v2.Listener
 	staticValues map[string]*v2.Listener
-	waiters      []chan int
-	last         int
+
+	// versions of values and staticValues, computed on demand and by Update.
+	versions map[string]string
+
+	// fleets restricts the listeners and filter chains sent to each fleet.
+	fleets Fleets
+
+	// values and versions sent to each fleet, computed on demand.
+	fleetValues   map[string]map[string]*v2.Listener
+	fleetVersions map[string]map[string]string
 }
 
 // NewListenerCache returns an instance of a ListenerCache
@@ -141,51 +210,41 @@ func NewListenerCache(address string, port int) ListenerCache {
 	}
 }
 
-// Register registers ch to receive a value when Notify is called.
-// The value of last is the count of the times Notify has been called on this Cache.
-// It functions of a sequence counter, if the value of last supplied to Register
-// is less than the Cache's internal counter, then the caller has missed at least
-// one notification and will fire immediately.
-//
-// Sends by the broadcaster to ch must not block, therefor ch must have a capacity
-// of at least 1.
-func (c *ListenerCache) Register(ch chan int, last int) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if last < c.last {
-		// notify this channel immediately
-		ch <- c.last
-		return
-	}
-	c.waiters = append(c.waiters, ch)
-}
-
 // Update replaces the contents of the cache with the supplied map.
+// Only the waiters interested in the listeners which changed are notified.
 func (c *ListenerCache) Update(v map[string]*v2.Listener) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	old := c.currentVersions()
 	c.values = v
-	c.notify()
+	c.versions = nil
+	c.fleetValues, c.fleetVersions = nil, nil
+	c.notifyChanged(changedNames(old, c.currentVersions()))
 }
 
-// notify notifies all registered waiters that an event has occurred.
-func (c *ListenerCache) notify() {
-	c.last++
+// SetFleets replaces the fleets which restrict the listeners and
+// filter chains sent to each node. Every waiter is notified if
+// they changed.
+func (c *ListenerCache) SetFleets(f Fleets) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	for _, ch := range c.waiters {
-		ch <- c.last
+	if reflect.DeepEqual(c.fleets, f) {
+		return
 	}
-	c.waiters = c.waiters[:0]
+	c.fleets = f
+	c.fleetValues, c.fleetVersions = nil, nil
+	c.Notify()
 }
 
-// Contents returns a copy of the cache's contents.
-func (c *ListenerCache) Contents() []proto.Message {
+// Contents returns a copy of the cache's contents sent to node.
+func (c *ListenerCache) Contents(node *core.Node) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	all, _ := c.valuesFor(node)
 	var values []proto.Message
-	for _, v := range c.values {
+	for _, v := range all {
 		values = append(values, v)
 	}
 	for _, v := range c.staticValues {
@@ -195,12 +254,69 @@ func (c *ListenerCache) Contents() []proto.Message {
 	return values
 }
 
-func (c *ListenerCache) Query(names []string) []proto.Message {
+// Versions returns the version of each listener sent to node,
+// keyed by name.
+func (c *ListenerCache) Versions(node *core.Node) map[string]string {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	_, versions := c.valuesFor(node)
+	return versions
+}
+
+// valuesFor returns the dynamic listeners sent to node, and the
+// versions of those and the static listeners, computing them if
+// needed. c.mu must be held.
+func (c *ListenerCache) valuesFor(node *core.Node) (map[string]*v2.Listener, map[string]string) {
+	fleet, ok := c.fleets.fleet(node)
+	if !ok {
+		return c.values, c.currentVersions()
+	}
+	if values, ok := c.fleetValues[fleet]; ok {
+		return values, c.fleetVersions[fleet]
+	}
+	values := make(map[string]*v2.Listener, len(c.values))
+	versions := make(map[string]string, len(c.values)+len(c.staticValues))
+	for name, v := range c.values {
+		if l := c.fleets.listener(v, fleet); l != nil {
+			values[name] = l
+			versions[name] = resourceVersion(l)
+		}
+	}
+	static := c.currentVersions()
+	for name := range c.staticValues {
+		versions[name] = static[name]
+	}
+	if c.fleetValues == nil {
+		c.fleetValues = make(map[string]map[string]*v2.Listener)
+		c.fleetVersions = make(map[string]map[string]string)
+	}
+	c.fleetValues[fleet] = values
+	c.fleetVersions[fleet] = versions
+	return values, versions
+}
+
+// currentVersions returns the versions of the cache's contents,
+// computing them if needed. c.mu must be held.
+func (c *ListenerCache) currentVersions() map[string]string {
+	if c.versions == nil {
+		c.versions = make(map[string]string, len(c.values)+len(c.staticValues))
+		for name, v := range c.values {
+			c.versions[name] = resourceVersion(v)
+		}
+		for name, v := range c.staticValues {
+			c.versions[name] = resourceVersion(v)
+		}
+	}
+	return c.versions
+}
+
+func (c *ListenerCache) Query(node *core.Node, names []string) []proto.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	all, _ := c.valuesFor(node)
 	var values []proto.Message
 	for _, n := range names {
-		v, ok := c.values[n]
+		v, ok := all[n]
 		if !ok {
 			v, ok = c.staticValues[n]
 			if !ok {
@@ -250,13 +366,13 @@ func visitListeners(root dag.Vertex, lvc *ListenerVisitorConfig) map[string]*v2.
 
 	// add a listener if there are vhosts bound to http.
 	if lv.http {
-		lv.listeners[ENVOY_HTTP_LISTENER] = envoy.Listener(
+		l := envoy.Listener(
 			ENVOY_HTTP_LISTENER,
 			lvc.httpAddress(), lvc.httpPort(),
 			proxyProtocol(lvc.UseProxyProto),
-			envoy.HTTPConnectionManager(ENVOY_HTTP_LISTENER, lvc.httpAccessLog()),
 		)
-
+		l.FilterChains = lvc.httpFilterChains(listener.FilterChain{}, ENVOY_HTTP_LISTENER, lvc.httpAccessLog())
+		lv.listeners[ENVOY_HTTP_LISTENER] = l
 	}
 
 	// remove the https listener if there are no vhosts bound to it.
@@ -277,6 +393,11 @@ func visitListeners(root dag.Vertex, lvc *ListenerVisitorConfig) map[string]*v2.
 	return lv.listeners
 }
 
+// tcpListenerName returns the name of the plain TCP listener on port.
+func tcpListenerName(port int) string {
+	return fmt.Sprintf("%s_%d", ENVOY_TCP_LISTENER_PREFIX, port)
+}
+
 func proxyProtocol(useProxy bool) []listener.ListenerFilter {
 	if useProxy {
 		return []listener.ListenerFilter{
@@ -292,38 +413,178 @@ func secureProxyProtocol(useProxy bool) []listener.ListenerFilter {
 
 func (v *listenerVisitor) visit(vertex dag.Vertex) {
 	switch vh := vertex.(type) {
+	case *dag.Listener:
+		if vh.Name != "" {
+			v.visitAdditionalListener(vh)
+			return
+		}
+		vertex.Visit(v.visit)
 	case *dag.VirtualHost:
 		// we only create on http listener so record the fact
 		// that we need to then double back at the end and add
 		// the listener properly.
 		v.http = true
-	case *dag.SecureVirtualHost:
-		filters := []listener.Filter{
-			envoy.HTTPConnectionManager(ENVOY_HTTPS_LISTENER, v.httpsAccessLog()),
+	case *dag.TCPListener:
+		name := tcpListenerName(vh.Port)
+		address := vh.Address
+		if address == "" {
+			address = v.httpAddress()
 		}
-		alpnProtos := []string{"h2", "http/1.1"}
-		if vh.VirtualHost.TCPProxy != nil {
-			filters = []listener.Filter{
-				envoy.TCPProxy(ENVOY_HTTPS_LISTENER, vh.VirtualHost.TCPProxy, v.httpsAccessLog()),
-			}
-			alpnProtos = nil // do not offer ALPN
+		v.listeners[name] = envoy.Listener(
+			name,
+			address, vh.Port,
+			proxyProtocol(v.UseProxyProto),
+			envoy.TCPProxy(name, vh.TCPProxy, v.accessLog(v.httpAccessLog())),
+		)
+	case *dag.SecureVirtualHost:
+		fcs := v.secureFilterChains(ENVOY_HTTPS_LISTENER, vh, v.httpsAccessLog())
+		v.listeners[ENVOY_HTTPS_LISTENER].FilterChains = append(v.listeners[ENVOY_HTTPS_LISTENER].FilterChains, fcs...)
+	default:
+		// recurse
+		vertex.Visit(v.visit)
+	}
+}
+
+// visitAdditionalListener adds an Envoy listener for the named listener l.
+func (v *listenerVisitor) visitAdditionalListener(l *dag.Listener) {
+	al := v.additionalListener(l.Name)
+	address := stringOrDefault(l.Address, DEFAULT_HTTP_LISTENER_ADDRESS)
+	accessLog := stringOrDefault(al.AccessLog, DEFAULT_HTTP_ACCESS_LOG)
+	useProxyProto := al.UseProxyProto || v.UseProxyProto
+
+	if !al.Secure {
+		el := envoy.Listener(
+			l.Name,
+			address, l.Port,
+			proxyProtocol(useProxyProto),
+		)
+		el.FilterChains = v.httpFilterChains(listener.FilterChain{}, l.Name, accessLog)
+		v.listeners[l.Name] = el
+		return
+	}
+
+	el := envoy.Listener(
+		l.Name,
+		address, l.Port,
+		secureProxyProtocol(useProxyProto),
+	)
+	l.Visit(func(vertex dag.Vertex) {
+		if vh, ok := vertex.(*dag.SecureVirtualHost); ok {
+			el.FilterChains = append(el.FilterChains, v.secureFilterChains(l.Name, vh, accessLog)...)
 		}
+	})
+	sort.SliceStable(el.FilterChains, func(i, j int) bool {
+		return el.FilterChains[i].FilterChainMatch.ServerNames[0] < el.FilterChains[j].FilterChainMatch.ServerNames[0]
+	})
+	v.listeners[l.Name] = el
+}
+
+// accessLog returns an access log filter which writes
+// to path in the configured format.
+func (lvc *ListenerVisitorConfig) accessLog(path string) []*accesslog.AccessLog {
+	return envoy.FormattedFileAccessLog(path, lvc.AccessLogFormat)
+}
+
+// connectionManagerAccessLog returns the access log filters of the
+// HTTP connection manager of the listener called name.
+func (lvc *ListenerVisitorConfig) connectionManagerAccessLog(name, path string) []*accesslog.AccessLog {
+	al := lvc.accessLog(path)
+	if lvc.GRPCAccessLog {
+		al = append(al, envoy.GRPCAccessLog(name)...)
+	}
+	return al
+}
+
+// configSource returns the config source from which listeners
+// fetch their routes and secrets.
+func (lvc *ListenerVisitorConfig) configSource() *core.ConfigSource {
+	if lvc.ADS {
+		return envoy.ADSConfigSource()
+	}
+	return envoy.ConfigSource("contour")
+}
+
+// downstreamTLSContext returns the TLS context of a filter chain
+// which serves the certificate of secret.
+func (lvc *ListenerVisitorConfig) downstreamTLSContext(secret *dag.Secret, tlsMinProtoVersion auth.TlsParameters_TlsProtocol, alpnProtos ...string) *auth.DownstreamTlsContext {
+	tc := envoy.DownstreamTLSContext(envoy.Secretname(secret), tlsMinProtoVersion, alpnProtos...)
+	for _, sds := range tc.CommonTlsContext.TlsCertificateSdsSecretConfigs {
+		sds.SdsConfig = lvc.configSource()
+	}
+	return tc
+}
 
-		fc := listener.FilterChain{
-			FilterChainMatch: &listener.FilterChainMatch{
-				ServerNames: []string{vh.VirtualHost.Name},
-			},
-			Filters: filters,
+// additionalListener returns the configuration of the additional
+// listener called name.
+func (lvc *ListenerVisitorConfig) additionalListener(name string) AdditionalListener {
+	for _, al := range lvc.AdditionalListeners {
+		if al.Name == name {
+			return al
 		}
+	}
+	return AdditionalListener{}
+}
 
-		// attach certificate data to this listener if provided.
+// secureFilterChains returns the filter chains for the secure virtual host vh.
+func (lvc *ListenerVisitorConfig) secureFilterChains(name string, vh *dag.SecureVirtualHost, accessLog string) []listener.FilterChain {
+	fc := listener.FilterChain{
+		FilterChainMatch: &listener.FilterChainMatch{
+			ServerNames: []string{vh.VirtualHost.Name},
+		},
+	}
+
+	if vh.VirtualHost.TCPProxy != nil {
+		fc.Filters = []listener.Filter{
+			envoy.TCPProxy(name, vh.VirtualHost.TCPProxy, lvc.accessLog(accessLog)),
+		}
+		// attach certificate data to this listener if provided,
+		// do not offer ALPN.
 		if vh.Secret != nil {
-			fc.TlsContext = envoy.DownstreamTLSContext(envoy.Secretname(vh.Secret), vh.MinProtoVersion, alpnProtos...)
+			fc.TlsContext = lvc.downstreamTLSContext(vh.Secret, vh.MinProtoVersion)
 		}
+		return []listener.FilterChain{fc}
+	}
 
-		v.listeners[ENVOY_HTTPS_LISTENER].FilterChains = append(v.listeners[ENVOY_HTTPS_LISTENER].FilterChains, fc)
-	default:
-		// recurse
-		vertex.Visit(v.visit)
+	// attach certificate data to this listener if provided.
+	if vh.Secret != nil {
+		fc.TlsContext = lvc.downstreamTLSContext(vh.Secret, vh.MinProtoVersion, "h2", "http/1.1")
+	}
+	return lvc.httpFilterChains(fc, name, accessLog)
+}
+
+// httpFilterChains returns copies of fc with an HTTP connection
+// manager filter for the supplied route and access log. If trusted
+// CIDRs are configured, a second filter chain matches connections
+// from those networks and trusts one more X-Forwarded-For entry.
+func (lvc *ListenerVisitorConfig) httpFilterChains(fc listener.FilterChain, routename, accessLog string) []listener.FilterChain {
+	cid := envoy.ClientIPDetection{
+		XffNumTrustedHops: lvc.XffNumTrustedHops,
+		SkipXffAppend:     lvc.SkipXffAppend,
+	}
+	fc.Filters = []listener.Filter{
+		envoy.ConnectionManager(routename, lvc.connectionManagerAccessLog(routename, accessLog), cid, lvc.configSource()),
+	}
+	if len(lvc.TrustedCIDRs) == 0 {
+		return []listener.FilterChain{fc}
+	}
+
+	trusted := fc
+	match := listener.FilterChainMatch{}
+	if fc.FilterChainMatch != nil {
+		match = *fc.FilterChainMatch
+	}
+	match.SourcePrefixRanges = envoy.CIDRRanges(lvc.TrustedCIDRs)
+	trusted.FilterChainMatch = &match
+	cid.XffNumTrustedHops++
+	trusted.Filters = []listener.Filter{
+		envoy.ConnectionManager(routename, lvc.connectionManagerAccessLog(routename, accessLog), cid, lvc.configSource()),
+	}
+	return []listener.FilterChain{fc, trusted}
+}
+
+func stringOrDefault(s, def string) string {
+	if s == "" {
+		return def
 	}
+	return s
 }
@@ -14,6 +14,7 @@
 package contour
 
 import (
+	"net"
 	"testing"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
@@ -22,6 +23,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/google/go-cmp/cmp"
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/envoy"
 	"github.com/heptio/contour/internal/metrics"
 	"github.com/prometheus/client_golang/prometheus"
@@ -60,7 +62,7 @@ func TestListenerCacheContents(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var lc ListenerCache
 			lc.Update(tc.contents)
-			got := lc.Contents()
+			got := lc.Contents(nil)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -119,7 +121,7 @@ func TestListenerCacheQuery(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var lc ListenerCache
 			lc.Update(tc.contents)
-			got := lc.Query(tc.query)
+			got := lc.Query(nil, tc.query)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -233,6 +235,238 @@ func TestListenerVisit(t *testing.T) {
 				}},
 			}),
 		},
+		"http listener, trusted hops": {
+			ListenerVisitorConfig: ListenerVisitorConfig{
+				XffNumTrustedHops: 2,
+			},
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						Backend: &v1beta1.IngressBackend{
+							ServiceName: "kuard",
+							ServicePort: intstr.FromInt(8080),
+						},
+					},
+				},
+			},
+			want: listenermap(&v2.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: *envoy.SocketAddress("0.0.0.0", 8080),
+				FilterChains: filterchain(envoy.ClientIPConnectionManager(ENVOY_HTTP_LISTENER, envoy.FileAccessLog(DEFAULT_HTTP_ACCESS_LOG), envoy.ClientIPDetection{
+					XffNumTrustedHops: 2,
+				})),
+			}),
+		},
+		"http listener, skip xff append": {
+			ListenerVisitorConfig: ListenerVisitorConfig{
+				SkipXffAppend: true,
+			},
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						Backend: &v1beta1.IngressBackend{
+							ServiceName: "kuard",
+							ServicePort: intstr.FromInt(8080),
+						},
+					},
+				},
+			},
+			want: listenermap(&v2.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: *envoy.SocketAddress("0.0.0.0", 8080),
+				FilterChains: filterchain(envoy.ClientIPConnectionManager(ENVOY_HTTP_LISTENER, envoy.FileAccessLog(DEFAULT_HTTP_ACCESS_LOG), envoy.ClientIPDetection{
+					SkipXffAppend: true,
+				})),
+			}),
+		},
+		"http listener, custom access log format": {
+			ListenerVisitorConfig: ListenerVisitorConfig{
+				AccessLogFormat: envoy.AccessLogFormat{
+					Format: "%START_TIME% %REQ(:AUTHORITY)% %RESPONSE_CODE%",
+				},
+			},
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						Backend: &v1beta1.IngressBackend{
+							ServiceName: "kuard",
+							ServicePort: intstr.FromInt(8080),
+						},
+					},
+				},
+			},
+			want: listenermap(&v2.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: *envoy.SocketAddress("0.0.0.0", 8080),
+				FilterChains: filterchain(envoy.ClientIPConnectionManager(ENVOY_HTTP_LISTENER, envoy.FormattedFileAccessLog(DEFAULT_HTTP_ACCESS_LOG, envoy.AccessLogFormat{
+					Format: "%START_TIME% %REQ(:AUTHORITY)% %RESPONSE_CODE%",
+				}), envoy.ClientIPDetection{})),
+			}),
+		},
+		"http listener, grpc access log": {
+			ListenerVisitorConfig: ListenerVisitorConfig{
+				GRPCAccessLog: true,
+			},
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						Backend: &v1beta1.IngressBackend{
+							ServiceName: "kuard",
+							ServicePort: intstr.FromInt(8080),
+						},
+					},
+				},
+			},
+			want: listenermap(&v2.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: *envoy.SocketAddress("0.0.0.0", 8080),
+				FilterChains: filterchain(envoy.ClientIPConnectionManager(ENVOY_HTTP_LISTENER, append(
+					envoy.FileAccessLog(DEFAULT_HTTP_ACCESS_LOG),
+					envoy.GRPCAccessLog(ENVOY_HTTP_LISTENER)...,
+				), envoy.ClientIPDetection{})),
+			}),
+		},
+		"http and https listeners, trusted cidrs": {
+			ListenerVisitorConfig: ListenerVisitorConfig{
+				XffNumTrustedHops: 1,
+				TrustedCIDRs:      cidrs("10.0.0.0/8", "fd00::/8"),
+			},
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						TLS: []v1beta1.IngressTLS{{
+							Hosts:      []string{"whatever.example.com"},
+							SecretName: "secret",
+						}},
+						Backend: &v1beta1.IngressBackend{
+							ServiceName: "kuard",
+							ServicePort: intstr.FromInt(8080),
+						},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Data: secretdata("certificate", "key"),
+				},
+			},
+			want: listenermap(&v2.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: *envoy.SocketAddress("0.0.0.0", 8080),
+				FilterChains: []listener.FilterChain{{
+					Filters: filters(envoy.ClientIPConnectionManager(ENVOY_HTTP_LISTENER, envoy.FileAccessLog(DEFAULT_HTTP_ACCESS_LOG), envoy.ClientIPDetection{
+						XffNumTrustedHops: 1,
+					})),
+				}, {
+					FilterChainMatch: &listener.FilterChainMatch{
+						SourcePrefixRanges: envoy.CIDRRanges(cidrs("10.0.0.0/8", "fd00::/8")),
+					},
+					Filters: filters(envoy.ClientIPConnectionManager(ENVOY_HTTP_LISTENER, envoy.FileAccessLog(DEFAULT_HTTP_ACCESS_LOG), envoy.ClientIPDetection{
+						XffNumTrustedHops: 2,
+					})),
+				}},
+			}, &v2.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: *envoy.SocketAddress("0.0.0.0", 8443),
+				ListenerFilters: []listener.ListenerFilter{
+					envoy.TLSInspector(),
+				},
+				FilterChains: []listener.FilterChain{{
+					FilterChainMatch: &listener.FilterChainMatch{
+						ServerNames: []string{"whatever.example.com"},
+					},
+					TlsContext: tlscontext(auth.TlsParameters_TLSv1_1, "h2", "http/1.1"),
+					Filters: filters(envoy.ClientIPConnectionManager(ENVOY_HTTPS_LISTENER, envoy.FileAccessLog(DEFAULT_HTTPS_ACCESS_LOG), envoy.ClientIPDetection{
+						XffNumTrustedHops: 1,
+					})),
+				}, {
+					FilterChainMatch: &listener.FilterChainMatch{
+						ServerNames:        []string{"whatever.example.com"},
+						SourcePrefixRanges: envoy.CIDRRanges(cidrs("10.0.0.0/8", "fd00::/8")),
+					},
+					TlsContext: tlscontext(auth.TlsParameters_TLSv1_1, "h2", "http/1.1"),
+					Filters: filters(envoy.ClientIPConnectionManager(ENVOY_HTTPS_LISTENER, envoy.FileAccessLog(DEFAULT_HTTPS_ACCESS_LOG), envoy.ClientIPDetection{
+						XffNumTrustedHops: 2,
+					})),
+				}},
+			}),
+		},
+		"http and https listeners, ads": {
+			ListenerVisitorConfig: ListenerVisitorConfig{
+				ADS: true,
+			},
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						TLS: []v1beta1.IngressTLS{{
+							Hosts:      []string{"whatever.example.com"},
+							SecretName: "secret",
+						}},
+						Backend: &v1beta1.IngressBackend{
+							ServiceName: "kuard",
+							ServicePort: intstr.FromInt(8080),
+						},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Data: secretdata("certificate", "key"),
+				},
+			},
+			want: listenermap(&v2.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: *envoy.SocketAddress("0.0.0.0", 8080),
+				FilterChains: filterchain(envoy.ConnectionManager(ENVOY_HTTP_LISTENER, envoy.FileAccessLog(DEFAULT_HTTP_ACCESS_LOG),
+					envoy.ClientIPDetection{}, envoy.ADSConfigSource())),
+			}, &v2.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: *envoy.SocketAddress("0.0.0.0", 8443),
+				ListenerFilters: []listener.ListenerFilter{
+					envoy.TLSInspector(),
+				},
+				FilterChains: []listener.FilterChain{{
+					FilterChainMatch: &listener.FilterChainMatch{
+						ServerNames: []string{"whatever.example.com"},
+					},
+					TlsContext: func() *auth.DownstreamTlsContext {
+						tc := tlscontext(auth.TlsParameters_TLSv1_1, "h2", "http/1.1")
+						tc.CommonTlsContext.TlsCertificateSdsSecretConfigs[0].SdsConfig = envoy.ADSConfigSource()
+						return tc
+					}(),
+					Filters: filters(envoy.ConnectionManager(ENVOY_HTTPS_LISTENER, envoy.FileAccessLog(DEFAULT_HTTPS_ACCESS_LOG),
+						envoy.ClientIPDetection{}, envoy.ADSConfigSource())),
+				}},
+			}),
+		},
 		"multiple tls ingress with secrets should be sorted": {
 			objs: []interface{}{
 				&v1beta1.Ingress{
@@ -555,6 +789,88 @@ func TestListenerVisit(t *testing.T) {
 				}},
 			}),
 		},
+		"additional listeners": {
+			ListenerVisitorConfig: ListenerVisitorConfig{
+				AdditionalListeners: []AdditionalListener{{
+					ListenerConfig: dag.ListenerConfig{
+						Name:    "internal",
+						Address: "10.0.0.1",
+						Port:    9080,
+					},
+					AccessLog: "/tmp/internal.log",
+				}, {
+					ListenerConfig: dag.ListenerConfig{
+						Name:   "alt-https",
+						Port:   9443,
+						Secure: true,
+					},
+					UseProxyProto: true,
+				}},
+			},
+			objs: []interface{}{
+				&ingressroutev1.IngressRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: ingressroutev1.IngressRouteSpec{
+						VirtualHost: &ingressroutev1.VirtualHost{
+							Fqdn:      "www.example.com",
+							Listeners: []string{"internal", "alt-https"},
+							TLS: &ingressroutev1.TLS{
+								SecretName: "secret",
+							},
+						},
+						Routes: []ingressroutev1.Route{{
+							Match: "/",
+							Services: []ingressroutev1.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Data: secretdata("certificate", "key"),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+			},
+			want: listenermap(&v2.Listener{
+				Name:         "internal",
+				Address:      *envoy.SocketAddress("10.0.0.1", 9080),
+				FilterChains: filterchain(envoy.HTTPConnectionManager("internal", "/tmp/internal.log")),
+			}, &v2.Listener{
+				Name:    "alt-https",
+				Address: *envoy.SocketAddress("0.0.0.0", 9443),
+				ListenerFilters: []listener.ListenerFilter{
+					envoy.ProxyProtocol(),
+					envoy.TLSInspector(),
+				},
+				FilterChains: []listener.FilterChain{{
+					FilterChainMatch: &listener.FilterChainMatch{
+						ServerNames: []string{"www.example.com"},
+					},
+					TlsContext: tlscontext(auth.TlsParameters_TLSv1_1, "h2", "http/1.1"),
+					Filters:    filters(envoy.HTTPConnectionManager("alt-https", DEFAULT_HTTP_ACCESS_LOG)),
+				}},
+			}),
+		},
 		"--envoy-http-access-log": {
 			ListenerVisitorConfig: ListenerVisitorConfig{
 				HTTPAccessLog:  "/tmp/http_access.log",
@@ -613,6 +929,9 @@ func TestListenerVisit(t *testing.T) {
 				Notifier:    new(nullNotifier),
 				Metrics:     metrics.NewMetrics(prometheus.NewRegistry()),
 			}
+			for _, al := range tc.AdditionalListeners {
+				reh.Listeners = append(reh.Listeners, al.ListenerConfig)
+			}
 			for _, o := range tc.objs {
 				reh.OnAdd(o)
 			}
@@ -625,6 +944,18 @@ func TestListenerVisit(t *testing.T) {
 	}
 }
 
+func cidrs(networks ...string) []*net.IPNet {
+	var ipnets []*net.IPNet
+	for _, n := range networks {
+		_, ipnet, err := net.ParseCIDR(n)
+		if err != nil {
+			panic(err)
+		}
+		ipnets = append(ipnets, ipnet)
+	}
+	return ipnets
+}
+
 func filters(first listener.Filter, rest ...listener.Filter) []listener.Filter {
 	return append([]listener.Filter{first}, rest...)
 }
@@ -14,10 +14,12 @@
 package contour
 
 import (
+	"reflect"
 	"sort"
 	"sync"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
 	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/gogo/protobuf/proto"
@@ -28,69 +30,115 @@ import (
 
 // RouteCache manages the contents of the gRPC RDS cache.
 type RouteCache struct {
-	mu      sync.Mutex
-	values  map[string]*v2.RouteConfiguration
-	waiters []chan int
-	last    int
-}
+	Cond
 
-// Register registers ch to receive a value when Notify is called.
-// The value of last is the count of the times Notify has been called on this Cache.
-// It functions of a sequence counter, if the value of last supplied to Register
-// is less than the Cache's internal counter, then the caller has missed at least
-// one notification and will fire immediately.
-//
-// Sends by the broadcaster to ch must not block, therefor ch must have a capacity
-// of at least 1.
-func (c *RouteCache) Register(ch chan int, last int) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	mu     sync.Mutex
+	values map[string]*v2.RouteConfiguration
 
-	if last < c.last {
-		// notify this channel immediately
-		ch <- c.last
-		return
-	}
-	c.waiters = append(c.waiters, ch)
+	// versions of values, computed on demand and by Update.
+	versions map[string]string
+
+	// fleets restricts the virtual hosts sent to each fleet.
+	fleets Fleets
+
+	// values and versions sent to each fleet, computed on demand.
+	fleetValues   map[string]map[string]*v2.RouteConfiguration
+	fleetVersions map[string]map[string]string
 }
 
 // Update replaces the contents of the cache with the supplied map.
+// Only the waiters interested in the route configurations which changed are notified.
 func (c *RouteCache) Update(v map[string]*v2.RouteConfiguration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	old := c.currentVersions()
 	c.values = v
-	c.notify()
+	c.versions = nil
+	c.fleetValues, c.fleetVersions = nil, nil
+	c.notifyChanged(changedNames(old, c.currentVersions()))
 }
 
-// notify notifies all registered waiters that an event has occurred.
-func (c *RouteCache) notify() {
-	c.last++
+// SetFleets replaces the fleets which restrict the virtual hosts
+// sent to each node. Every waiter is notified if they changed.
+func (c *RouteCache) SetFleets(f Fleets) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	for _, ch := range c.waiters {
-		ch <- c.last
+	if reflect.DeepEqual(c.fleets, f) {
+		return
 	}
-	c.waiters = c.waiters[:0]
+	c.fleets = f
+	c.fleetValues, c.fleetVersions = nil, nil
+	c.Notify()
 }
 
-// Contents returns a copy of the cache's contents.
-func (c *RouteCache) Contents() []proto.Message {
+// Contents returns a copy of the cache's contents sent to node.
+func (c *RouteCache) Contents(node *core.Node) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	all, _ := c.valuesFor(node)
 	var values []proto.Message
-	for _, v := range c.values {
+	for _, v := range all {
 		values = append(values, v)
 	}
 	sort.Stable(routeConfigurationsByName(values))
 	return values
 }
 
-func (c *RouteCache) Query(names []string) []proto.Message {
+// Versions returns the version of each route configuration sent
+// to node, keyed by name.
+func (c *RouteCache) Versions(node *core.Node) map[string]string {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	_, versions := c.valuesFor(node)
+	return versions
+}
+
+// valuesFor returns the route configurations sent to node, and their
+// versions, computing them if needed. c.mu must be held.
+func (c *RouteCache) valuesFor(node *core.Node) (map[string]*v2.RouteConfiguration, map[string]string) {
+	fleet, ok := c.fleets.fleet(node)
+	if !ok {
+		return c.values, c.currentVersions()
+	}
+	if values, ok := c.fleetValues[fleet]; ok {
+		return values, c.fleetVersions[fleet]
+	}
+	values := make(map[string]*v2.RouteConfiguration, len(c.values))
+	versions := make(map[string]string, len(c.values))
+	for name, v := range c.values {
+		values[name] = c.fleets.routeConfiguration(v, fleet)
+		versions[name] = resourceVersion(values[name])
+	}
+	if c.fleetValues == nil {
+		c.fleetValues = make(map[string]map[string]*v2.RouteConfiguration)
+		c.fleetVersions = make(map[string]map[string]string)
+	}
+	c.fleetValues[fleet] = values
+	c.fleetVersions[fleet] = versions
+	return values, versions
+}
+
+// currentVersions returns the versions of the cache's contents,
+// computing them if needed. c.mu must be held.
+func (c *RouteCache) currentVersions() map[string]string {
+	if c.versions == nil {
+		c.versions = make(map[string]string, len(c.values))
+		for name, v := range c.values {
+			c.versions[name] = resourceVersion(v)
+		}
+	}
+	return c.versions
+}
+
+func (c *RouteCache) Query(node *core.Node, names []string) []proto.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	all, _ := c.valuesFor(node)
 	var values []proto.Message
 	for _, n := range names {
-		v, ok := c.values[n]
+		v, ok := all[n]
 		if !ok {
 			// if there is no route registered with the cache
 			// we return a blank route configuration. This is
@@ -142,14 +190,21 @@ func visitRoutes(root dag.Vertex) map[string]*v2.RouteConfiguration {
 func (v *routeVisitor) visit(vertex dag.Vertex) {
 	switch l := vertex.(type) {
 	case *dag.Listener:
+		// vhosts bound to an additional listener share a
+		// route configuration named after that listener.
+		httpName, httpsName := "ingress_http", "ingress_https"
+		if l.Name != "" {
+			httpName, httpsName = l.Name, l.Name
+		}
 		l.Visit(func(vertex dag.Vertex) {
 			switch vh := vertex.(type) {
 			case *dag.VirtualHost:
 				vhost := envoy.VirtualHost(vh.Name)
 				vh.Visit(func(v dag.Vertex) {
 					if r, ok := v.(*dag.Route); ok {
-						if len(r.Clusters) < 1 {
+						if len(r.Clusters) < 1 && !r.HTTPSUpgrade {
 							// no services for this route, skip it.
+							// redirects to HTTPS do not need services.
 							return
 						}
 						rr := route.Route{
@@ -169,7 +224,8 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 					return
 				}
 				sort.Stable(sort.Reverse(longestRouteFirst(vhost.Routes)))
-				v.routes["ingress_http"].VirtualHosts = append(v.routes["ingress_http"].VirtualHosts, vhost)
+				rc := v.routeConfiguration(httpName)
+				rc.VirtualHosts = append(rc.VirtualHosts, vhost)
 			case *dag.SecureVirtualHost:
 				vhost := envoy.VirtualHost(vh.VirtualHost.Name)
 				vh.Visit(func(v dag.Vertex) {
@@ -189,7 +245,8 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 					return
 				}
 				sort.Stable(sort.Reverse(longestRouteFirst(vhost.Routes)))
-				v.routes["ingress_https"].VirtualHosts = append(v.routes["ingress_https"].VirtualHosts, vhost)
+				rc := v.routeConfiguration(httpsName)
+				rc.VirtualHosts = append(rc.VirtualHosts, vhost)
 			default:
 				// recurse
 				vertex.Visit(v.visit)
@@ -201,6 +258,19 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 	}
 }
 
+// routeConfiguration returns the route configuration called name,
+// creating it if necessary.
+func (v *routeVisitor) routeConfiguration(name string) *v2.RouteConfiguration {
+	rc, ok := v.routes[name]
+	if !ok {
+		rc = &v2.RouteConfiguration{
+			Name: name,
+		}
+		v.routes[name] = rc
+	}
+	return rc
+}
+
 type virtualHostsByName []route.VirtualHost
 
 func (v virtualHostsByName) Len() int           { return len(v) }
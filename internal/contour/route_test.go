@@ -22,6 +22,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/google/go-cmp/cmp"
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/envoy"
 	"github.com/heptio/contour/internal/metrics"
 	"github.com/prometheus/client_golang/prometheus"
@@ -64,7 +65,7 @@ func TestRouteCacheContents(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var rc RouteCache
 			rc.Update(tc.contents)
-			got := rc.Contents()
+			got := rc.Contents(nil)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -126,7 +127,7 @@ func TestRouteCacheQuery(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var rc RouteCache
 			rc.Update(tc.contents)
-			got := rc.Query(tc.query)
+			got := rc.Query(nil, tc.query)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -615,6 +616,78 @@ func TestRouteVisit(t *testing.T) {
 				},
 			},
 		},
+		"tls passthrough ingressroute with plaintext route and https redirect": {
+			objs: []interface{}{
+				&ingressroutev1.IngressRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: ingressroutev1.IngressRouteSpec{
+						VirtualHost: &ingressroutev1.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &ingressroutev1.TLS{
+								Passthrough: true,
+							},
+						},
+						Routes: []ingressroutev1.Route{{
+							Match: "/.well-known/acme-challenge/",
+							Services: []ingressroutev1.Service{{
+								Name: "kuard",
+								Port: 8080,
+							}},
+						}},
+						TCPProxy: &ingressroutev1.TCPProxy{
+							Services: []ingressroutev1.Service{{
+								Name: "kuard",
+								Port: 8080,
+							}},
+							HTTPSRedirect:   true,
+							PlaintextRoutes: true,
+						},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       8080,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: map[string]*v2.RouteConfiguration{
+				"ingress_http": {
+					Name: "ingress_http",
+					VirtualHosts: []route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: []route.Route{{
+							Match:               envoy.PrefixMatch("/.well-known/acme-challenge/"),
+							Action:              routecluster("default/kuard/8080/da39a3ee5e"),
+							RequestHeadersToAdd: envoy.RouteHeaders(),
+						}, {
+							Match: envoy.PrefixMatch("/"),
+							Action: &route.Route_Redirect{
+								Redirect: &route.RedirectAction{
+									SchemeRewriteSpecifier: &route.RedirectAction_HttpsRedirect{
+										HttpsRedirect: true,
+									},
+								},
+							},
+						}},
+					}},
+				},
+				"ingress_https": {
+					Name: "ingress_https",
+				},
+			},
+		},
 		"ingress with websocket annotation": {
 			objs: []interface{}{
 				&v1beta1.Ingress{
@@ -1842,6 +1915,75 @@ func TestRouteVisit(t *testing.T) {
 	}
 }
 
+func TestRouteVisitAdditionalListener(t *testing.T) {
+	reh := ResourceEventHandler{
+		FieldLogger: testLogger(t),
+		Notifier:    new(nullNotifier),
+		Metrics:     metrics.NewMetrics(prometheus.NewRegistry()),
+	}
+	reh.Listeners = []dag.ListenerConfig{{
+		Name: "internal",
+		Port: 9080,
+	}}
+	reh.OnAdd(&ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: "default",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn:      "www.example.com",
+				Listeners: []string{"internal"},
+			},
+			Routes: []ingressroutev1.Route{{
+				Match: "/",
+				Services: []ingressroutev1.Service{{
+					Name: "backend",
+					Port: 80,
+				}},
+			}},
+		},
+	})
+	reh.OnAdd(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backend",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     80,
+			}},
+		},
+	})
+
+	want := map[string]*v2.RouteConfiguration{
+		"ingress_http": {
+			Name: "ingress_http",
+		},
+		"ingress_https": {
+			Name: "ingress_https",
+		},
+		"internal": {
+			Name: "internal",
+			VirtualHosts: []route.VirtualHost{{
+				Name:    "www.example.com",
+				Domains: domains("www.example.com"),
+				Routes: []route.Route{{
+					Match:               envoy.PrefixMatch("/"),
+					Action:              routecluster("default/backend/80/da39a3ee5e"),
+					RequestHeadersToAdd: envoy.RouteHeaders(),
+				}},
+			}},
+		},
+	}
+	got := visitRoutes(reh.Build())
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
 func domains(hostname string) []string {
 	return []string{hostname, hostname + ":*"}
 }
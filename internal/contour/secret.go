@@ -14,10 +14,12 @@
 package contour
 
 import (
+	"reflect"
 	"sort"
 	"sync"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/gogo/protobuf/proto"
 	"github.com/heptio/contour/internal/dag"
@@ -26,72 +28,102 @@ import (
 
 // SecretCache manages the contents of the gRPC SDS cache.
 type SecretCache struct {
-	mu      sync.Mutex
-	values  map[string]*auth.Secret
-	waiters []chan int
-	last    int
-}
+	Cond
 
-// Register registers ch to receive a value when Notify is called.
-// The value of last is the count of the times Notify has been called on this Cache.
-// It functions of a sequence counter, if the value of last supplied to Register
-// is less than the Cache's internal counter, then the caller has missed at least
-// one notification and will fire immediately.
-//
-// Sends by the broadcaster to ch must not block, therefor ch must have a capacity
-// of at least 1.
-func (c *SecretCache) Register(ch chan int, last int) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	mu     sync.Mutex
+	values map[string]*auth.Secret
 
-	if last < c.last {
-		// notify this channel immediately
-		ch <- c.last
-		return
-	}
-	c.waiters = append(c.waiters, ch)
+	// versions of values, computed on demand and by Update.
+	versions map[string]string
+
+	// fleets restricts the secrets sent to each fleet.
+	fleets Fleets
 }
 
 // Update replaces the contents of the cache with the supplied map.
+// Only the waiters interested in the secrets which changed are notified.
 func (c *SecretCache) Update(v map[string]*auth.Secret) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	old := c.currentVersions()
 	c.values = v
-	c.notify()
+	c.versions = nil
+	c.notifyChanged(changedNames(old, c.currentVersions()))
 }
 
-// notify notifies all registered waiters that an event has occurred.
-func (c *SecretCache) notify() {
-	c.last++
+// SetFleets replaces the fleets which restrict the secrets sent
+// to each node. Every waiter is notified if they changed.
+func (c *SecretCache) SetFleets(f Fleets) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	for _, ch := range c.waiters {
-		ch <- c.last
+	if reflect.DeepEqual(c.fleets, f) {
+		return
 	}
-	c.waiters = c.waiters[:0]
+	c.fleets = f
+	c.Notify()
 }
 
-// Contents returns a copy of the cache's contents.
-func (c *SecretCache) Contents() []proto.Message {
+// Contents returns a copy of the cache's contents sent to node.
+func (c *SecretCache) Contents(node *core.Node) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	var values []proto.Message
-	for _, v := range c.values {
-		values = append(values, v)
+	for name, v := range c.values {
+		if c.allowed(node, name) {
+			values = append(values, v)
+		}
 	}
 	sort.Stable(secretsByName(values))
 	return values
 }
 
-func (c *SecretCache) Query(names []string) []proto.Message {
+// Versions returns the version of each secret sent to node, keyed by name.
+func (c *SecretCache) Versions(node *core.Node) map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fleet, ok := c.fleets.fleet(node)
+	if !ok {
+		return c.currentVersions()
+	}
+	versions := make(map[string]string, len(c.values))
+	for name, v := range c.currentVersions() {
+		if allowed(c.fleets.Secrets, name, fleet) {
+			versions[name] = v
+		}
+	}
+	return versions
+}
+
+// allowed returns true if the secret called name may be sent to
+// node. c.mu must be held.
+func (c *SecretCache) allowed(node *core.Node, name string) bool {
+	fleet, ok := c.fleets.fleet(node)
+	return !ok || allowed(c.fleets.Secrets, name, fleet)
+}
+
+// currentVersions returns the versions of the cache's contents,
+// computing them if needed. c.mu must be held.
+func (c *SecretCache) currentVersions() map[string]string {
+	if c.versions == nil {
+		c.versions = make(map[string]string, len(c.values))
+		for name, v := range c.values {
+			c.versions[name] = resourceVersion(v)
+		}
+	}
+	return c.versions
+}
+
+func (c *SecretCache) Query(node *core.Node, names []string) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	var values []proto.Message
 	for _, n := range names {
 		// we can only return secrets where their value is
-		// known. if the secret is not registered in the cache
-		// we return nothing.
-		if v, ok := c.values[n]; ok {
+		// known, and which may be sent to node. if the secret
+		// is not registered in the cache we return nothing.
+		if v, ok := c.values[n]; ok && c.allowed(node, n) {
 			values = append(values, v)
 		}
 	}
@@ -40,7 +40,7 @@ func TestSecretCacheContents(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var sc SecretCache
 			sc.Update(tc.contents)
-			got := sc.Contents()
+			got := sc.Contents(nil)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -86,7 +86,7 @@ func TestSecretCacheQuery(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var sc SecretCache
 			sc.Update(tc.contents)
-			got := sc.Query(tc.query)
+			got := sc.Query(nil, tc.query)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
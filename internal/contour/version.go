@@ -0,0 +1,50 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// resourceVersion returns the version of m, a hash of its contents.
+// Messages with equal contents have equal versions. The text form of m
+// is hashed, rather than its encoding, as it prints map fields, and
+// the contents of Anys, in a stable order; gogo cannot marshal the
+// go-control-plane types deterministically.
+func resourceVersion(m proto.Message) string {
+	text := proto.TextMarshaler{Compact: true, ExpandAny: true}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(text.Text(m))))[:16]
+}
+
+// changedNames returns the names whose versions differ between
+// before and after, including those present in only one of them.
+func changedNames(before, after map[string]string) []string {
+	var names []string
+	for name, v := range after {
+		if before[name] != v {
+			names = append(names, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,199 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterCacheVersions(t *testing.T) {
+	cluster := func(name, servicename string) *v2.Cluster {
+		return &v2.Cluster{
+			Name: name,
+			EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+				ServiceName: servicename,
+			},
+		}
+	}
+	c1 := cluster("default/kuard/443/da39a3ee5e", "default/kuard")
+	c2 := cluster("default/kuard/443/da39a3ee5e", "default/kuard/https")
+	c3 := cluster("default/httpbin/80/da39a3ee5e", "default/httpbin")
+
+	var cc ClusterCache
+	if got := cc.Versions(nil); len(got) != 0 {
+		t.Fatalf("expected no versions, got: %v", got)
+	}
+
+	cc.Update(map[string]*v2.Cluster{c1.Name: c1, c3.Name: c3})
+	v1 := cc.Versions(nil)
+	if len(v1) != 2 || v1[c1.Name] == "" || v1[c3.Name] == "" {
+		t.Fatalf("expected a version for each cluster, got: %v", v1)
+	}
+
+	// updating with equal contents does not change the versions.
+	cc.Update(map[string]*v2.Cluster{
+		c1.Name: cluster("default/kuard/443/da39a3ee5e", "default/kuard"),
+		c3.Name: c3,
+	})
+	if diff := cmp.Diff(v1, cc.Versions(nil)); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// changing a cluster changes only its version.
+	cc.Update(map[string]*v2.Cluster{c2.Name: c2, c3.Name: c3})
+	v3 := cc.Versions(nil)
+	if v3[c2.Name] == v1[c1.Name] {
+		t.Fatalf("expected version of %q to change", c2.Name)
+	}
+	if v3[c3.Name] != v1[c3.Name] {
+		t.Fatalf("expected version of %q not to change", c3.Name)
+	}
+}
+
+func TestEndpointsTranslatorVersions(t *testing.T) {
+	var et EndpointsTranslator
+	et.Add(&v2.ClusterLoadAssignment{ClusterName: "default/kuard"})
+	et.Add(&v2.ClusterLoadAssignment{ClusterName: "default/httpbin"})
+	versions := et.Versions(nil)
+	if len(versions) != 2 {
+		t.Fatalf("expected two versions, got: %v", versions)
+	}
+
+	et.Remove("default/httpbin")
+	want := map[string]string{
+		"default/kuard": versions["default/kuard"],
+	}
+	if diff := cmp.Diff(want, et.Versions(nil)); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestChangedNames(t *testing.T) {
+	tests := map[string]struct {
+		before, after map[string]string
+		want          []string
+	}{
+		"nothing before": {
+			after: map[string]string{"b": "1", "a": "1"},
+			want:  []string{"a", "b"},
+		},
+		"unchanged": {
+			before: map[string]string{"a": "1"},
+			after:  map[string]string{"a": "1"},
+		},
+		"changed, added, and removed": {
+			before: map[string]string{"a": "1", "b": "1", "c": "1"},
+			after:  map[string]string{"a": "1", "b": "2", "d": "1"},
+			want:   []string{"b", "c", "d"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := changedNames(tc.before, tc.after)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestClusterCacheUpdateNotifiesInterested(t *testing.T) {
+	var cc ClusterCache
+	cc.Update(map[string]*v2.Cluster{
+		"a": {Name: "a"},
+		"b": {Name: "b"},
+	})
+	a, b, all := make(chan int, 1), make(chan int, 1), make(chan int, 1)
+	cc.Register(a, 1, "a")
+	cc.Register(b, 1, "b")
+	cc.Register(all, 1)
+
+	// an update which changes nothing notifies no one.
+	cc.Update(map[string]*v2.Cluster{
+		"a": {Name: "a"},
+		"b": {Name: "b"},
+	})
+	// a change to b notifies b, and those interested in every cluster.
+	cc.Update(map[string]*v2.Cluster{
+		"a": {Name: "a"},
+		"b": {Name: "b", AltStatName: "b"},
+	})
+
+	select {
+	case v := <-a:
+		t.Fatal("a was notified with seq", v)
+	default:
+	}
+	for name, ch := range map[string]chan int{"b": b, "all": all} {
+		select {
+		case v := <-ch:
+			if v != 3 {
+				t.Fatal(name, "was notified with the wrong sequence number", v)
+			}
+		default:
+			t.Fatal(name, "was not notified")
+		}
+	}
+}
+
+func TestEndpointsTranslatorNotifiesInterested(t *testing.T) {
+	var et EndpointsTranslator
+	ep := func(port int32) *v1.Endpoints {
+		return &v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kuard",
+				Namespace: "default",
+			},
+			Subsets: []v1.EndpointSubset{{
+				Addresses: []v1.EndpointAddress{{IP: "192.168.183.24"}},
+				Ports:     []v1.EndpointPort{{Port: port}},
+			}},
+		}
+	}
+	e1 := ep(8080)
+	et.OnAdd(e1)
+
+	kuard, httpbin := make(chan int, 1), make(chan int, 1)
+	et.Register(kuard, 1, "default/kuard")
+	et.Register(httpbin, 1, "default/httpbin")
+
+	// resyncing the same endpoints notifies no one.
+	et.OnUpdate(e1, ep(8080))
+	select {
+	case v := <-kuard:
+		t.Fatal("kuard was notified of an unchanged endpoint with seq", v)
+	default:
+	}
+
+	et.OnUpdate(e1, ep(9000))
+	select {
+	case v := <-kuard:
+		if v != 3 {
+			t.Fatal("kuard was notified with the wrong sequence number", v)
+		}
+	default:
+		t.Fatal("kuard was not notified")
+	}
+	select {
+	case v := <-httpbin:
+		t.Fatal("httpbin was notified of a change to kuard with seq", v)
+	default:
+	}
+}
@@ -78,7 +78,7 @@ func TestVisitClusters(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := visitClusters(tc.root)
+			got := visitClusters(tc.root, new(ClusterVisitorConfig))
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -102,6 +102,7 @@ func TestVisitListeners(t *testing.T) {
 	}
 
 	tests := map[string]struct {
+		ListenerVisitorConfig
 		root dag.Visitable
 		want map[string]*v2.Listener
 	}{
@@ -136,7 +137,57 @@ func TestVisitListeners(t *testing.T) {
 							ServerNames: []string{"tcpproxy.example.com"},
 						},
 						TlsContext: tlscontext(auth.TlsParameters_TLSv1_1),
-						Filters:    filters(envoy.TCPProxy(ENVOY_HTTPS_LISTENER, p1, DEFAULT_HTTPS_ACCESS_LOG)),
+						Filters:    filters(envoy.TCPProxy(ENVOY_HTTPS_LISTENER, p1, envoy.FileAccessLog(DEFAULT_HTTPS_ACCESS_LOG))),
+					}},
+					ListenerFilters: []listener.ListenerFilter{
+						envoy.TLSInspector(),
+					},
+				},
+			),
+		},
+		"TCPService forward, json access log": {
+			ListenerVisitorConfig: ListenerVisitorConfig{
+				AccessLogFormat: envoy.AccessLogFormat{
+					JSONFields: map[string]string{
+						"upstream_cluster": "%UPSTREAM_CLUSTER%",
+					},
+				},
+			},
+			root: &dag.Listener{
+				Port: 443,
+				VirtualHosts: virtualhosts(
+					&dag.SecureVirtualHost{
+						VirtualHost: dag.VirtualHost{
+							Name:     "tcpproxy.example.com",
+							TCPProxy: p1,
+						},
+						Secret: &dag.Secret{
+							Object: &v1.Secret{
+								ObjectMeta: metav1.ObjectMeta{
+									Name:      "secret",
+									Namespace: "default",
+								},
+								Data: secretdata("certificate", "key"),
+							},
+						},
+						MinProtoVersion: auth.TlsParameters_TLSv1_1,
+					},
+				),
+			},
+			want: listenermap(
+				&v2.Listener{
+					Name:    ENVOY_HTTPS_LISTENER,
+					Address: *envoy.SocketAddress("0.0.0.0", 8443),
+					FilterChains: []listener.FilterChain{{
+						FilterChainMatch: &listener.FilterChainMatch{
+							ServerNames: []string{"tcpproxy.example.com"},
+						},
+						TlsContext: tlscontext(auth.TlsParameters_TLSv1_1),
+						Filters: filters(envoy.TCPProxy(ENVOY_HTTPS_LISTENER, p1, envoy.FormattedFileAccessLog(DEFAULT_HTTPS_ACCESS_LOG, envoy.AccessLogFormat{
+							JSONFields: map[string]string{
+								"upstream_cluster": "%UPSTREAM_CLUSTER%",
+							},
+						}))),
 					}},
 					ListenerFilters: []listener.ListenerFilter{
 						envoy.TLSInspector(),
@@ -148,7 +199,7 @@ func TestVisitListeners(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := visitListeners(tc.root, new(ListenerVisitorConfig))
+			got := visitListeners(tc.root, &tc.ListenerVisitorConfig)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -0,0 +1,497 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package crd generates the CustomResourceDefinitions of Contour's
+// custom resources, including their OpenAPI v3 validation schemas,
+// from the Go types of apis/contour/v1beta1.
+//
+// The schema of each field is derived from its type and its doc
+// comment, which becomes the field's description. The following
+// markers, on lines of their own in the doc comment of a field,
+// constrain its value:
+//
+//	+kubebuilder:validation:Required
+//	+kubebuilder:validation:Enum=a;b;"1.3"
+//	+kubebuilder:validation:Minimum=1
+//	+kubebuilder:validation:Maximum=65535
+//	+kubebuilder:validation:Pattern=^/.*$
+package crd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	group   = "contour.heptio.com"
+	version = "v1beta1"
+
+	markerPrefix = "+kubebuilder:validation:"
+)
+
+// Column is an additional printer column of a CustomResourceDefinition.
+type Column struct {
+	Name        string
+	Type        string
+	Description string
+	JSONPath    string
+}
+
+// Definition describes the parts of a CustomResourceDefinition which
+// are not derived from its Go types.
+type Definition struct {
+	// Kind is the kind of the resource, and the name of its Go type.
+	Kind string
+
+	// Plural is the plural name of the resource.
+	Plural string
+
+	// Component is the value of the component label.
+	Component string
+
+	// Columns are the additional columns printed by kubectl get.
+	Columns []Column
+
+	// StatusSubresource enables the status subresource, so that
+	// writes of the status do not change the resource's generation.
+	StatusSubresource bool
+}
+
+// Definitions are the CustomResourceDefinitions of the contour.heptio.com group.
+var Definitions = []Definition{{
+	Kind:      "IngressRoute",
+	Plural:    "ingressroutes",
+	Component: "ingressroute",
+	// Contour records the generation it observed in the status.
+	StatusSubresource: true,
+	Columns: []Column{
+		{Name: "FQDN", Type: "string", Description: "Fully qualified domain name", JSONPath: ".spec.virtualhost.fqdn"},
+		{Name: "TLS Secret", Type: "string", Description: "Secret with TLS credentials", JSONPath: ".spec.virtualhost.tls.secretName"},
+		{Name: "First route", Type: "string", Description: "First routes defined", JSONPath: ".spec.routes[0].match"},
+		{Name: "Status", Type: "string", Description: "The current status of the IngressRoute", JSONPath: ".status.currentStatus"},
+		{Name: "Status Description", Type: "string", Description: "Description of the current status", JSONPath: ".status.description"},
+	},
+}, {
+	Kind:      "TLSCertificateDelegation",
+	Plural:    "tlscertificatedelegations",
+	Component: "tlscertificatedelegation",
+}}
+
+// Schema is an OpenAPI v3 schema.
+type Schema struct {
+	Description string
+	Type        string
+	Format      string
+	Enum        []string
+	Minimum     *int64
+	Maximum     *int64
+	Pattern     string
+	Items       *Schema
+	Required    []string
+	Properties  []Property
+}
+
+// Property is a named property of an object Schema.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Generate writes the CustomResourceDefinitions of defs, whose Go
+// types are declared in the package in dir, to w as YAML.
+func Generate(w io.Writer, dir string, defs []Definition) error {
+	g, err := parseDir(dir)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("# This file is generated from the types of apis/contour/v1beta1 by hack/crdgen.\n")
+	buf.WriteString("# Do not edit this file directly but instead edit the types and run make crds.\n")
+	for _, def := range defs {
+		s, err := g.schema(def.Kind)
+		if err != nil {
+			return err
+		}
+		writeDefinition(&buf, def, s)
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// generator derives schemas from the type declarations of a package.
+type generator struct {
+	types map[string]*ast.TypeSpec
+	docs  map[string]*ast.CommentGroup
+}
+
+func parseDir(dir string) (*generator, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		name := fi.Name()
+		return !strings.HasSuffix(name, "_test.go") && !strings.HasPrefix(name, "zz_generated")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			files = append(files, f)
+		}
+	}
+	return newGenerator(files...), nil
+}
+
+func newGenerator(files ...*ast.File) *generator {
+	g := &generator{
+		types: make(map[string]*ast.TypeSpec),
+		docs:  make(map[string]*ast.CommentGroup),
+	}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				g.types[ts.Name.Name] = ts
+				g.docs[ts.Name.Name] = ts.Doc
+				if ts.Doc == nil && len(gd.Specs) == 1 {
+					g.docs[ts.Name.Name] = gd.Doc
+				}
+			}
+		}
+	}
+	return g
+}
+
+// schema returns the schema of the named type.
+func (g *generator) schema(name string) (*Schema, error) {
+	ts, ok := g.types[name]
+	if !ok {
+		return nil, fmt.Errorf("type %s not found", name)
+	}
+	s, err := g.typeSchema(ts.Type)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	s.Description = description(g.docs[name])
+	return s, nil
+}
+
+// typeSchema returns the schema of the type expression expr.
+func (g *generator) typeSchema(expr ast.Expr) (*Schema, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return &Schema{Type: "string"}, nil
+		case "bool":
+			return &Schema{Type: "boolean"}, nil
+		case "int", "int32", "int64", "uint32", "uint64":
+			return &Schema{Type: "integer"}, nil
+		}
+		ts, ok := g.types[t.Name]
+		if !ok {
+			return nil, fmt.Errorf("type %s not supported", t.Name)
+		}
+		return g.typeSchema(ts.Type)
+	case *ast.StarExpr:
+		return g.typeSchema(t.X)
+	case *ast.ArrayType:
+		items, err := g.typeSchema(t.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case *ast.StructType:
+		s := &Schema{Type: "object"}
+		if err := g.addFields(s, t); err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("type %T not supported", expr)
+	}
+}
+
+// addFields adds the properties of the fields of st to s.
+func (g *generator) addFields(s *Schema, st *ast.StructType) error {
+	for _, field := range st.Fields.List {
+		name, inline := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		if sel, ok := field.Type.(*ast.SelectorExpr); ok {
+			fs := externalSchema(sel)
+			if fs == nil {
+				// the other types of other packages, such as
+				// metav1.ObjectMeta, are validated by the API server.
+				continue
+			}
+			fs.Description = description(field.Doc)
+			s.Properties = append(s.Properties, Property{Name: name, Schema: fs})
+			continue
+		}
+		if inline {
+			ident, ok := field.Type.(*ast.Ident)
+			if !ok || g.types[ident.Name] == nil {
+				return fmt.Errorf("inline field %v not supported", field.Type)
+			}
+			st, ok := g.types[ident.Name].Type.(*ast.StructType)
+			if !ok {
+				return fmt.Errorf("inline field %s not supported", ident.Name)
+			}
+			if err := g.addFields(s, st); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fs, err := g.typeSchema(field.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		fs.Description = description(field.Doc)
+		if fs.Description == "" {
+			if ident, ok := field.Type.(*ast.Ident); ok {
+				// fields without a doc comment of their own,
+				// such as embedded fields, take that of their type.
+				fs.Description = description(g.docs[ident.Name])
+			}
+		}
+		required, err := applyMarkers(fs, field.Doc)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties = append(s.Properties, Property{Name: name, Schema: fs})
+	}
+	return nil
+}
+
+// externalSchema returns the schema of sel, a type of another
+// package, or nil if the type is not described by the schema.
+func externalSchema(sel *ast.SelectorExpr) *Schema {
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	switch pkg.Name + "." + sel.Sel.Name {
+	case "metav1.Time":
+		return &Schema{Type: "string", Format: "date-time"}
+	default:
+		return nil
+	}
+}
+
+// jsonName returns the JSON name of field, and true if the fields of
+// an embedded field are inlined.
+func jsonName(field *ast.Field) (string, bool) {
+	var tag string
+	if field.Tag != nil {
+		tag, _ = strconv.Unquote(field.Tag.Value)
+	}
+	name := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]
+	if len(field.Names) == 0 {
+		// embedded field
+		return name, name == ""
+	}
+	if name == "" {
+		name = field.Names[0].Name
+	}
+	return name, false
+}
+
+// description returns the text of doc, without markers, as one line.
+func description(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	var words []string
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "+") {
+			continue
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+	return strings.Join(words, " ")
+}
+
+// applyMarkers applies the validation markers of doc to s. It returns
+// true if the field is required.
+func applyMarkers(s *Schema, doc *ast.CommentGroup) (bool, error) {
+	if doc == nil {
+		return false, nil
+	}
+	var required bool
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, markerPrefix) {
+			continue
+		}
+		marker := strings.TrimPrefix(line, markerPrefix)
+		kv := strings.SplitN(marker, "=", 2)
+		value := ""
+		if len(kv) == 2 {
+			value = kv[1]
+		}
+		switch kv[0] {
+		case "Required":
+			required = true
+		case "Enum":
+			for _, v := range strings.Split(value, ";") {
+				if u, err := strconv.Unquote(v); err == nil {
+					v = u
+				}
+				s.Enum = append(s.Enum, v)
+			}
+		case "Minimum", "Maximum":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return false, fmt.Errorf("%s: %v", line, err)
+			}
+			if kv[0] == "Minimum" {
+				s.Minimum = &n
+			} else {
+				s.Maximum = &n
+			}
+		case "Pattern":
+			if _, err := regexp.Compile(value); err != nil {
+				return false, fmt.Errorf("%s: %v", line, err)
+			}
+			s.Pattern = value
+		default:
+			return false, fmt.Errorf("unknown marker %s", line)
+		}
+	}
+	return required, nil
+}
+
+func writeDefinition(buf *bytes.Buffer, def Definition, s *Schema) {
+	fmt.Fprintf(buf, "apiVersion: apiextensions.k8s.io/v1beta1\n")
+	fmt.Fprintf(buf, "kind: CustomResourceDefinition\n")
+	fmt.Fprintf(buf, "metadata:\n")
+	fmt.Fprintf(buf, "  name: %s.%s\n", def.Plural, group)
+	fmt.Fprintf(buf, "  labels:\n")
+	fmt.Fprintf(buf, "    component: %s\n", def.Component)
+	fmt.Fprintf(buf, "spec:\n")
+	fmt.Fprintf(buf, "  group: %s\n", group)
+	fmt.Fprintf(buf, "  version: %s\n", version)
+	fmt.Fprintf(buf, "  scope: Namespaced\n")
+	fmt.Fprintf(buf, "  names:\n")
+	fmt.Fprintf(buf, "    plural: %s\n", def.Plural)
+	fmt.Fprintf(buf, "    kind: %s\n", def.Kind)
+	if len(def.Columns) > 0 {
+		fmt.Fprintf(buf, "  additionalPrinterColumns:\n")
+		for _, c := range def.Columns {
+			fmt.Fprintf(buf, "    - name: %s\n", scalar(c.Name))
+			fmt.Fprintf(buf, "      type: %s\n", scalar(c.Type))
+			fmt.Fprintf(buf, "      description: %s\n", scalar(c.Description))
+			fmt.Fprintf(buf, "      JSONPath: %s\n", scalar(c.JSONPath))
+		}
+	}
+	if def.StatusSubresource {
+		fmt.Fprintf(buf, "  subresources:\n")
+		fmt.Fprintf(buf, "    status: {}\n")
+	}
+	fmt.Fprintf(buf, "  validation:\n")
+	fmt.Fprintf(buf, "    openAPIV3Schema:\n")
+	writeSchema(buf, 6, s)
+	fmt.Fprintf(buf, "---\n")
+}
+
+// writeSchema writes s as YAML, indented by indent spaces.
+func writeSchema(buf *bytes.Buffer, indent int, s *Schema) {
+	pad := strings.Repeat(" ", indent)
+	if s.Description != "" {
+		fmt.Fprintf(buf, "%sdescription: %s\n", pad, scalar(s.Description))
+	}
+	fmt.Fprintf(buf, "%stype: %s\n", pad, s.Type)
+	if s.Format != "" {
+		fmt.Fprintf(buf, "%sformat: %s\n", pad, scalar(s.Format))
+	}
+	if len(s.Enum) > 0 {
+		fmt.Fprintf(buf, "%senum:\n", pad)
+		for _, v := range s.Enum {
+			fmt.Fprintf(buf, "%s  - %s\n", pad, scalar(v))
+		}
+	}
+	if s.Minimum != nil {
+		fmt.Fprintf(buf, "%sminimum: %d\n", pad, *s.Minimum)
+	}
+	if s.Maximum != nil {
+		fmt.Fprintf(buf, "%smaximum: %d\n", pad, *s.Maximum)
+	}
+	if s.Pattern != "" {
+		fmt.Fprintf(buf, "%spattern: %s\n", pad, scalar(s.Pattern))
+	}
+	if s.Items != nil {
+		fmt.Fprintf(buf, "%sitems:\n", pad)
+		writeSchema(buf, indent+2, s.Items)
+	}
+	if len(s.Required) > 0 {
+		fmt.Fprintf(buf, "%srequired:\n", pad)
+		for _, r := range s.Required {
+			fmt.Fprintf(buf, "%s  - %s\n", pad, scalar(r))
+		}
+	}
+	if len(s.Properties) > 0 {
+		fmt.Fprintf(buf, "%sproperties:\n", pad)
+		for _, p := range s.Properties {
+			fmt.Fprintf(buf, "%s  %s:\n", pad, scalar(p.Name))
+			writeSchema(buf, indent+4, p.Schema)
+		}
+	}
+}
+
+var plainScalar = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// reserved are the plain scalars YAML does not read as strings.
+var reserved = []string{"false", "n", "no", "null", "off", "on", "true", "y", "yes"}
+
+// scalar returns s as a YAML string; plain if it is a simple word,
+// otherwise double quoted.
+func scalar(s string) string {
+	if plainScalar.MatchString(s) && !isReserved(s) {
+		return s
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s) // strings always encode
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func isReserved(s string) bool {
+	for _, r := range reserved {
+		if strings.EqualFold(s, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestGeneratedCRDsUpToDate fails if the types of apis/contour/v1beta1
+// and the CRDs in examples/common/crds.yaml have drifted apart.
+func TestGeneratedCRDsUpToDate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Generate(&buf, "../../apis/contour/v1beta1", Definitions); err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("../../examples/common/crds.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), buf.String()); diff != "" {
+		t.Fatalf("examples/common/crds.yaml is out of date, run make crds:\n%s", diff)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	min, max := int64(1), int64(65535)
+
+	tests := map[string]struct {
+		src  string
+		want *Schema
+	}{
+		"scalars": {
+			src: `
+// Example is an example.
+type Example struct {
+	// Name is a name.
+	Name string ` + "`json:\"name\"`" + `
+	Enabled bool ` + "`json:\"enabled,omitempty\"`" + `
+	Count uint32
+	Ignored string ` + "`json:\"-\"`" + `
+}`,
+			want: &Schema{
+				Description: "Example is an example.",
+				Type:        "object",
+				Properties: []Property{
+					{Name: "name", Schema: &Schema{Description: "Name is a name.", Type: "string"}},
+					{Name: "enabled", Schema: &Schema{Type: "boolean"}},
+					{Name: "Count", Schema: &Schema{Type: "integer"}},
+				},
+			},
+		},
+		"markers": {
+			src: `
+type Example struct {
+	// Port is a port,
+	// on two lines.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int ` + "`json:\"port\"`" + `
+	// +kubebuilder:validation:Enum=a;"1.3"
+	Version string ` + "`json:\"version\"`" + `
+	// +kubebuilder:validation:Pattern=^\/.*$
+	Path string ` + "`json:\"path\"`" + `
+}`,
+			want: &Schema{
+				Type:     "object",
+				Required: []string{"port"},
+				Properties: []Property{
+					{Name: "port", Schema: &Schema{Description: "Port is a port, on two lines.", Type: "integer", Minimum: &min, Maximum: &max}},
+					{Name: "version", Schema: &Schema{Type: "string", Enum: []string{"a", "1.3"}}},
+					{Name: "path", Schema: &Schema{Type: "string", Pattern: `^\/.*$`}},
+				},
+			},
+		},
+		"nested types": {
+			src: `
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+type Example struct {
+	metav1.TypeMeta ` + "`json:\",inline\"`" + `
+	metav1.ObjectMeta ` + "`json:\"metadata\"`" + `
+	// Time is a time.
+	Time metav1.Time ` + "`json:\"time\"`" + `
+	Items []*Item ` + "`json:\"items\"`" + `
+	Inline ` + "`json:\",inline\"`" + `
+	Status ` + "`json:\"status\"`" + `
+}
+
+type Item struct {
+	Names []string ` + "`json:\"names\"`" + `
+}
+
+type Inline struct {
+	Inlined string ` + "`json:\"inlined\"`" + `
+}
+
+// Status is a status.
+type Status struct {
+	Phase string ` + "`json:\"phase\"`" + `
+}`,
+			want: &Schema{
+				Type: "object",
+				Properties: []Property{{
+					Name:   "time",
+					Schema: &Schema{Description: "Time is a time.", Type: "string", Format: "date-time"},
+				}, {
+					Name: "items",
+					Schema: &Schema{
+						Type: "array",
+						Items: &Schema{
+							Type: "object",
+							Properties: []Property{{
+								Name:   "names",
+								Schema: &Schema{Type: "array", Items: &Schema{Type: "string"}},
+							}},
+						},
+					},
+				}, {
+					Name:   "inlined",
+					Schema: &Schema{Type: "string"},
+				}, {
+					Name: "status",
+					Schema: &Schema{
+						Description: "Status is a status.",
+						Type:        "object",
+						Properties: []Property{{
+							Name:   "phase",
+							Schema: &Schema{Type: "string"},
+						}},
+					},
+				}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := parser.ParseFile(token.NewFileSet(), "example.go", "package example\n"+tc.src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := newGenerator(f).schema("Example")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown marker": `
+type Example struct {
+	// +kubebuilder:validation:MaxLength=5
+	Name string
+}`,
+		"invalid pattern": `
+type Example struct {
+	// +kubebuilder:validation:Pattern=^(
+	Name string
+}`,
+		"unsupported type": `
+type Example struct {
+	Labels map[string]string
+}`,
+	}
+
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := parser.ParseFile(token.NewFileSet(), "example.go", "package example\n"+src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := newGenerator(f).schema("Example"); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestScalar(t *testing.T) {
+	tests := map[string]string{
+		"string":      "string",
+		"must-staple": "must-staple",
+		"1.3":         `"1.3"`,
+		"on":          `"on"`,
+		"Yes":         `"Yes"`,
+		"TLS Secret":  `"TLS Secret"`,
+		`^\/.*$`:      `"^\\/.*$"`,
+		"a <b>":       `"a <b>"`,
+	}
+
+	for s, want := range tests {
+		t.Run(s, func(t *testing.T) {
+			got := scalar(s)
+			if got != want {
+				t.Fatalf("expected %s, got %s", want, got)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ package dag
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -36,6 +37,8 @@ const (
 	annotationRetryOn            = "contour.heptio.com/retry-on"
 	annotationNumRetries         = "contour.heptio.com/num-retries"
 	annotationPerTryTimeout      = "contour.heptio.com/per-try-timeout"
+	annotationLoadReporting      = "contour.heptio.com/load-reporting"
+	annotationFleet              = "contour.heptio.com/fleet"
 )
 
 // parseAnnotation parses the annotation map for the supplied key.
@@ -84,6 +87,20 @@ func tlsRequired(i *v1beta1.Ingress) bool {
 	return i.Annotations["ingress.kubernetes.io/force-ssl-redirect"] == "true"
 }
 
+// fleets returns the sorted, comma separated, fleet names of the
+// contour.heptio.com/fleet annotation, or nil if there are none.
+func fleets(annotations map[string]string) []string {
+	var fleets []string
+	for _, v := range strings.Split(annotations[annotationFleet], ",") {
+		fleet := strings.TrimSpace(v)
+		if fleet != "" {
+			fleets = append(fleets, fleet)
+		}
+	}
+	sort.Strings(fleets)
+	return fleets
+}
+
 func websocketRoutes(i *v1beta1.Ingress) map[string]bool {
 	routes := make(map[string]bool)
 	for _, v := range strings.Split(i.Annotations[annotationWebsocketRoutes], ",") {
@@ -182,6 +182,39 @@ func TestWebsocketRoutes(t *testing.T) {
 	}
 }
 
+func TestFleets(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		want        []string
+	}{
+		"no annotation": {
+			annotations: nil,
+			want:        nil,
+		},
+		"empty with spaces": {
+			annotations: map[string]string{annotationFleet: ", ,"},
+			want:        nil,
+		},
+		"single value": {
+			annotations: map[string]string{annotationFleet: "internal"},
+			want:        []string{"internal"},
+		},
+		"multiple values with spaces are sorted": {
+			annotations: map[string]string{annotationFleet: " internal, edge "},
+			want:        []string{"edge", "internal"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := fleets(tc.annotations)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("fleets(%q): want: %v, got: %v", tc.annotations, tc.want, got)
+			}
+		})
+	}
+}
+
 func TestHttpAllowed(t *testing.T) {
 	tests := map[string]struct {
 		i     *v1beta1.Ingress
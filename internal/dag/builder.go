@@ -33,6 +33,7 @@ const (
 	StatusValid    = "valid"
 	StatusInvalid  = "invalid"
 	StatusOrphaned = "orphaned"
+	StatusWarning  = "warning"
 )
 
 // A Builder builds a *DAGs
@@ -58,8 +59,31 @@ type builder struct {
 	secrets   map[meta]*Secret
 	listeners map[int]*Listener
 
+	// tcplisteners holds the plain TCP listeners declared
+	// by root IngressRoutes, keyed by port.
+	tcplisteners map[int]*TCPListener
+
+	// namedlisteners holds the additional listeners
+	// bound to by root IngressRoutes, keyed by name.
+	namedlisteners map[string]*Listener
+
 	orphaned map[meta]bool
 
+	// warnings records non fatal problems found while
+	// processing an IngressRoute.
+	warnings map[meta][]string
+
+	// errors records every problem which makes an
+	// IngressRoute invalid.
+	errors map[meta][]string
+
+	// checks records the outcome of the checks of the
+	// conditions of each IngressRoute, by condition type.
+	checks map[meta]map[string][]check
+
+	// sources records the objects which declared each vertex.
+	sources map[Vertex][]Source
+
 	statuses []Status
 }
 
@@ -154,6 +178,7 @@ func (b *builder) addHTTPService(svc *v1.Service, port *v1.ServicePort) *HTTPSer
 			MaxRequests:        parseAnnotation(svc.Annotations, annotationMaxRequests),
 			MaxRetries:         parseAnnotation(svc.Annotations, annotationMaxRetries),
 			ExternalName:       externalName(svc),
+			LoadReporting:      svc.Annotations[annotationLoadReporting] == "true",
 		},
 		Protocol: protocol,
 	}
@@ -181,6 +206,7 @@ func (b *builder) addTCPService(svc *v1.Service, port *v1.ServicePort) *TCPServi
 		MaxPendingRequests: parseAnnotation(svc.Annotations, annotationMaxPendingRequests),
 		MaxRequests:        parseAnnotation(svc.Annotations, annotationMaxRequests),
 		MaxRetries:         parseAnnotation(svc.Annotations, annotationMaxRetries),
+		LoadReporting:      svc.Annotations[annotationLoadReporting] == "true",
 	}
 	b.services[s.toMeta()] = s
 	return s
@@ -351,12 +377,7 @@ func (b *builder) validIngressRoutes() []*ingressroutev1.IngressRoute {
 			valid = append(valid, irs[0])
 		default:
 			// multiple irs use the same fqdn. mark them as invalid.
-			var conflicting []string
-			for _, ir := range irs {
-				conflicting = append(conflicting, fmt.Sprintf("%s/%s", ir.Namespace, ir.Name))
-			}
-			sort.Strings(conflicting) // sort for test stability
-			msg := fmt.Sprintf("fqdn %q is used in multiple IngressRoutes: %s", fqdn, strings.Join(conflicting, ", "))
+			msg := fqdnConflict(fqdn, irs)
 			for _, ir := range irs {
 				b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: msg, Vhost: fqdn})
 			}
@@ -372,6 +393,11 @@ func (b *builder) computeSecureVirtualhosts() {
 		for _, tls := range ing.Spec.TLS {
 			m := splitSecret(tls.SecretName, ing.Namespace)
 			if sec := b.lookupSecret(m, validSecret); sec != nil && b.delegationPermitted(m, ing.Namespace) {
+				if _, err := inspectSecret(sec, ""); err != nil {
+					// corrupt certificate or key, do not attach
+					// it to any virtual host.
+					continue
+				}
 				for _, host := range tls.Hosts {
 					svhost := b.lookupSecureVirtualHost(host)
 					svhost.Secret = sec
@@ -423,9 +449,14 @@ func (b *builder) delegationPermitted(secret meta, to string) bool {
 		if d.Namespace != secret.namespace {
 			continue
 		}
-		for _, d := range d.Spec.Delegations {
-			if contains(d.TargetNamespaces, to) {
-				if secret.name == d.SecretName {
+		for i, cd := range d.Spec.Delegations {
+			if len(validateCertificateDelegation(i, cd, d.Namespace)) > 0 {
+				// the webhook rejects such delegations,
+				// ignore any which were admitted anyway.
+				continue
+			}
+			if contains(cd.TargetNamespaces, to) {
+				if secret.name == cd.SecretName {
 					return true
 				}
 			}
@@ -451,14 +482,20 @@ func (b *builder) computeIngresses() {
 				if s := b.lookupHTTPService(m, be.ServicePort); s != nil {
 					r.Clusters = append(r.Clusters, &Cluster{Upstream: s})
 				}
+				source := Source{Kind: "Ingress", Namespace: ing.Namespace, Name: ing.Name}
+				b.addSources(r, source)
 
 				// should we create port 80 routes for this ingress
 				if tlsRequired(ing) || httpAllowed(ing) {
-					b.lookupVirtualHost(host).addRoute(r)
+					vh := b.lookupVirtualHost(host)
+					vh.addRoute(r)
+					b.addSources(vh, source)
 				}
 
 				if b.secureVirtualhostExists(host) && host != "*" {
-					b.lookupSecureVirtualHost(host).addRoute(r)
+					svh := b.lookupSecureVirtualHost(host)
+					svh.addRoute(r)
+					b.addSources(svh, source)
 				}
 			}
 		}
@@ -473,7 +510,9 @@ func stringOrDefault(s, def string) string {
 }
 
 func (b *builder) computeIngressRoutes() {
-	for _, ir := range b.validIngressRoutes() {
+	irs := b.validIngressRoutes()
+	claims := make(map[int][]*ingressroutev1.IngressRoute)
+	for _, ir := range irs {
 		if ir.Spec.VirtualHost == nil {
 			// mark delegate ingressroute orphaned.
 			b.setOrphaned(ir)
@@ -488,41 +527,204 @@ func (b *builder) computeIngressRoutes() {
 
 		host := ir.Spec.VirtualHost.Fqdn
 		if isBlank(host) {
-			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: "Spec.VirtualHost.Fqdn must be specified"})
+			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: fqdnRequired})
+			continue
+		}
+		if err := validateFqdn(host); err != nil {
+			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: err.Error()})
 			continue
 		}
 
-		var enforceTLS, passthrough bool
-		if tls := ir.Spec.VirtualHost.TLS; tls != nil {
-			// attach secrets to TLS enabled vhosts
-			m := splitSecret(tls.SecretName, ir.Namespace)
-			sec := b.lookupSecret(m, validSecret)
-			secretInvalidOrNotFound := sec == nil
-			if sec != nil && b.delegationPermitted(m, ir.Namespace) {
-				svhost := b.lookupSecureVirtualHost(host)
-				svhost.Secret = sec
-				svhost.MinProtoVersion = minProtoVersion(ir.Spec.VirtualHost.TLS.MinimumProtocolVersion)
-				enforceTLS = true
+		if port := ir.Spec.VirtualHost.Port; port != 0 {
+			if b.validTCPListener(ir, host) {
+				claims[port] = append(claims[port], ir)
 			}
-			// passthrough is true if tls.secretName is not present, and
-			// tls.passthrough is set to true.
-			passthrough = isBlank(tls.SecretName) && tls.Passthrough
+			continue
+		}
+
+		if names := ir.Spec.VirtualHost.Listeners; len(names) > 0 {
+			b.computeNamedListeners(ir, host, names)
+			continue
+		}
 
-			// If not passthrough and secret is invalid, then set status
-			if secretInvalidOrNotFound && !passthrough {
-				b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: "TLS Secret not found or is malformed"})
+		b.computeVirtualHost(ir, host)
+		for _, l := range b.listeners {
+			setFleets(l.VirtualHosts[host], fleets(ir.Annotations))
+			b.addSources(l.VirtualHosts[host], ingressRouteSources([]*ingressroutev1.IngressRoute{ir})...)
+		}
+	}
+	b.computeTCPListeners(claims)
+}
+
+// setFleets restricts the vhost v, if any, to fleets.
+func setFleets(v Vertex, fleets []string) {
+	switch vh := v.(type) {
+	case *VirtualHost:
+		vh.Fleets = fleets
+	case *SecureVirtualHost:
+		vh.Fleets = fleets
+	}
+}
+
+// computeVirtualHost adds the vhost described by the root ingressroute ir
+// to the default HTTP and HTTPS listeners.
+func (b *builder) computeVirtualHost(ir *ingressroutev1.IngressRoute, host string) {
+	var enforceTLS, passthrough bool
+	if tls := ir.Spec.VirtualHost.TLS; tls != nil {
+		// attach secrets to TLS enabled vhosts
+		m := splitSecret(tls.SecretName, ir.Namespace)
+		sec := b.lookupSecret(m, validSecret)
+		secretInvalidOrNotFound := sec == nil
+		if sec != nil && !b.delegationPermitted(m, ir.Namespace) {
+			b.setCondition(ir, ConditionTLSReady, "SecretNotDelegated", fmt.Sprintf("TLS Secret %s/%s is not delegated to namespace %q", m.namespace, m.name, ir.Namespace))
+		}
+		if sec != nil && b.delegationPermitted(m, ir.Namespace) {
+			warnings, err := inspectSecret(sec, host)
+			if err != nil {
+				description := fmt.Sprintf("TLS Secret %s/%s: %v", m.namespace, m.name, err)
+				b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: description, Vhost: host})
+				b.setCondition(ir, ConditionTLSReady, "SecretInvalid", description)
+				return
+			}
+			b.setWarnings(ir, warnings...)
+
+			ocsp, warnings, err := b.lookupOCSPResponse(ir, sec)
+			if err != nil {
+				description := fmt.Sprintf("TLS Secret %s/%s: %v", m.namespace, m.name, err)
+				b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: description, Vhost: host})
+				b.setCondition(ir, ConditionTLSReady, "SecretInvalid", description)
+				return
+			}
+			b.setWarnings(ir, warnings...)
+			if ocsp != nil {
+				// the OCSP response, and its stapling policy, is
+				// specific to this vhost so copy the secret.
+				sec = &Secret{
+					Object: sec.Object,
+					OCSP:   ocsp,
+				}
 			}
+
+			svhost := b.lookupSecureVirtualHost(host)
+			svhost.Secret = sec
+			svhost.MinProtoVersion = minProtoVersion(ir.Spec.VirtualHost.TLS.MinimumProtocolVersion)
+			b.setCondition(ir, ConditionTLSReady, "", "")
+			enforceTLS = true
+		}
+		// passthrough is true if tls.secretName is not present, and
+		// tls.passthrough is set to true.
+		passthrough = isBlank(tls.SecretName) && tls.Passthrough
+
+		// If not passthrough and secret is invalid, then set status
+		if secretInvalidOrNotFound && !passthrough {
+			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: "TLS Secret not found or is malformed"})
+			b.setCondition(ir, ConditionTLSReady, "SecretNotFound", fmt.Sprintf("TLS Secret %s/%s not found or is malformed", m.namespace, m.name))
 		}
+	}
 
+	switch {
+	case ir.Spec.TCPProxy != nil && (passthrough || enforceTLS):
+		if b.processPlaintextRoutes(ir, host) {
+			if proxy := b.processTCPProxy(ir, nil, host); proxy != nil {
+				b.lookupSecureVirtualHost(host).VirtualHost.TCPProxy = proxy
+			}
+		}
+	case ir.Spec.Routes != nil:
+		b.processRoutes(ir, "", nil, host, enforceTLS)
+	}
+}
+
+// computeNamedListeners adds the vhost described by the root ingressroute ir
+// to each of the additional listeners named by ir.
+func (b *builder) computeNamedListeners(ir *ingressroutev1.IngressRoute, host string, names []string) {
+	var insecure, secure []*Listener
+	for _, name := range names {
+		l, ok := b.namedListener(name)
 		switch {
-		case ir.Spec.TCPProxy != nil && (passthrough || enforceTLS):
-			b.processTCPProxy(ir, nil, host)
-		case ir.Spec.Routes != nil:
-			b.processRoutes(ir, "", nil, host, enforceTLS)
+		case l == nil:
+			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: fmt.Sprintf("listener %q is not defined", name), Vhost: host})
+			return
+		case ok && ir.Spec.VirtualHost.TLS == nil:
+			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: fmt.Sprintf("listener %q requires Spec.VirtualHost.TLS", name), Vhost: host})
+			return
+		case ok:
+			secure = append(secure, l)
+		default:
+			insecure = append(insecure, l)
+		}
+	}
+
+	// process ir in isolation from any Ingress objects which have
+	// added vhosts for host to the default listeners, then move
+	// the resulting vhosts to the named listeners.
+	vh, svh := b.detachVirtualHosts(host)
+	b.computeVirtualHost(ir, host)
+	nvh, nsvh := b.detachVirtualHosts(host)
+	b.attachVirtualHosts(host, vh, svh)
+	setFleets(nvh, fleets(ir.Annotations))
+	setFleets(nsvh, fleets(ir.Annotations))
+	b.addSources(nvh, ingressRouteSources([]*ingressroutev1.IngressRoute{ir})...)
+	b.addSources(nsvh, ingressRouteSources([]*ingressroutev1.IngressRoute{ir})...)
+
+	for _, l := range insecure {
+		if nvh != nil {
+			l.VirtualHosts[host] = nvh
+		}
+	}
+	for _, l := range secure {
+		if nsvh != nil {
+			l.VirtualHosts[host] = nsvh
 		}
 	}
 }
 
+// detachVirtualHosts removes, and returns, the vhosts for host
+// from the default HTTP and HTTPS listeners.
+func (b *builder) detachVirtualHosts(host string) (Vertex, Vertex) {
+	vh := b.listener(80).VirtualHosts[host]
+	delete(b.listener(80).VirtualHosts, host)
+	svh := b.listener(443).VirtualHosts[host]
+	delete(b.listener(443).VirtualHosts, host)
+	return vh, svh
+}
+
+// attachVirtualHosts restores vhosts for host previously
+// removed by detachVirtualHosts.
+func (b *builder) attachVirtualHosts(host string, vh, svh Vertex) {
+	if vh != nil {
+		b.listener(80).VirtualHosts[host] = vh
+	}
+	if svh != nil {
+		b.listener(443).VirtualHosts[host] = svh
+	}
+}
+
+// namedListener returns the additional listener called name, and whether
+// that listener terminates TLS. If no such listener is configured
+// namedListener returns nil, false.
+func (b *builder) namedListener(name string) (*Listener, bool) {
+	for _, lc := range b.source.Listeners {
+		if lc.Name != name {
+			continue
+		}
+		l, ok := b.namedlisteners[name]
+		if !ok {
+			l = &Listener{
+				Name:         lc.Name,
+				Address:      lc.Address,
+				Port:         lc.Port,
+				VirtualHosts: make(map[string]Vertex),
+			}
+			if b.namedlisteners == nil {
+				b.namedlisteners = make(map[string]*Listener)
+			}
+			b.namedlisteners[name] = l
+		}
+		return l, lc.Secure
+	}
+	return nil, false
+}
+
 func (b *builder) secureVirtualhostExists(host string) bool {
 	_, ok := b.listener(443).VirtualHosts[host]
 	return ok
@@ -558,7 +760,14 @@ func defaultBackendRule(be *v1beta1.IngressBackend) v1beta1.IngressRule {
 // DAG returns a *DAG representing the current state of this builder.
 func (b *builder) DAG() *DAG {
 	var dag DAG
+	var listeners []*Listener
 	for _, l := range b.listeners {
+		listeners = append(listeners, l)
+	}
+	for _, l := range b.namedlisteners {
+		listeners = append(listeners, l)
+	}
+	for _, l := range listeners {
 		for k, vh := range l.VirtualHosts {
 			switch vh := vh.(type) {
 			case *VirtualHost:
@@ -578,6 +787,9 @@ func (b *builder) DAG() *DAG {
 			dag.roots = append(dag.roots, l)
 		}
 	}
+	for _, l := range b.tcplisteners {
+		dag.roots = append(dag.roots, l)
+	}
 	for meta := range b.orphaned {
 		ir, ok := b.source.ingressroutes[meta]
 		if ok {
@@ -585,14 +797,100 @@ func (b *builder) DAG() *DAG {
 		}
 	}
 	dag.statuses = b.statuses
+	dag.sources = b.sources
+	dag.reports = b.reports()
 	return &dag
 }
 
+// addSources records that v, if not nil, was declared by sources.
+func (b *builder) addSources(v Vertex, sources ...Source) {
+	if v == nil {
+		return
+	}
+	if b.sources == nil {
+		b.sources = make(map[Vertex][]Source)
+	}
+	for _, s := range sources {
+		if !containsSource(b.sources[v], s) {
+			b.sources[v] = append(b.sources[v], s)
+		}
+	}
+}
+
+func containsSource(sources []Source, s Source) bool {
+	for _, x := range sources {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(strs []string, s string) bool {
+	for _, x := range strs {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ingressRouteSources returns the sources of a chain of IngressRoutes.
+func ingressRouteSources(irs []*ingressroutev1.IngressRoute) []Source {
+	var sources []Source
+	for _, ir := range irs {
+		sources = append(sources, Source{Kind: "IngressRoute", Namespace: ir.Namespace, Name: ir.Name})
+	}
+	return sources
+}
+
 // setStatus assigns a status to an object.
+// A valid status is downgraded to a warning if any warnings
+// have been recorded against the object. The description of
+// an invalid status is recorded as an error of the object.
 func (b *builder) setStatus(st Status) {
+	switch st.Status {
+	case StatusValid:
+		if w := b.warnings[meta{name: st.Object.Name, namespace: st.Object.Namespace}]; len(w) > 0 {
+			st.Status = StatusWarning
+			st.Description = "valid IngressRoute with warnings: " + strings.Join(w, ", ")
+		}
+	case StatusInvalid:
+		b.setErrors(st.Object, st.Description)
+	}
 	b.statuses = append(b.statuses, st)
 }
 
+// setWarnings records non fatal problems with an ingressroute.
+func (b *builder) setWarnings(ir *ingressroutev1.IngressRoute, warnings ...string) {
+	b.warnings = addProblems(b.warnings, ir, warnings)
+}
+
+// setErrors records problems which make an ingressroute invalid.
+func (b *builder) setErrors(ir *ingressroutev1.IngressRoute, errs ...string) {
+	b.errors = addProblems(b.errors, ir, errs)
+}
+
+// addProblems adds problems to those of ir in m, allocating m if
+// needed, and returns m.
+func addProblems(m map[meta][]string, ir *ingressroutev1.IngressRoute, problems []string) map[meta][]string {
+	if len(problems) == 0 {
+		return m
+	}
+	if m == nil {
+		m = make(map[meta][]string)
+	}
+	k := meta{name: ir.Name, namespace: ir.Namespace}
+	for _, p := range problems {
+		if !containsString(m[k], p) {
+			// an ingressroute may be reached by more than one
+			// delegation chain, only record each problem once.
+			m[k] = append(m[k], p)
+		}
+	}
+	return m
+}
+
 // setOrphaned records an ingressroute as orphaned.
 func (b *builder) setOrphaned(ir *ingressroutev1.IngressRoute) {
 	if b.orphaned == nil {
@@ -627,53 +925,28 @@ func validCA(s *v1.Secret) bool {
 func (b *builder) processRoutes(ir *ingressroutev1.IngressRoute, prefixMatch string, visited []*ingressroutev1.IngressRoute, host string, enforceTLS bool) {
 	visited = append(visited, ir)
 
-	for _, route := range ir.Spec.Routes {
+	for i, route := range ir.Spec.Routes {
 		// route cannot both delegate and point to services
-		if len(route.Services) > 0 && route.Delegate != nil {
-			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: fmt.Sprintf("route %q: cannot specify services and delegate in the same route", route.Match), Vhost: host})
+		if err := validateRoute(route); err != nil {
+			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: err.Error(), Vhost: host})
+			b.setErrors(ir, routeErrors(ir.Spec.Routes[i:], prefixMatch)...)
 			return
 		}
 
 		// base case: The route points to services, so we add them to the vhost
 		if len(route.Services) > 0 {
-			if !matchesPathPrefix(route.Match, prefixMatch) {
-				b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: fmt.Sprintf("the path prefix %q does not match the parent's path prefix %q", route.Match, prefixMatch), Vhost: host})
+			if err := validatePrefix(route, prefixMatch); err != nil {
+				b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: err.Error(), Vhost: host})
+				b.setErrors(ir, routeErrors(ir.Spec.Routes[i:], prefixMatch)...)
 				return
 			}
 
-			r := &Route{
-				Prefix:        route.Match,
-				Websocket:     route.EnableWebsockets,
-				HTTPSUpgrade:  routeEnforceTLS(enforceTLS, route.PermitInsecure),
-				PrefixRewrite: route.PrefixRewrite,
-				TimeoutPolicy: timeoutPolicy(route.TimeoutPolicy),
-				RetryPolicy:   retryPolicy(route.RetryPolicy),
-			}
-			for _, service := range route.Services {
-				if service.Port < 1 || service.Port > 65535 {
-					b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: fmt.Sprintf("route %q: service %q: port must be in the range 1-65535", route.Match, service.Name), Vhost: host})
-					return
-				}
-				if service.Weight < 0 {
-					b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: fmt.Sprintf("route %q: service %q: weight must be greater than or equal to zero", route.Match, service.Name), Vhost: host})
-					return
-				}
-				m := meta{name: service.Name, namespace: ir.Namespace}
-				if s := b.lookupHTTPService(m, intstr.FromInt(service.Port)); s != nil {
-					var uv *UpstreamValidation
-					if s.Protocol == "tls" {
-						// we can only varlidate TLS connections to services that talk TLS
-						uv = b.lookupUpstreamValidation(ir, host, route, service, ir.Namespace)
-					}
-					r.Clusters = append(r.Clusters, &Cluster{
-						Upstream:             s,
-						LoadBalancerStrategy: service.Strategy,
-						Weight:               service.Weight,
-						HealthCheck:          service.HealthCheck,
-						UpstreamValidation:   uv,
-					})
-				}
+			r := b.serviceRoute(ir, route, host, routeEnforceTLS(enforceTLS, route.PermitInsecure))
+			if r == nil {
+				b.setErrors(ir, routeErrors(ir.Spec.Routes[i:], prefixMatch)...)
+				return
 			}
+			b.addSources(r, ingressRouteSources(visited)...)
 
 			b.lookupVirtualHost(host).addRoute(r)
 			b.lookupSecureVirtualHost(host).addRoute(r)
@@ -691,30 +964,127 @@ func (b *builder) processRoutes(ir *ingressroutev1.IngressRoute, prefixMatch str
 			namespace = ir.Namespace
 		}
 
-		if dest, ok := b.source.ingressroutes[meta{name: route.Delegate.Name, namespace: namespace}]; ok {
-			// dest is not an orphaned ingress route, as there is an IR that points to it
-			delete(b.orphaned, meta{name: dest.Name, namespace: dest.Namespace})
+		dest, ok := b.source.ingressroutes[meta{name: route.Delegate.Name, namespace: namespace}]
+		if !ok {
+			b.setCondition(ir, ConditionDelegationResolved, "DelegateNotFound", fmt.Sprintf("route %q: delegate %s/%s: not found", route.Match, namespace, route.Delegate.Name))
+			continue
+		}
+		b.setCondition(ir, ConditionDelegationResolved, "", "")
+
+		// dest is not an orphaned ingress route, as there is an IR that points to it
+		delete(b.orphaned, meta{name: dest.Name, namespace: dest.Namespace})
 
-			// ensure we are not following an edge that produces a cycle
-			var path []string
-			for _, vir := range visited {
-				path = append(path, fmt.Sprintf("%s/%s", vir.Namespace, vir.Name))
+		// ensure we are not following an edge that produces a cycle
+		var path []string
+		for _, vir := range visited {
+			path = append(path, fmt.Sprintf("%s/%s", vir.Namespace, vir.Name))
+		}
+		for _, vir := range visited {
+			if dest.Name == vir.Name && dest.Namespace == vir.Namespace {
+				path = append(path, fmt.Sprintf("%s/%s", dest.Namespace, dest.Name))
+				description := fmt.Sprintf("route creates a delegation cycle: %s", strings.Join(path, " -> "))
+				b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: description, Vhost: host})
+				b.setCondition(ir, ConditionDelegationResolved, "DelegationCycle", description)
+				b.setErrors(ir, routeErrors(ir.Spec.Routes[i+1:], prefixMatch)...)
+				return
 			}
-			for _, vir := range visited {
-				if dest.Name == vir.Name && dest.Namespace == vir.Namespace {
-					path = append(path, fmt.Sprintf("%s/%s", dest.Namespace, dest.Name))
-					description := fmt.Sprintf("route creates a delegation cycle: %s", strings.Join(path, " -> "))
-					b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: description, Vhost: host})
-					return
-				}
+		}
+
+		// follow the link and process the target ingress route
+		b.processRoutes(dest, route.Match, visited, host, enforceTLS)
+	}
+
+	b.setStatus(Status{Object: ir, Status: StatusValid, Description: "valid IngressRoute", Vhost: host})
+}
+
+// serviceRoute returns a *Route for route, which must point to services.
+// If any of route's services are invalid the status of ir is set to
+// invalid and nil is returned.
+func (b *builder) serviceRoute(ir *ingressroutev1.IngressRoute, route ingressroutev1.Route, host string, httpsUpgrade bool) *Route {
+	r := &Route{
+		Prefix:        route.Match,
+		Websocket:     route.EnableWebsockets,
+		HTTPSUpgrade:  httpsUpgrade,
+		PrefixRewrite: route.PrefixRewrite,
+		TimeoutPolicy: timeoutPolicy(route.TimeoutPolicy),
+		RetryPolicy:   retryPolicy(route.RetryPolicy),
+	}
+	if b.source.Strict {
+		b.setWarnings(ir, policyWarnings(route)...)
+	}
+	for _, service := range route.Services {
+		if err := validateService(route, service); err != nil {
+			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: err.Error(), Vhost: host})
+			return nil
+		}
+		m := meta{name: service.Name, namespace: ir.Namespace}
+		s := b.lookupHTTPService(m, intstr.FromInt(service.Port))
+		if s == nil {
+			notFound := fmt.Sprintf("route %q: service %s/%s/%d: not found", route.Match, ir.Namespace, service.Name, service.Port)
+			b.setCondition(ir, ConditionServicesResolved, "ServiceNotFound", notFound)
+			if b.source.Strict {
+				b.setWarnings(ir, notFound)
 			}
+			continue
+		}
+		b.setCondition(ir, ConditionServicesResolved, "", "")
 
-			// follow the link and process the target ingress route
-			b.processRoutes(dest, route.Match, visited, host, enforceTLS)
+		var uv *UpstreamValidation
+		if s.Protocol == "tls" {
+			// we can only varlidate TLS connections to services that talk TLS
+			uv = b.lookupUpstreamValidation(ir, host, route, service, ir.Namespace)
 		}
+		r.Clusters = append(r.Clusters, &Cluster{
+			Upstream:             s,
+			LoadBalancerStrategy: service.Strategy,
+			Weight:               service.Weight,
+			HealthCheck:          service.HealthCheck,
+			UpstreamValidation:   uv,
+		})
 	}
+	return r
+}
 
-	b.setStatus(Status{Object: ir, Status: StatusValid, Description: "valid IngressRoute", Vhost: host})
+// processPlaintextRoutes adds the routes of a tcpproxy ingressroute to the
+// port 80 vhost for host if tcpproxy.plaintextRoutes is set, otherwise they
+// are ignored. The secure vhost is served by the tcpproxy so these routes
+// are not reachable over HTTPS; they exist so that, for example, ACME
+// HTTP-01 challenges can be answered for a TLS passthrough vhost.
+// If tcpproxy.httpsRedirect is set, requests not matched by a route are
+// redirected to HTTPS. processPlaintextRoutes returns false, having set the
+// status of ir, if any route is invalid.
+func (b *builder) processPlaintextRoutes(ir *ingressroutev1.IngressRoute, host string) bool {
+	var routes []*Route
+	if ir.Spec.TCPProxy.HTTPSRedirect {
+		routes = append(routes, &Route{
+			Prefix:       "/",
+			HTTPSUpgrade: true,
+		})
+	}
+	var plaintext []ingressroutev1.Route
+	if ir.Spec.TCPProxy.PlaintextRoutes {
+		plaintext = ir.Spec.Routes
+	}
+	for i, route := range plaintext {
+		if err := validatePlaintextRoute(route); err != nil {
+			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: err.Error(), Vhost: host})
+			b.setErrors(ir, plaintextRouteErrors(plaintext[i+1:])...)
+			return false
+		}
+		r := b.serviceRoute(ir, route, host, false)
+		if r == nil {
+			b.setErrors(ir, plaintextRouteErrors(plaintext[i:])...)
+			return false
+		}
+		routes = append(routes, r)
+	}
+
+	// add routes in order, an explicit route for "/" replaces the redirect.
+	for _, r := range routes {
+		b.addSources(r, ingressRouteSources([]*ingressroutev1.IngressRoute{ir})...)
+		b.lookupVirtualHost(host).addRoute(r)
+	}
+	return true
 }
 
 // TODO(dfc) needs unit tests; we should pass in some kind of context object that encasulates all the properties we need for reporting
@@ -746,38 +1116,49 @@ func (b *builder) lookupUpstreamValidation(ir *ingressroutev1.IngressRoute, host
 	}
 }
 
-func (b *builder) processTCPProxy(ir *ingressroutev1.IngressRoute, visited []*ingressroutev1.IngressRoute, host string) {
+// processTCPProxy returns the TCPProxy described by ir, following any
+// delegation, or nil if ir does not describe a valid TCPProxy.
+func (b *builder) processTCPProxy(ir *ingressroutev1.IngressRoute, visited []*ingressroutev1.IngressRoute, host string) *TCPProxy {
 	visited = append(visited, ir)
 
 	// tcpproxy cannot both delegate and point to services
 	tcpproxy := ir.Spec.TCPProxy
-	if len(tcpproxy.Services) > 0 && tcpproxy.Delegate != nil {
-		b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: "tcpproxy: cannot specify services and delegate in the same tcpproxy", Vhost: host})
-		return
+	if err := validateTCPProxy(tcpproxy); err != nil {
+		b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: err.Error(), Vhost: host})
+		return nil
 	}
 
 	if len(tcpproxy.Services) > 0 {
 		var proxy TCPProxy
+		var notFound []string
 		for _, service := range tcpproxy.Services {
 			m := meta{name: service.Name, namespace: ir.Namespace}
 			s := b.lookupTCPService(m, intstr.FromInt(service.Port))
 			if s == nil {
-				b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: fmt.Sprintf("tcpproxy: service %s/%s/%d: not found", ir.Namespace, service.Name, service.Port), Vhost: host})
-				return
+				msg := fmt.Sprintf("tcpproxy: service %s/%s/%d: not found", ir.Namespace, service.Name, service.Port)
+				b.setCondition(ir, ConditionServicesResolved, "ServiceNotFound", msg)
+				notFound = append(notFound, msg)
+				continue
 			}
+			b.setCondition(ir, ConditionServicesResolved, "", "")
 			proxy.Clusters = append(proxy.Clusters, &Cluster{
 				Upstream:             s,
 				LoadBalancerStrategy: service.Strategy,
 			})
 		}
-		b.lookupSecureVirtualHost(host).VirtualHost.TCPProxy = &proxy
+		if len(notFound) > 0 {
+			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: notFound[0], Vhost: host})
+			b.setErrors(ir, notFound[1:]...)
+			return nil
+		}
 		b.setStatus(Status{Object: ir, Status: StatusValid, Description: "valid IngressRoute", Vhost: host})
-		return
+		b.addSources(&proxy, ingressRouteSources(visited)...)
+		return &proxy
 	}
 
 	if tcpproxy.Delegate == nil {
 		// not a delegate tcpproxy
-		return
+		return nil
 	}
 
 	namespace := tcpproxy.Delegate.Namespace
@@ -786,29 +1167,132 @@ func (b *builder) processTCPProxy(ir *ingressroutev1.IngressRoute, visited []*in
 		namespace = ir.Namespace
 	}
 
-	if dest, ok := b.source.ingressroutes[meta{name: tcpproxy.Delegate.Name, namespace: namespace}]; ok {
-		// dest is not an orphaned ingress route, as there is an IR that points to it
-		delete(b.orphaned, meta{name: dest.Name, namespace: dest.Namespace})
+	dest, ok := b.source.ingressroutes[meta{name: tcpproxy.Delegate.Name, namespace: namespace}]
+	if !ok {
+		description := fmt.Sprintf("tcpproxy: delegate %s/%s: not found", namespace, tcpproxy.Delegate.Name)
+		b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: description, Vhost: host})
+		b.setCondition(ir, ConditionDelegationResolved, "DelegateNotFound", description)
+		return nil
+	}
+	b.setCondition(ir, ConditionDelegationResolved, "", "")
 
-		// ensure we are not following an edge that produces a cycle
-		var path []string
-		for _, vir := range visited {
-			path = append(path, fmt.Sprintf("%s/%s", vir.Namespace, vir.Name))
+	// dest is not an orphaned ingress route, as there is an IR that points to it
+	delete(b.orphaned, meta{name: dest.Name, namespace: dest.Namespace})
+
+	// ensure we are not following an edge that produces a cycle
+	var path []string
+	for _, vir := range visited {
+		path = append(path, fmt.Sprintf("%s/%s", vir.Namespace, vir.Name))
+	}
+	for _, vir := range visited {
+		if dest.Name == vir.Name && dest.Namespace == vir.Namespace {
+			path = append(path, fmt.Sprintf("%s/%s", dest.Namespace, dest.Name))
+			description := fmt.Sprintf("tcpproxy creates a delegation cycle: %s", strings.Join(path, " -> "))
+			b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: description, Vhost: host})
+			b.setCondition(ir, ConditionDelegationResolved, "DelegationCycle", description)
+			return nil
 		}
-		for _, vir := range visited {
-			if dest.Name == vir.Name && dest.Namespace == vir.Namespace {
-				path = append(path, fmt.Sprintf("%s/%s", dest.Namespace, dest.Name))
-				description := fmt.Sprintf("tcpproxy creates a delegation cycle: %s", strings.Join(path, " -> "))
-				b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: description, Vhost: host})
-				return
+	}
+
+	// follow the link and process the target ingress route
+	proxy := b.processTCPProxy(dest, visited, host)
+	if proxy == nil {
+		// the status of dest says why.
+		description := fmt.Sprintf("tcpproxy: delegate %s/%s is not valid", dest.Namespace, dest.Name)
+		b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: description, Vhost: host})
+		b.setCondition(ir, ConditionDelegationResolved, "DelegateInvalid", description)
+		return nil
+	}
+
+	b.setStatus(Status{Object: ir, Status: StatusValid, Description: "valid IngressRoute", Vhost: host})
+	return proxy
+}
+
+// validTCPListener returns true if the plain TCP listener requested
+// by ir is valid, otherwise it sets the status of ir to invalid.
+func (b *builder) validTCPListener(ir *ingressroutev1.IngressRoute, host string) bool {
+	port := ir.Spec.VirtualHost.Port
+	var description string
+	switch {
+	case port < 1 || port > 65535:
+		description = virtualHostPortRange
+	case ir.Spec.VirtualHost.TLS != nil:
+		description = "Spec.VirtualHost.Port cannot be combined with Spec.VirtualHost.TLS"
+	case len(ir.Spec.VirtualHost.Listeners) > 0:
+		description = "Spec.VirtualHost.Port cannot be combined with Spec.VirtualHost.Listeners"
+	case ir.Spec.TCPProxy == nil:
+		description = "Spec.VirtualHost.Port requires Spec.TCPProxy"
+	case b.portReserved(port):
+		description = fmt.Sprintf("port %d is reserved", port)
+	default:
+		return true
+	}
+	b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: description, Vhost: host})
+	return false
+}
+
+// computeTCPListeners adds a plain TCP listener for each port claimed
+// by the valid root ingressroutes of claims. If several ingressroutes
+// claim the same port the oldest whose tcpproxy can be served owns it,
+// ties are broken by namespace and name. The others are marked invalid.
+func (b *builder) computeTCPListeners(claims map[int][]*ingressroutev1.IngressRoute) {
+	var ports []int
+	for port := range claims {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	for _, port := range ports {
+		irs := claims[port]
+		sort.Slice(irs, func(i, j int) bool { return olderThan(irs[i], irs[j]) })
+
+		var owner *ingressroutev1.IngressRoute
+		for _, ir := range irs {
+			host := ir.Spec.VirtualHost.Fqdn
+			if owner != nil {
+				b.setStatus(Status{Object: ir, Status: StatusInvalid, Description: fmt.Sprintf("port %d is already in use by IngressRoute %s/%s", port, owner.Namespace, owner.Name), Vhost: host})
+				continue
+			}
+			proxy := b.processTCPProxy(ir, nil, host)
+			if proxy == nil {
+				continue
 			}
+			owner = ir
+			if b.tcplisteners == nil {
+				b.tcplisteners = make(map[int]*TCPListener)
+			}
+			l := &TCPListener{
+				Port:     port,
+				Name:     host,
+				Fleets:   fleets(ir.Annotations),
+				TCPProxy: proxy,
+			}
+			b.addSources(l, ingressRouteSources([]*ingressroutev1.IngressRoute{ir})...)
+			b.tcplisteners[port] = l
 		}
+	}
+}
 
-		// follow the link and process the target ingress route
-		b.processTCPProxy(dest, visited, host)
+// portReserved returns true if port is used by one of Contour's own listeners.
+func (b *builder) portReserved(port int) bool {
+	for _, p := range b.source.ReservedPorts {
+		if p == port {
+			return true
+		}
 	}
+	return false
+}
 
-	b.setStatus(Status{Object: ir, Status: StatusValid, Description: "valid IngressRoute", Vhost: host})
+// olderThan returns true if a was created before b. If both were
+// created at the same time a and b are ordered by namespace and name.
+func olderThan(a, b *ingressroutev1.IngressRoute) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
 }
 
 // routeEnforceTLS determines if the route should redirect the user to a secure TLS listener
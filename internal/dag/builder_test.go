@@ -706,6 +706,39 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// ir1f tcp forwards traffic to default/kuard:8080 by TLS pass-throughing
+	// it, and serves /.well-known/acme-challenge/ over plaintext, redirecting
+	// all other plaintext requests to HTTPS.
+	ir1f := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard-tcp",
+			Namespace: "default",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn: "kuard.example.com",
+				TLS: &ingressroutev1.TLS{
+					Passthrough: true,
+				},
+			},
+			Routes: []ingressroutev1.Route{{
+				Match: "/.well-known/acme-challenge/",
+				Services: []ingressroutev1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+			TCPProxy: &ingressroutev1.TCPProxy{
+				Services: []ingressroutev1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+				HTTPSRedirect:   true,
+				PlaintextRoutes: true,
+			},
+		},
+	}
+
 	// ir1c tcp delegates to another ingress route, concretely to
 	// marketing/kuard-tcp. it.
 	ir1c := &ingressroutev1.IngressRoute{
@@ -1305,6 +1338,25 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// s1c is like s1 but opts into load reporting
+	s1c := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"contour.heptio.com/load-reporting": "true",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
 	// s2 is like s1 but with a different name
 	s2 := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -2009,6 +2061,40 @@ func TestDAGInsert(t *testing.T) {
 			),
 		},
 
+		"insert ingressroute with tcp forward w/ passthrough and plaintext routes": {
+			objs: []interface{}{
+				ir1f, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("kuard.example.com",
+							&Route{
+								Prefix:       "/",
+								HTTPSUpgrade: true,
+							},
+							route("/.well-known/acme-challenge/", httpService(s1)),
+						),
+					),
+				},
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name: "kuard.example.com",
+								TCPProxy: &TCPProxy{
+									Clusters: clusters(
+										tcpService(s1),
+									),
+								},
+							},
+						},
+					),
+				},
+			),
+		},
 		"insert root ingress route and delegate ingress route for a tcp proxy": {
 			objs: []interface{}{
 				ir1d, s6, ir1c,
@@ -2653,6 +2739,29 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert ingress then service w/ load reporting annotation": {
+			objs: []interface{}{
+				i1,
+				s1c,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("*",
+							route("/", &HTTPService{
+								TCPService: TCPService{
+									Name:          s1c.Name,
+									Namespace:     s1c.Namespace,
+									ServicePort:   &s1c.Spec.Ports[0],
+									LoadReporting: true,
+								},
+							}),
+						),
+					),
+				},
+			),
+		},
 		"insert ingressroute with two routes to the same service": {
 			objs: []interface{}{
 				ir13, s1,
@@ -3496,6 +3605,81 @@ func TestDAGIngressRouteStatus(t *testing.T) {
 		},
 	}
 
+	// ir15 is invalid because its plaintext routes delegate
+	ir15 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "passthrough",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &ingressroutev1.TLS{
+					Passthrough: true,
+				},
+			},
+			Routes: []ingressroutev1.Route{{
+				Match: "/.well-known/acme-challenge/",
+				Delegate: &ingressroutev1.Delegate{
+					Name: "validChild",
+				},
+			}},
+			TCPProxy: &ingressroutev1.TCPProxy{
+				Services: []ingressroutev1.Service{{
+					Name: "foo",
+					Port: 8080,
+				}},
+				PlaintextRoutes: true,
+			},
+		},
+	}
+
+	// ir16 is invalid because its plaintext route has no services
+	ir16 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "passthrough",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &ingressroutev1.TLS{
+					Passthrough: true,
+				},
+			},
+			Routes: []ingressroutev1.Route{{
+				Match: "/",
+			}},
+			TCPProxy: &ingressroutev1.TCPProxy{
+				Services: []ingressroutev1.Service{{
+					Name: "foo",
+					Port: 8080,
+				}},
+				PlaintextRoutes: true,
+			},
+		},
+	}
+
+	// ir17 is invalid because its fqdn is not a valid hostname
+	ir17 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "badfqdn",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn: "example.com/foo",
+			},
+			Routes: []ingressroutev1.Route{{
+				Match: "/foo",
+				Services: []ingressroutev1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
 	tests := map[string]struct {
 		objs []*ingressroutev1.IngressRoute
 		want []Status
@@ -3527,6 +3711,10 @@ func TestDAGIngressRouteStatus(t *testing.T) {
 			objs: []*ingressroutev1.IngressRoute{ir13},
 			want: []Status{{Object: ir13, Status: "invalid", Description: "Spec.VirtualHost.Fqdn must be specified"}},
 		},
+		"root ingressroute with invalid FQDN": {
+			objs: []*ingressroutev1.IngressRoute{ir17},
+			want: []Status{{Object: ir17, Status: "invalid", Description: `Spec.VirtualHost.Fqdn "example.com/foo" is not a valid hostname`}},
+		},
 		"self-edge produces a cycle": {
 			objs: []*ingressroutev1.IngressRoute{ir6},
 			want: []Status{{Object: ir6, Status: "invalid", Description: "route creates a delegation cycle: roots/self -> roots/self", Vhost: "example.com"}},
@@ -3561,6 +3749,14 @@ func TestDAGIngressRouteStatus(t *testing.T) {
 				{Object: ir11, Status: "orphaned", Description: "this IngressRoute is not part of a delegation chain from a root IngressRoute"},
 			},
 		},
+		"tcpproxy ingressroute with delegated plaintext route": {
+			objs: []*ingressroutev1.IngressRoute{ir15},
+			want: []Status{{Object: ir15, Status: "invalid", Description: `route "/.well-known/acme-challenge/": routes cannot be delegated when tcpproxy is present`, Vhost: "example.com"}},
+		},
+		"tcpproxy ingressroute with plaintext route without services": {
+			objs: []*ingressroutev1.IngressRoute{ir16},
+			want: []Status{{Object: ir16, Status: "invalid", Description: `route "/": routes must have at least one service when tcpproxy is present`, Vhost: "example.com"}},
+		},
 		"multi-parent children is not orphaned when one of the parents is invalid": {
 			objs: []*ingressroutev1.IngressRoute{ir14, ir11, ir10},
 			want: []Status{
@@ -3602,6 +3798,106 @@ func TestDAGIngressRouteStatus(t *testing.T) {
 	}
 }
 
+func TestDAGIngressRouteStrictStatus(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "home",
+			Namespace: "roots",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	// ir1 routes to a service which does not exist
+	ir1 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []ingressroutev1.Route{{
+				Match: "/",
+				Services: []ingressroutev1.Service{{
+					Name: "missing",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// ir2 has a timeout which cannot be parsed
+	ir2 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []ingressroutev1.Route{{
+				Match: "/",
+				TimeoutPolicy: &ingressroutev1.TimeoutPolicy{
+					Request: "1 minute",
+				},
+				Services: []ingressroutev1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	tests := map[string]struct {
+		strict bool
+		objs   []interface{}
+		want   Status
+	}{
+		"missing service": {
+			objs: []interface{}{ir1},
+			want: Status{Object: ir1, Status: StatusValid, Description: "valid IngressRoute", Vhost: "example.com"},
+		},
+		"strict, missing service": {
+			strict: true,
+			objs:   []interface{}{ir1},
+			want:   Status{Object: ir1, Status: StatusWarning, Description: `valid IngressRoute with warnings: route "/": service roots/missing/8080: not found`, Vhost: "example.com"},
+		},
+		"invalid timeout": {
+			objs: []interface{}{s1, ir2},
+			want: Status{Object: ir2, Status: StatusValid, Description: "valid IngressRoute", Vhost: "example.com"},
+		},
+		"strict, invalid timeout": {
+			strict: true,
+			objs:   []interface{}{s1, ir2},
+			want:   Status{Object: ir2, Status: StatusWarning, Description: `valid IngressRoute with warnings: route "/": timeoutPolicy.request "1 minute" is not a valid duration`, Vhost: "example.com"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := Builder{
+				KubernetesCache: KubernetesCache{
+					Strict: tc.strict,
+				},
+			}
+			for _, o := range tc.objs {
+				b.Insert(o)
+			}
+			got := b.Build().Statuses()
+			if diff := cmp.Diff([]Status{tc.want}, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
 func TestDAGIngressRouteUniqueFQDNs(t *testing.T) {
 	ir1 := &ingressroutev1.IngressRoute{
 		ObjectMeta: metav1.ObjectMeta{
@@ -4014,3 +4310,644 @@ func listeners(ls ...*Listener) []Vertex {
 	}
 	return v
 }
+
+func TestDAGIngressRouteTCPListener(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "postgres",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     5432,
+			}},
+		},
+	}
+
+	ingressroute := func(name string, created time.Time, port int) *ingressroutev1.IngressRoute {
+		return &ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: name + ".example.com",
+					Port: port,
+				},
+				TCPProxy: &ingressroutev1.TCPProxy{
+					Services: []ingressroutev1.Service{{
+						Name: "postgres",
+						Port: 5432,
+					}},
+				},
+			},
+		}
+	}
+
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	ir1 := ingressroute("db", now, 5432)
+	ir2 := ingressroute("other", now.Add(time.Minute), 5432)
+	ir3 := ingressroute("reserved", now, 8080)
+	ir4 := ingressroute("tls", now, 5433)
+	ir4.Spec.VirtualHost.TLS = &ingressroutev1.TLS{Passthrough: true}
+	ir5 := ingressroute("noproxy", now, 5434)
+	ir5.Spec.TCPProxy = nil
+
+	// ir6 and ir7 claim the port of ir1 first, but cannot be served.
+	ir6 := ingressroute("old-tls", now.Add(-time.Minute), 5432)
+	ir6.Spec.VirtualHost.TLS = &ingressroutev1.TLS{Passthrough: true}
+	ir7 := ingressroute("old-missing", now.Add(-time.Minute), 5432)
+	ir7.Spec.TCPProxy.Services[0].Name = "missing"
+
+	// ir8 delegates its tcpproxy to an ingressroute which does not exist.
+	ir8 := ingressroute("missing-delegate", now, 5432)
+	ir8.Spec.TCPProxy = &ingressroutev1.TCPProxy{
+		Delegate: &ingressroutev1.Delegate{Name: "missing"},
+	}
+
+	// ir9 delegates its tcpproxy to ir10, whose service does not exist.
+	ir9 := ingressroute("invalid-delegate", now, 5432)
+	ir9.Spec.TCPProxy = &ingressroutev1.TCPProxy{
+		Delegate: &ingressroutev1.Delegate{Name: "broken"},
+	}
+	ir10 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "broken",
+			Namespace: "default",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			TCPProxy: &ingressroutev1.TCPProxy{
+				Services: []ingressroutev1.Service{{
+					Name: "missing",
+					Port: 5432,
+				}},
+			},
+		},
+	}
+
+	tcplistener := func(port int, name string) *TCPListener {
+		return &TCPListener{
+			Port: port,
+			Name: name,
+			TCPProxy: &TCPProxy{
+				Clusters: clusters(tcpService(s1)),
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		objs       []interface{}
+		want       []*TCPListener
+		wantStatus []Status
+	}{
+		"tcp listener": {
+			objs: []interface{}{s1, ir1},
+			want: []*TCPListener{tcplistener(5432, "db.example.com")},
+			wantStatus: []Status{
+				{Object: ir1, Status: StatusValid, Description: "valid IngressRoute", Vhost: "db.example.com"},
+			},
+		},
+		"port conflict, oldest wins": {
+			objs: []interface{}{s1, ir2, ir1},
+			want: []*TCPListener{tcplistener(5432, "db.example.com")},
+			wantStatus: []Status{
+				{Object: ir1, Status: StatusValid, Description: "valid IngressRoute", Vhost: "db.example.com"},
+				{Object: ir2, Status: StatusInvalid, Description: "port 5432 is already in use by IngressRoute default/db", Vhost: "other.example.com"},
+			},
+		},
+		"port conflict, older claimants invalid": {
+			objs: []interface{}{s1, ir1, ir6, ir7},
+			want: []*TCPListener{tcplistener(5432, "db.example.com")},
+			wantStatus: []Status{
+				{Object: ir1, Status: StatusValid, Description: "valid IngressRoute", Vhost: "db.example.com"},
+				{Object: ir7, Status: StatusInvalid, Description: "tcpproxy: service default/missing/5432: not found", Vhost: "old-missing.example.com"},
+				{Object: ir6, Status: StatusInvalid, Description: "Spec.VirtualHost.Port cannot be combined with Spec.VirtualHost.TLS", Vhost: "old-tls.example.com"},
+			},
+		},
+		"missing tcpproxy delegate": {
+			objs: []interface{}{s1, ir8},
+			wantStatus: []Status{
+				{Object: ir8, Status: StatusInvalid, Description: "tcpproxy: delegate default/missing: not found", Vhost: "missing-delegate.example.com"},
+			},
+		},
+		"invalid tcpproxy delegate": {
+			objs: []interface{}{s1, ir9, ir10},
+			wantStatus: []Status{
+				{Object: ir10, Status: StatusInvalid, Description: "tcpproxy: service default/missing/5432: not found", Vhost: "invalid-delegate.example.com"},
+				{Object: ir9, Status: StatusInvalid, Description: "tcpproxy: delegate default/broken is not valid", Vhost: "invalid-delegate.example.com"},
+			},
+		},
+		"reserved port": {
+			objs: []interface{}{s1, ir3},
+			wantStatus: []Status{
+				{Object: ir3, Status: StatusInvalid, Description: "port 8080 is reserved", Vhost: "reserved.example.com"},
+			},
+		},
+		"port and tls": {
+			objs: []interface{}{s1, ir4},
+			wantStatus: []Status{
+				{Object: ir4, Status: StatusInvalid, Description: "Spec.VirtualHost.Port cannot be combined with Spec.VirtualHost.TLS", Vhost: "tls.example.com"},
+			},
+		},
+		"port without tcpproxy": {
+			objs: []interface{}{s1, ir5},
+			wantStatus: []Status{
+				{Object: ir5, Status: StatusInvalid, Description: "Spec.VirtualHost.Port requires Spec.TCPProxy", Vhost: "noproxy.example.com"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := Builder{
+				KubernetesCache: KubernetesCache{
+					ReservedPorts: []int{8080, 8443},
+				},
+			}
+			for _, o := range tc.objs {
+				b.Insert(o)
+			}
+			dag := b.Build()
+
+			var got []*TCPListener
+			dag.Visit(func(v Vertex) {
+				if l, ok := v.(*TCPListener); ok {
+					got = append(got, l)
+				}
+			})
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+
+			gotStatus := dag.Statuses()
+			sort.Stable(statusByNamespaceAndName(gotStatus))
+			if diff := cmp.Diff(tc.wantStatus, gotStatus); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestDAGIngressRouteFleets(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Data: secretdata("certificate", "key"),
+	}
+
+	ingressroute := func(name string, annotations map[string]string) *ingressroutev1.IngressRoute {
+		return &ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: name + ".example.com",
+					TLS: &ingressroutev1.TLS{
+						SecretName: sec1.Name,
+					},
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+
+	ir1 := ingressroute("internal", map[string]string{
+		"contour.heptio.com/fleet": "internal",
+	})
+	ir2 := ingressroute("public", nil)
+	ir3 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"contour.heptio.com/fleet": "internal,edge",
+			},
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn: "db.example.com",
+				Port: 5432,
+			},
+			TCPProxy: &ingressroutev1.TCPProxy{
+				Services: []ingressroutev1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			},
+		},
+	}
+
+	var b Builder
+	for _, o := range []interface{}{s1, sec1, ir1, ir2, ir3} {
+		b.Insert(o)
+	}
+	dag := b.Build()
+
+	got := make(map[string][]string)
+	var visit func(Vertex)
+	visit = func(v Vertex) {
+		switch v := v.(type) {
+		case *VirtualHost:
+			got["http "+v.Name] = v.Fleets
+		case *SecureVirtualHost:
+			got["https "+v.Name] = v.Fleets
+		case *TCPListener:
+			got["tcp "+v.Name] = v.Fleets
+		default:
+			v.Visit(visit)
+		}
+	}
+	dag.Visit(visit)
+
+	want := map[string][]string{
+		"http internal.example.com":  {"internal"},
+		"https internal.example.com": {"internal"},
+		"http public.example.com":    nil,
+		"https public.example.com":   nil,
+		"tcp db.example.com":         {"edge", "internal"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestDAGSources(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	i1 := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{
+				Host: "ingress.example.com",
+				IngressRuleValue: v1beta1.IngressRuleValue{
+					HTTP: &v1beta1.HTTPIngressRuleValue{
+						Paths: []v1beta1.HTTPIngressPath{{
+							Backend: v1beta1.IngressBackend{
+								ServiceName: "kuard",
+								ServicePort: intstr.FromInt(8080),
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	ir1 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "root",
+			Namespace: "default",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn: "ingressroute.example.com",
+			},
+			Routes: []ingressroutev1.Route{{
+				Match: "/",
+				Services: []ingressroutev1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}, {
+				Match: "/api",
+				Delegate: &ingressroutev1.Delegate{
+					Name:      "api",
+					Namespace: "teama",
+				},
+			}},
+		},
+	}
+
+	ir2 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api",
+			Namespace: "teama",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			Routes: []ingressroutev1.Route{{
+				Match: "/api",
+				Services: []ingressroutev1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	s2 := s1.DeepCopy()
+	s2.Namespace = "teama"
+
+	var b Builder
+	for _, o := range []interface{}{s1, s2, i1, ir1, ir2} {
+		b.Insert(o)
+	}
+	dag := b.Build()
+
+	// record the sources of each plain http route, by host and prefix.
+	got := make(map[string][]Source)
+	dag.Visit(func(v Vertex) {
+		v.Visit(func(v Vertex) {
+			vh, ok := v.(*VirtualHost)
+			if !ok {
+				return
+			}
+			vh.Visit(func(v Vertex) {
+				if r, ok := v.(*Route); ok {
+					got[vh.Name+r.Prefix] = dag.Sources(r)
+				}
+			})
+		})
+	})
+
+	want := map[string][]Source{
+		"ingress.example.com/": {
+			{Kind: "Ingress", Namespace: "default", Name: "kuard"},
+		},
+		"ingressroute.example.com/": {
+			{Kind: "IngressRoute", Namespace: "default", Name: "root"},
+		},
+		"ingressroute.example.com/api": {
+			{Kind: "IngressRoute", Namespace: "default", Name: "root"},
+			{Kind: "IngressRoute", Namespace: "teama", Name: "api"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestDAGIngressRouteNamedListeners(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Data: secretdata("certificate", "key"),
+	}
+
+	ingressroute := func(tls bool, listeners ...string) *ingressroutev1.IngressRoute {
+		ir := &ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kuard",
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn:      "kuard.example.com",
+					Listeners: listeners,
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+		if tls {
+			ir.Spec.VirtualHost.TLS = &ingressroutev1.TLS{
+				SecretName: "secret",
+			}
+		}
+		return ir
+	}
+
+	// i1 is an ingress for the same host on the default listeners.
+	i1 := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{
+				Host:             "kuard.example.com",
+				IngressRuleValue: ingressrulevalue(backend("kuard", intstr.FromInt(8080))),
+			}},
+		},
+	}
+
+	ir1 := ingressroute(false, "internal")
+	ir2 := ingressroute(true, "internal", "alt-https")
+	ir3 := ingressroute(false, "missing")
+	ir4 := ingressroute(false, "alt-https")
+
+	tests := map[string]struct {
+		objs       []interface{}
+		want       map[string][]string
+		wantStatus Status
+	}{
+		"http listener": {
+			objs: []interface{}{s1, ir1},
+			want: map[string][]string{
+				"internal": {"kuard.example.com"},
+			},
+			wantStatus: Status{Object: ir1, Status: StatusValid, Description: "valid IngressRoute", Vhost: "kuard.example.com"},
+		},
+		"http and https listeners": {
+			objs: []interface{}{s1, sec1, ir2},
+			want: map[string][]string{
+				"internal":  {"kuard.example.com"},
+				"alt-https": {"kuard.example.com"},
+			},
+			wantStatus: Status{Object: ir2, Status: StatusValid, Description: "valid IngressRoute", Vhost: "kuard.example.com"},
+		},
+		"ingress on default listener is not moved": {
+			objs: []interface{}{s1, i1, ir1},
+			want: map[string][]string{
+				"":         {"kuard.example.com"},
+				"internal": {"kuard.example.com"},
+			},
+			wantStatus: Status{Object: ir1, Status: StatusValid, Description: "valid IngressRoute", Vhost: "kuard.example.com"},
+		},
+		"undefined listener": {
+			objs:       []interface{}{s1, ir3},
+			want:       map[string][]string{},
+			wantStatus: Status{Object: ir3, Status: StatusInvalid, Description: `listener "missing" is not defined`, Vhost: "kuard.example.com"},
+		},
+		"secure listener without tls": {
+			objs:       []interface{}{s1, ir4},
+			want:       map[string][]string{},
+			wantStatus: Status{Object: ir4, Status: StatusInvalid, Description: `listener "alt-https" requires Spec.VirtualHost.TLS`, Vhost: "kuard.example.com"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := Builder{
+				KubernetesCache: KubernetesCache{
+					Listeners: []ListenerConfig{{
+						Name: "internal",
+						Port: 9080,
+					}, {
+						Name:   "alt-https",
+						Port:   9443,
+						Secure: true,
+					}},
+				},
+			}
+			for _, o := range tc.objs {
+				b.Insert(o)
+			}
+			dag := b.Build()
+
+			got := make(map[string][]string)
+			dag.Visit(func(v Vertex) {
+				l, ok := v.(*Listener)
+				if !ok {
+					return
+				}
+				for host := range l.VirtualHosts {
+					got[l.Name] = append(got[l.Name], host)
+				}
+			})
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+
+			if diff := cmp.Diff([]Status{tc.wantStatus}, dag.Statuses()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestDelegationPermitted(t *testing.T) {
+	delegation := func(delegations ...ingressroutev1.CertificateDelegation) *ingressroutev1.TLSCertificateDelegation {
+		return &ingressroutev1.TLSCertificateDelegation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "delegation",
+				Namespace: "certs",
+			},
+			Spec: ingressroutev1.TLSCertificateDelegationSpec{
+				Delegations: delegations,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		delegation *ingressroutev1.TLSCertificateDelegation
+		to         string
+		want       bool
+	}{
+		"same namespace": {
+			delegation: delegation(),
+			to:         "certs",
+			want:       true,
+		},
+		"delegated to namespace": {
+			delegation: delegation(ingressroutev1.CertificateDelegation{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"teama"},
+			}),
+			to:   "teama",
+			want: true,
+		},
+		"delegated to all namespaces": {
+			delegation: delegation(ingressroutev1.CertificateDelegation{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"*"},
+			}),
+			to:   "teama",
+			want: true,
+		},
+		"not delegated to namespace": {
+			delegation: delegation(ingressroutev1.CertificateDelegation{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"teamb"},
+			}),
+			to: "teama",
+		},
+		"namespaced secret name is ignored": {
+			delegation: delegation(ingressroutev1.CertificateDelegation{
+				SecretName:       "certs/wildcard",
+				TargetNamespaces: []string{"teama"},
+			}),
+			to: "teama",
+		},
+		"all namespaces combined with others is ignored": {
+			delegation: delegation(ingressroutev1.CertificateDelegation{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"teama", "*"},
+			}),
+			to: "teama",
+		},
+		"invalid delegations do not affect valid ones": {
+			delegation: delegation(ingressroutev1.CertificateDelegation{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"teama", "*"},
+			}, ingressroutev1.CertificateDelegation{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"teama"},
+			}),
+			to:   "teama",
+			want: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var source Builder
+			source.Insert(tc.delegation)
+			b := &builder{source: &source}
+			got := b.delegationPermitted(meta{name: "wildcard", namespace: "certs"}, tc.to)
+			if got != tc.want {
+				t.Fatalf("expected: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
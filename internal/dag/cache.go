@@ -33,6 +33,20 @@ type KubernetesCache struct {
 	// namespace.
 	IngressRouteRootNamespaces []string
 
+	// ReservedPorts lists the ports used by Contour's own Envoy
+	// listeners. IngressRoutes cannot claim these ports for plain
+	// TCP listeners.
+	ReservedPorts []int
+
+	// Listeners describes the additional listeners root
+	// IngressRoutes may bind to.
+	Listeners []ListenerConfig
+
+	// Strict reports problems which Contour otherwise tolerates,
+	// such as routes to missing services or malformed timeouts,
+	// as warnings in the status of the offending IngressRoute.
+	Strict bool
+
 	mu sync.RWMutex
 
 	ingresses     map[meta]*v1beta1.Ingress
@@ -42,6 +56,22 @@ type KubernetesCache struct {
 	services      map[meta]*v1.Service
 }
 
+// ListenerConfig describes an additional named listener.
+type ListenerConfig struct {
+	// Name is the name of the listener. IngressRoutes
+	// refer to the listener by this name.
+	Name string
+
+	// Address is the TCP address to listen on.
+	Address string
+
+	// Port is the TCP port to listen on.
+	Port int
+
+	// Secure is true if the listener terminates TLS.
+	Secure bool
+}
+
 // meta holds the name and namespace of a Kubernetes object.
 type meta struct {
 	name, namespace string
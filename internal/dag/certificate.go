@@ -0,0 +1,101 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// pemPreamble is the start of every PEM encoded block.
+var pemPreamble = []byte("-----BEGIN")
+
+// Certificate returns the leaf certificate held by this secret.
+// If the certificate material is not PEM encoded Certificate returns
+// nil, nil; such secrets are passed through to Envoy uninspected.
+func (s *Secret) Certificate() (*x509.Certificate, error) {
+	return parseCertificate(s.Cert())
+}
+
+// parseCertificate parses the first PEM encoded certificate in data.
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	if !bytes.Contains(data, pemPreamble) {
+		// not PEM, nothing to inspect.
+		return nil, nil
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("certificate PEM data could not be decoded")
+	}
+	if block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("expected a PEM block of type CERTIFICATE, found %q", block.Type)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("certificate could not be parsed: %v", err)
+	}
+	return cert, nil
+}
+
+// parsePrivateKey parses a DER encoded PKCS1, PKCS8, or EC private key.
+func parsePrivateKey(der []byte) (interface{}, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	return x509.ParseECPrivateKey(der)
+}
+
+// inspectSecret checks the certificate and private key held by s.
+// An error is returned if the key material is corrupt. Otherwise
+// inspectSecret returns a, possibly empty, slice of warnings describing
+// problems which do not prevent the secret from being served.
+// If host is not blank the certificate is checked to ensure it is
+// valid for host.
+func inspectSecret(s *Secret, host string) ([]string, error) {
+	cert, err := s.Certificate()
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, nil
+	}
+
+	block, _ := pem.Decode(s.PrivateKey())
+	if block == nil {
+		return nil, errors.New("private key PEM data could not be decoded")
+	}
+	if _, err := parsePrivateKey(block.Bytes); err != nil {
+		return nil, fmt.Errorf("private key could not be parsed: %v", err)
+	}
+
+	var warnings []string
+	if _, err := tls.X509KeyPair(s.Cert(), s.PrivateKey()); err != nil {
+		// both halves parsed above, so the only remaining failure
+		// is that the key does not belong to the certificate.
+		warnings = append(warnings, "private key does not match certificate")
+	}
+	if !isBlank(host) {
+		if err := cert.VerifyHostname(host); err != nil {
+			warnings = append(warnings, fmt.Sprintf("certificate is not valid for %q", host))
+		}
+	}
+	return warnings, nil
+}
@@ -32,6 +32,12 @@ type DAG struct {
 
 	// status computed while building this dag.
 	statuses []Status
+
+	// sources of the vertices of this dag.
+	sources map[Vertex][]Source
+
+	// reports of the IngressRoutes of this dag.
+	reports map[meta]Report
 }
 
 // Visit calls fn on each root of this DAG.
@@ -47,6 +53,22 @@ func (d *DAG) Statuses() []Status {
 	return d.statuses
 }
 
+// Sources returns the objects which declared v. The sources of a
+// Route or TCPProxy are either an Ingress, or a root IngressRoute
+// followed by each IngressRoute it delegated to on the way to v.
+// The sources of a vhost or TCPListener are the Ingresses, or root
+// IngressRoute, which declared it.
+func (d *DAG) Sources(v Vertex) []Source {
+	return d.sources[v]
+}
+
+// A Source identifies the Kubernetes object which declared part of a DAG.
+type Source struct {
+	Kind      string // Ingress or IngressRoute
+	Namespace string
+	Name      string
+}
+
 type Route struct {
 	Prefix   string
 	Clusters []*Cluster
@@ -115,6 +137,10 @@ type VirtualHost struct {
 	// as defined by RFC 3986.
 	Name string
 
+	// Fleets, if not empty, restricts this vhost to the Envoys
+	// of the named fleets.
+	Fleets []string
+
 	routes map[string]*Route
 
 	// Service to TCP proxy all incoming connections.
@@ -172,6 +198,10 @@ type Service interface {
 // incoming connections.
 type Listener struct {
 
+	// Name is the name of an additional listener.
+	// It is blank for the default HTTP and HTTPS listeners.
+	Name string
+
 	// Address is the TCP address to listen on.
 	// If blank 0.0.0.0, or ::/0 for IPv6, is assumed.
 	Address string
@@ -188,6 +218,31 @@ func (l *Listener) Visit(f func(Vertex)) {
 	}
 }
 
+// A TCPListener proxies plain TCP connections received on
+// Port to the endpoints of a TCPProxy.
+type TCPListener struct {
+
+	// Address is the TCP address to listen on.
+	// If blank 0.0.0.0, or ::/0 for IPv6, is assumed.
+	Address string
+
+	// Port is the TCP port to listen on.
+	Port int
+
+	// Name is the fqdn of the IngressRoute which declared this listener.
+	Name string
+
+	// Fleets, if not empty, restricts this listener to the Envoys
+	// of the named fleets.
+	Fleets []string
+
+	*TCPProxy
+}
+
+func (l *TCPListener) Visit(f func(Vertex)) {
+	f(l.TCPProxy)
+}
+
 // TCPProxy represents a cluster of TCP endpoints.
 type TCPProxy struct {
 
@@ -228,6 +283,10 @@ type TCPService struct {
 
 	// ExternalName is an optional field referencing a dns entry for Service type "ExternalName"
 	ExternalName string
+
+	// LoadReporting is true if Envoy should report the load
+	// of this service to Contour's load reporting service.
+	LoadReporting bool
 }
 
 type servicemeta struct {
@@ -283,10 +342,31 @@ type HTTPService struct {
 	Protocol string
 }
 
+// OCSPStapleKey is the key of a Secret's data which holds
+// a DER encoded OCSP response.
+const OCSPStapleKey = "tls.ocsp-staple"
+
 // Secret represents a K8s Secret for TLS usage as a DAG Vertex. A Secret is
 // a leaf in the DAG.
 type Secret struct {
 	Object *v1.Secret
+
+	// OCSP is an optional OCSP response for this secret's certificate.
+	OCSP *OCSPResponse
+}
+
+// OCSPResponse represents an OCSP response for a certificate.
+type OCSPResponse struct {
+	// Raw is the DER encoded OCSP response.
+	Raw []byte
+
+	// NextUpdate is the time after which the response is stale.
+	// A zero value indicates that newer information is always available.
+	NextUpdate time.Time
+
+	// Stale is true if the response has passed its NextUpdate time.
+	// Stale responses are not stapled.
+	Stale bool
 }
 
 func (s *Secret) Name() string       { return s.Object.Name }
@@ -308,6 +388,15 @@ func (s *Secret) PrivateKey() []byte {
 	return s.Object.Data[v1.TLSPrivateKeyKey]
 }
 
+// OCSPStaple returns the OCSP response to staple to TLS handshakes
+// presenting this secret's certificate, or nil if there is none.
+func (s *Secret) OCSPStaple() []byte {
+	if s.OCSP == nil || s.OCSP.Stale {
+		return nil
+	}
+	return s.OCSP.Raw
+}
+
 func (s *Secret) toMeta() meta {
 	return meta{
 		name:      s.Name(),
@@ -0,0 +1,110 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// OCSPPolicyLenient staples a response if a fresh one is available,
+	// otherwise the certificate is served without a staple.
+	OCSPPolicyLenient = "lenient"
+
+	// OCSPPolicyStrict staples a response if one is supplied, and refuses
+	// to serve the vhost if the supplied response is stale or invalid.
+	OCSPPolicyStrict = "strict"
+
+	// OCSPPolicyMustStaple refuses to serve the vhost unless a fresh
+	// response is supplied.
+	OCSPPolicyMustStaple = "must-staple"
+)
+
+// lookupOCSPResponse returns the OCSP response for the certificate in sec
+// according to the stapling policy of ir. An error is returned if the policy
+// cannot be satisfied. Otherwise lookupOCSPResponse returns the response,
+// which may be nil if none was supplied, and a possibly empty slice of warnings.
+func (b *builder) lookupOCSPResponse(ir *ingressroutev1.IngressRoute, sec *Secret) (*OCSPResponse, []string, error) {
+	tls := ir.Spec.VirtualHost.TLS
+	policy := stringOrDefault(tls.OCSPStaplePolicy, OCSPPolicyLenient)
+	switch policy {
+	case OCSPPolicyLenient, OCSPPolicyStrict, OCSPPolicyMustStaple:
+		// ok
+	default:
+		return nil, nil, fmt.Errorf("unknown OCSP staple policy %q", policy)
+	}
+
+	raw := sec.Data()[OCSPStapleKey]
+	if name := tls.OCSPSecretName; !isBlank(name) {
+		raw = nil
+		m := splitSecret(name, ir.Namespace)
+		if s, ok := b.source.secrets[m]; ok && b.delegationPermitted(m, ir.Namespace) {
+			raw = s.Data[OCSPStapleKey]
+		}
+	}
+
+	if len(raw) == 0 {
+		if policy == OCSPPolicyMustStaple {
+			return nil, nil, errors.New("OCSP response required by must-staple policy but not found")
+		}
+		return nil, nil, nil
+	}
+
+	cert, err := sec.Certificate()
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := parseOCSPResponse(raw, cert, time.Now())
+	if err == nil && resp.Stale {
+		err = fmt.Errorf("is stale, next update was due at %s", resp.NextUpdate.UTC().Format(time.RFC3339))
+	}
+	if err != nil {
+		if policy == OCSPPolicyLenient {
+			return resp, []string{fmt.Sprintf("OCSP response %v, not stapled", err)}, nil
+		}
+		return nil, nil, fmt.Errorf("OCSP response %v", err)
+	}
+	return resp, nil, nil
+}
+
+// parseOCSPResponse parses the DER encoded OCSP response raw. If cert
+// is not nil the response is checked to ensure it refers to cert.
+func parseOCSPResponse(raw []byte, cert *x509.Certificate, now time.Time) (*OCSPResponse, error) {
+	resp, err := ocsp.ParseResponse(raw, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not be parsed: %v", err)
+	}
+	if cert != nil && resp.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		return nil, errors.New("does not match certificate")
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		// ok
+	case ocsp.Revoked:
+		return nil, errors.New("reports the certificate as revoked")
+	default:
+		return nil, errors.New("reports the certificate status as unknown")
+	}
+	return &OCSPResponse{
+		Raw:        raw,
+		NextUpdate: resp.NextUpdate,
+		Stale:      !resp.NextUpdate.IsZero() && now.After(resp.NextUpdate),
+	}, nil
+}
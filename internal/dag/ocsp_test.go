@@ -0,0 +1,246 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OCSP_RESPONSE is a good OCSP response for CERTIFICATE whose
+// next update is due on 2126-01-01.
+const OCSP_RESPONSE = "MIIBGwoBAKCCARQwggEQBgkrBgEFBQcwAQEEggEBMIH+MIGkoRgwFjEUMBIGA1UEAwwLZXhhbXBsZS5jb20YDzIwMjYxMDE4MTQ0MzAwWjB3MHUwTTAJBgUrDgMCGgUABBTv+OVj83OXEaAXLD/ioahH2q0CYgQUNkJsfMJKiCn3vkQLtfMuwF+flloCFCh3qhSlwn4jP8chIPHV5h/PYZrBgAAYDzIwMTkwMTAxMDAwMDAwWqARGA8yMTI2MDEwMTAwMDAwMFowCgYIKoZIzj0EAwIDSQAwRgIhAOnfmCj/q/oKuSV/EOC/NPs+8O6s0S9YrwZTFh2Jy3d+AiEA4dNlWrP/UpBytbX9o9F4kDOCCxJZVgO3HG0no+LcHbc="
+
+// STALE_OCSP_RESPONSE is a good OCSP response for CERTIFICATE whose
+// next update was due on 2019-01-02.
+const STALE_OCSP_RESPONSE = "MIIBGAoBAKCCAREwggENBgkrBgEFBQcwAQEEgf8wgfwwgaShGDAWMRQwEgYDVQQDDAtleGFtcGxlLmNvbRgPMjAyNjEwMTgxNDQzMDBaMHcwdTBNMAkGBSsOAwIaBQAEFO/45WPzc5cRoBcsP+KhqEfarQJiBBQ2Qmx8wkqIKfe+RAu18y7AX5+WWgIUKHeqFKXCfiM/xyEg8dXmH89hmsGAABgPMjAxOTAxMDEwMDAwMDBaoBEYDzIwMTkwMTAyMDAwMDAwWjAKBggqhkjOPQQDAgNHADBEAiA5Y7fyYYYbUyqH0IWnqY+O/o+RrINYFlBn8Gx8BC2K5gIgC/Ynw6/LT86uzcx2RM3n1ZuhFQkFC0qLaU3npNNZZAY="
+
+func ocspResponse(t *testing.T, s string) []byte {
+	t.Helper()
+	der, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestParseOCSPResponse(t *testing.T) {
+	cert, err := parseCertificate([]byte(CERTIFICATE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		raw     []byte
+		want    *OCSPResponse
+		wantErr bool
+	}{
+		"fresh response": {
+			raw: ocspResponse(t, OCSP_RESPONSE),
+			want: &OCSPResponse{
+				Raw:        ocspResponse(t, OCSP_RESPONSE),
+				NextUpdate: time.Date(2126, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"stale response": {
+			raw: ocspResponse(t, STALE_OCSP_RESPONSE),
+			want: &OCSPResponse{
+				Raw:        ocspResponse(t, STALE_OCSP_RESPONSE),
+				NextUpdate: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+				Stale:      true,
+			},
+		},
+		"garbage": {
+			raw:     []byte("not an ocsp response"),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseOCSPResponse(tc.raw, cert, now)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestDAGIngressRouteOCSPStaple(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	secret := func(name, staple string) *v1.Secret {
+		s := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Data: secretdata(CERTIFICATE, PRIVATE_KEY),
+		}
+		if staple != "" {
+			s.Data[OCSPStapleKey] = ocspResponse(t, staple)
+		}
+		return s
+	}
+
+	ocspSecret := func(name, staple string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				OCSPStapleKey: ocspResponse(t, staple),
+			},
+		}
+	}
+
+	ingressroute := func(secretName, policy, ocspSecretName string) *ingressroutev1.IngressRoute {
+		return &ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example",
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: "example.com",
+					TLS: &ingressroutev1.TLS{
+						SecretName:       secretName,
+						OCSPStaplePolicy: policy,
+						OCSPSecretName:   ocspSecretName,
+					},
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		ir         *ingressroutev1.IngressRoute
+		objs       []interface{}
+		wantStatus string
+		wantDesc   string
+		wantStaple []byte
+	}{
+		"no staple, lenient": {
+			ir:         ingressroute("tls", "", ""),
+			objs:       []interface{}{secret("tls", "")},
+			wantStatus: StatusValid,
+			wantDesc:   "valid IngressRoute",
+		},
+		"staple in tls secret": {
+			ir:         ingressroute("tls", "", ""),
+			objs:       []interface{}{secret("tls", OCSP_RESPONSE)},
+			wantStatus: StatusValid,
+			wantDesc:   "valid IngressRoute",
+			wantStaple: ocspResponse(t, OCSP_RESPONSE),
+		},
+		"staple in separate secret": {
+			ir:         ingressroute("tls", OCSPPolicyStrict, "ocsp"),
+			objs:       []interface{}{secret("tls", ""), ocspSecret("ocsp", OCSP_RESPONSE)},
+			wantStatus: StatusValid,
+			wantDesc:   "valid IngressRoute",
+			wantStaple: ocspResponse(t, OCSP_RESPONSE),
+		},
+		"stale staple, lenient": {
+			ir:         ingressroute("tls", OCSPPolicyLenient, ""),
+			objs:       []interface{}{secret("tls", STALE_OCSP_RESPONSE)},
+			wantStatus: StatusWarning,
+			wantDesc:   "valid IngressRoute with warnings: OCSP response is stale, next update was due at 2019-01-02T00:00:00Z, not stapled",
+		},
+		"stale staple, strict": {
+			ir:         ingressroute("tls", OCSPPolicyStrict, ""),
+			objs:       []interface{}{secret("tls", STALE_OCSP_RESPONSE)},
+			wantStatus: StatusInvalid,
+			wantDesc:   "TLS Secret default/tls: OCSP response is stale, next update was due at 2019-01-02T00:00:00Z",
+		},
+		"no staple, strict": {
+			ir:         ingressroute("tls", OCSPPolicyStrict, ""),
+			objs:       []interface{}{secret("tls", "")},
+			wantStatus: StatusValid,
+			wantDesc:   "valid IngressRoute",
+		},
+		"no staple, must-staple": {
+			ir:         ingressroute("tls", OCSPPolicyMustStaple, ""),
+			objs:       []interface{}{secret("tls", "")},
+			wantStatus: StatusInvalid,
+			wantDesc:   "TLS Secret default/tls: OCSP response required by must-staple policy but not found",
+		},
+		"unknown policy": {
+			ir:         ingressroute("tls", "sometimes", ""),
+			objs:       []interface{}{secret("tls", OCSP_RESPONSE)},
+			wantStatus: StatusInvalid,
+			wantDesc:   `TLS Secret default/tls: unknown OCSP staple policy "sometimes"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b Builder
+			b.Insert(s1)
+			b.Insert(tc.ir)
+			for _, o := range tc.objs {
+				b.Insert(o)
+			}
+			dag := b.Build()
+
+			want := []Status{{Object: tc.ir, Status: tc.wantStatus, Description: tc.wantDesc, Vhost: "example.com"}}
+			if diff := cmp.Diff(want, dag.Statuses()); diff != "" {
+				t.Fatal(diff)
+			}
+
+			var staple []byte
+			dag.Visit(func(v Vertex) {
+				l, ok := v.(*Listener)
+				if !ok {
+					return
+				}
+				if svh, ok := l.VirtualHosts["example.com"].(*SecureVirtualHost); ok {
+					staple = svh.Secret.OCSPStaple()
+				}
+			})
+			if diff := cmp.Diff(tc.wantStaple, staple); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@
 package dag
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/heptio/contour/apis/contour/v1beta1"
@@ -66,6 +67,24 @@ func parseTimeout(timeout string) time.Duration {
 	return d
 }
 
+// policyWarnings returns a warning for each timeout of route's
+// timeout and retry policies which cannot be parsed. Such timeouts
+// are otherwise treated as infinite, or as Envoy's default.
+func policyWarnings(route v1beta1.Route) []string {
+	var warnings []string
+	if tp := route.TimeoutPolicy; tp != nil && tp.Request != "" && tp.Request != "infinity" {
+		if _, err := time.ParseDuration(tp.Request); err != nil {
+			warnings = append(warnings, fmt.Sprintf("route %q: timeoutPolicy.request %q is not a valid duration", route.Match, tp.Request))
+		}
+	}
+	if rp := route.RetryPolicy; rp != nil && rp.PerTryTimeout != "" {
+		if _, err := time.ParseDuration(rp.PerTryTimeout); err != nil {
+			warnings = append(warnings, fmt.Sprintf("route %q: retryPolicy.perTryTimeout %q is not a valid duration", route.Match, rp.PerTryTimeout))
+		}
+	}
+	return warnings
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
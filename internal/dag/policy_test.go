@@ -165,3 +165,51 @@ func TestParseTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestPolicyWarnings(t *testing.T) {
+	tests := map[string]struct {
+		route v1beta1.Route
+		want  []string
+	}{
+		"no policies": {
+			route: v1beta1.Route{Match: "/"},
+			want:  nil,
+		},
+		"valid timeouts": {
+			route: v1beta1.Route{
+				Match: "/",
+				TimeoutPolicy: &v1beta1.TimeoutPolicy{
+					Request: "infinity",
+				},
+				RetryPolicy: &v1beta1.RetryPolicy{
+					PerTryTimeout: "150ms",
+				},
+			},
+			want: nil,
+		},
+		"invalid timeouts": {
+			route: v1beta1.Route{
+				Match: "/",
+				TimeoutPolicy: &v1beta1.TimeoutPolicy{
+					Request: "10 seconds",
+				},
+				RetryPolicy: &v1beta1.RetryPolicy{
+					PerTryTimeout: "infinity",
+				},
+			},
+			want: []string{
+				`route "/": timeoutPolicy.request "10 seconds" is not a valid duration`,
+				`route "/": retryPolicy.perTryTimeout "infinity" is not a valid duration`,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := policyWarnings(tc.route)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"sort"
+	"strings"
+
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"k8s.io/api/core/v1"
+)
+
+// The types of the conditions reported in the status of an IngressRoute.
+const (
+	ConditionValid              = "Valid"
+	ConditionDelegationResolved = "DelegationResolved"
+	ConditionTLSReady           = "TLSReady"
+	ConditionServicesResolved   = "ServicesResolved"
+)
+
+// A Report collects what one build found about an IngressRoute,
+// across every delegation chain which reached it.
+type Report struct {
+	// Vhosts are the fqdns of the virtual hosts the IngressRoute
+	// contributes to.
+	Vhosts []string
+
+	// Errors are the problems which make the IngressRoute invalid.
+	Errors []string
+
+	// Warnings are the problems Contour tolerates.
+	Warnings []string
+
+	// Conditions are the DelegationResolved, TLSReady and
+	// ServicesResolved conditions of the IngressRoute, in that
+	// order. A condition is omitted if it does not apply; for
+	// example TLSReady for an IngressRoute without TLS.
+	Conditions []ingressroutev1.Condition
+}
+
+// Report returns the report of ir.
+func (d *DAG) Report(ir *ingressroutev1.IngressRoute) Report {
+	return d.reports[meta{name: ir.Name, namespace: ir.Namespace}]
+}
+
+// IngressRouteStatus returns the status of st.Object; the status
+// and description of st, the Valid condition they imply, and the
+// report of st.Object. If st is invalid for a reason this DAG did
+// not record, its description leads the errors of the status.
+func (d *DAG) IngressRouteStatus(st Status) ingressroutev1.Status {
+	r := d.Report(st.Object)
+	valid := ingressroutev1.Condition{
+		Type:    ConditionValid,
+		Status:  string(v1.ConditionTrue),
+		Message: st.Description,
+	}
+	switch st.Status {
+	case StatusInvalid:
+		valid.Status = string(v1.ConditionFalse)
+		valid.Reason = "Invalid"
+	case StatusOrphaned:
+		valid.Status = string(v1.ConditionFalse)
+		valid.Reason = "Orphaned"
+	}
+	errs := r.Errors
+	if st.Status == StatusInvalid && !containsString(errs, st.Description) {
+		errs = append([]string{st.Description}, errs...)
+	}
+	return ingressroutev1.Status{
+		CurrentStatus: st.Status,
+		Description:   st.Description,
+		Conditions:    append([]ingressroutev1.Condition{valid}, r.Conditions...),
+		Errors:        errs,
+		Warnings:      r.Warnings,
+		VirtualHosts:  r.Vhosts,
+	}
+}
+
+// A check is the outcome of one check of a condition.
+// A check with a blank reason passed.
+type check struct {
+	reason, message string
+}
+
+// setCondition records the outcome of a check of the condition typ of
+// ir. If reason is blank the check passed, otherwise reason and message
+// describe why it failed. A condition is true if all its checks passed.
+func (b *builder) setCondition(ir *ingressroutev1.IngressRoute, typ, reason, message string) {
+	if b.checks == nil {
+		b.checks = make(map[meta]map[string][]check)
+	}
+	m := meta{name: ir.Name, namespace: ir.Namespace}
+	if b.checks[m] == nil {
+		b.checks[m] = make(map[string][]check)
+	}
+	checks := b.checks[m][typ]
+	c := check{reason: reason, message: message}
+	if reason != "" && !containsCheck(checks, c) {
+		checks = append(checks, c)
+	}
+	b.checks[m][typ] = checks
+}
+
+func containsCheck(checks []check, c check) bool {
+	for _, x := range checks {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+// conditions returns the conditions checked for m. The reason of a
+// false condition is that of its first failed check.
+func (b *builder) conditions(m meta) []ingressroutev1.Condition {
+	var conditions []ingressroutev1.Condition
+	for _, typ := range []string{ConditionDelegationResolved, ConditionTLSReady, ConditionServicesResolved} {
+		checks, ok := b.checks[m][typ]
+		if !ok {
+			continue
+		}
+		c := ingressroutev1.Condition{
+			Type:   typ,
+			Status: string(v1.ConditionTrue),
+		}
+		if len(checks) > 0 {
+			var messages []string
+			for _, check := range checks {
+				messages = append(messages, check.message)
+			}
+			c.Status = string(v1.ConditionFalse)
+			c.Reason = checks[0].reason
+			c.Message = strings.Join(messages, ", ")
+		}
+		conditions = append(conditions, c)
+	}
+	return conditions
+}
+
+// reports returns the report of each IngressRoute with a status.
+func (b *builder) reports() map[meta]Report {
+	reports := make(map[meta]Report)
+	for _, st := range b.statuses {
+		m := meta{name: st.Object.Name, namespace: st.Object.Namespace}
+		r, ok := reports[m]
+		if !ok {
+			r = Report{
+				Errors:     b.errors[m],
+				Warnings:   b.warnings[m],
+				Conditions: b.conditions(m),
+			}
+		}
+		valid := st.Status == StatusValid || st.Status == StatusWarning
+		if valid && st.Vhost != "" && !containsString(r.Vhosts, st.Vhost) {
+			r.Vhosts = append(r.Vhosts, st.Vhost)
+			sort.Strings(r.Vhosts)
+		}
+		reports[m] = r
+	}
+	return reports
+}
@@ -0,0 +1,258 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDAGIngressRouteStatusReport(t *testing.T) {
+	service := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Protocol: "TCP",
+					Port:     8080,
+				}},
+			},
+		}
+	}
+	ingressroute := func(name string, vhost *ingressroutev1.VirtualHost, routes ...ingressroutev1.Route) *ingressroutev1.IngressRoute {
+		return &ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: vhost,
+				Routes:      routes,
+			},
+		}
+	}
+	route := func(match, service string, port, weight int) ingressroutev1.Route {
+		return ingressroutev1.Route{
+			Match: match,
+			Services: []ingressroutev1.Service{{
+				Name:   service,
+				Port:   port,
+				Weight: weight,
+			}},
+		}
+	}
+	delegate := func(match, name string) ingressroutev1.Route {
+		return ingressroutev1.Route{
+			Match: match,
+			Delegate: &ingressroutev1.Delegate{
+				Name: name,
+			},
+		}
+	}
+
+	// ir1 has three broken routes.
+	ir1 := ingressroute("broken", &ingressroutev1.VirtualHost{Fqdn: "example.com"},
+		route("/", "kuard", 8080, 0),
+		route("/admin", "admin", 0, 0),
+		route("/static", "static", 8080, -1),
+	)
+
+	// ir2 is delegated to by both ir3 and ir4.
+	ir2 := ingressroute("child", nil, route("/", "kuard", 8080, 0))
+	ir3 := ingressroute("example-com", &ingressroutev1.VirtualHost{Fqdn: "example.com"}, delegate("/", "child"))
+	ir4 := ingressroute("example-org", &ingressroutev1.VirtualHost{Fqdn: "example.org"}, delegate("/", "child"))
+
+	// ir5 uses a secret which is not delegated to its namespace,
+	// and delegates to an ingressroute which does not exist.
+	ir5 := ingressroute("secure", &ingressroutev1.VirtualHost{
+		Fqdn: "example.com",
+		TLS: &ingressroutev1.TLS{
+			SecretName: "certs/wildcard",
+		},
+	}, route("/", "kuard", 8080, 0), delegate("/api", "missing"))
+	sec := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wildcard",
+			Namespace: "certs",
+		},
+		Data: map[string][]byte{
+			v1.TLSCertKey:       []byte("certificate"),
+			v1.TLSPrivateKeyKey: []byte("key"),
+		},
+	}
+
+	servicesResolved := ingressroutev1.Condition{Type: ConditionServicesResolved, Status: "True"}
+
+	tests := map[string]struct {
+		objs []interface{}
+		ir   *ingressroutev1.IngressRoute
+		want ingressroutev1.Status
+	}{
+		"every route error": {
+			objs: []interface{}{service("admin"), service("static"), ir1},
+			ir:   ir1,
+			want: ingressroutev1.Status{
+				CurrentStatus: StatusInvalid,
+				Description:   `route "/admin": service "admin": port must be in the range 1-65535`,
+				Conditions: []ingressroutev1.Condition{{
+					Type:    ConditionValid,
+					Status:  "False",
+					Reason:  "Invalid",
+					Message: `route "/admin": service "admin": port must be in the range 1-65535`,
+				}, {
+					Type:    ConditionServicesResolved,
+					Status:  "False",
+					Reason:  "ServiceNotFound",
+					Message: `route "/": service default/kuard/8080: not found`,
+				}},
+				Errors: []string{
+					`route "/admin": service "admin": port must be in the range 1-65535`,
+					`route "/static": service "static": weight must be greater than or equal to zero`,
+				},
+			},
+		},
+		"delegated to by two vhosts": {
+			objs: []interface{}{service("kuard"), ir2, ir3, ir4},
+			ir:   ir2,
+			want: ingressroutev1.Status{
+				CurrentStatus: StatusValid,
+				Description:   "valid IngressRoute",
+				Conditions: []ingressroutev1.Condition{{
+					Type:    ConditionValid,
+					Status:  "True",
+					Message: "valid IngressRoute",
+				}, servicesResolved},
+				VirtualHosts: []string{"example.com", "example.org"},
+			},
+		},
+		"delegating root": {
+			objs: []interface{}{service("kuard"), ir2, ir3},
+			ir:   ir3,
+			want: ingressroutev1.Status{
+				CurrentStatus: StatusValid,
+				Description:   "valid IngressRoute",
+				Conditions: []ingressroutev1.Condition{{
+					Type:    ConditionValid,
+					Status:  "True",
+					Message: "valid IngressRoute",
+				}, {
+					Type:   ConditionDelegationResolved,
+					Status: "True",
+				}},
+				VirtualHosts: []string{"example.com"},
+			},
+		},
+		"unresolved delegate and secret": {
+			objs: []interface{}{service("kuard"), sec, ir5},
+			ir:   ir5,
+			want: ingressroutev1.Status{
+				CurrentStatus: StatusValid,
+				Description:   "valid IngressRoute",
+				Conditions: []ingressroutev1.Condition{{
+					Type:    ConditionValid,
+					Status:  "True",
+					Message: "valid IngressRoute",
+				}, {
+					Type:    ConditionDelegationResolved,
+					Status:  "False",
+					Reason:  "DelegateNotFound",
+					Message: `route "/api": delegate default/missing: not found`,
+				}, {
+					Type:    ConditionTLSReady,
+					Status:  "False",
+					Reason:  "SecretNotDelegated",
+					Message: `TLS Secret certs/wildcard is not delegated to namespace "default"`,
+				}, servicesResolved},
+				VirtualHosts: []string{"example.com"},
+			},
+		},
+		"orphaned": {
+			objs: []interface{}{service("kuard"), ir2},
+			ir:   ir2,
+			want: ingressroutev1.Status{
+				CurrentStatus: StatusOrphaned,
+				Description:   "this IngressRoute is not part of a delegation chain from a root IngressRoute",
+				Conditions: []ingressroutev1.Condition{{
+					Type:    ConditionValid,
+					Status:  "False",
+					Reason:  "Orphaned",
+					Message: "this IngressRoute is not part of a delegation chain from a root IngressRoute",
+				}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b Builder
+			for _, o := range tc.objs {
+				b.Insert(o)
+			}
+			dag := b.Build()
+
+			var found bool
+			for _, st := range dag.Statuses() {
+				if st.Object != tc.ir {
+					continue
+				}
+				found = true
+				// each delegation chain which reaches tc.ir
+				// yields the same status.
+				if diff := cmp.Diff(tc.want, dag.IngressRouteStatus(st)); diff != "" {
+					t.Fatal(diff)
+				}
+			}
+			if !found {
+				t.Fatalf("no status for %s/%s", tc.ir.Namespace, tc.ir.Name)
+			}
+		})
+	}
+}
+
+func TestDAGIngressRouteStatusRejected(t *testing.T) {
+	var dag DAG
+	ir := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+	}
+	got := dag.IngressRouteStatus(Status{
+		Object:      ir,
+		Status:      StatusInvalid,
+		Description: "Envoy rejected the configuration of this virtual host",
+		Vhost:       "example.com",
+	})
+	want := ingressroutev1.Status{
+		CurrentStatus: StatusInvalid,
+		Description:   "Envoy rejected the configuration of this virtual host",
+		Conditions: []ingressroutev1.Condition{{
+			Type:    ConditionValid,
+			Status:  "False",
+			Reason:  "Invalid",
+			Message: "Envoy rejected the configuration of this virtual host",
+		}},
+		Errors: []string{"Envoy rejected the configuration of this virtual host"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+)
+
+// The rules in this file are applied both by the builder, which marks
+// IngressRoutes which break them invalid, and by ValidateIngressRoute,
+// which reports them before an IngressRoute is accepted.
+
+const (
+	fqdnRequired         = "Spec.VirtualHost.Fqdn must be specified"
+	virtualHostPortRange = "Spec.VirtualHost.Port must be in the range 1-65535"
+)
+
+// fqdnPattern matches the fqdns of root ingressroutes; "*", or a DNS-1123
+// subdomain in any case, optionally prefixed by a "*." wildcard label.
+// It is also the Pattern of VirtualHost.Fqdn in the CRD validation schema,
+// the two must be kept in step.
+var fqdnPattern = regexp.MustCompile(`^(\*|(\*\.)?[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?)*)$`)
+
+// validateFqdn returns an error if fqdn is not blank and
+// does not match fqdnPattern.
+func validateFqdn(fqdn string) error {
+	if !isBlank(fqdn) && !fqdnPattern.MatchString(fqdn) {
+		return fmt.Errorf("Spec.VirtualHost.Fqdn %q is not a valid hostname", fqdn)
+	}
+	return nil
+}
+
+// validateRoute returns an error if route both delegates and
+// forwards to services.
+func validateRoute(route ingressroutev1.Route) error {
+	if len(route.Services) > 0 && route.Delegate != nil {
+		return fmt.Errorf("route %q: cannot specify services and delegate in the same route", route.Match)
+	}
+	return nil
+}
+
+// validatePrefix returns an error if route, a route of an ingressroute
+// delegated prefixMatch, forwards a path outside of prefixMatch.
+func validatePrefix(route ingressroutev1.Route, prefixMatch string) error {
+	if len(route.Services) > 0 && !matchesPathPrefix(route.Match, prefixMatch) {
+		return fmt.Errorf("the path prefix %q does not match the parent's path prefix %q", route.Match, prefixMatch)
+	}
+	return nil
+}
+
+// validateService returns an error if the port or weight of service,
+// a service of route, is out of range.
+func validateService(route ingressroutev1.Route, service ingressroutev1.Service) error {
+	if service.Port < 1 || service.Port > 65535 {
+		return fmt.Errorf("route %q: service %q: port must be in the range 1-65535", route.Match, service.Name)
+	}
+	if service.Weight < 0 {
+		return fmt.Errorf("route %q: service %q: weight must be greater than or equal to zero", route.Match, service.Name)
+	}
+	return nil
+}
+
+// routeErrors returns the problems with routes, routes of an ingressroute
+// delegated prefixMatch, which can be found without following delegations.
+func routeErrors(routes []ingressroutev1.Route, prefixMatch string) []string {
+	var errs []string
+	for _, route := range routes {
+		if err := validateRoute(route); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if err := validatePrefix(route, prefixMatch); err != nil {
+			errs = append(errs, err.Error())
+		}
+		for _, service := range route.Services {
+			if err := validateService(route, service); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	return errs
+}
+
+// validatePlaintextRoute returns an error if route, a route of an
+// ingressroute with a tcpproxy, delegates.
+func validatePlaintextRoute(route ingressroutev1.Route) error {
+	switch {
+	case route.Delegate != nil:
+		return fmt.Errorf("route %q: routes cannot be delegated when tcpproxy is present", route.Match)
+	case len(route.Services) == 0:
+		return fmt.Errorf("route %q: routes must have at least one service when tcpproxy is present", route.Match)
+	}
+	return nil
+}
+
+// plaintextRouteErrors returns the problems with routes, the routes
+// of an ingressroute with a tcpproxy.
+func plaintextRouteErrors(routes []ingressroutev1.Route) []string {
+	var errs []string
+	for _, route := range routes {
+		if err := validatePlaintextRoute(route); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		for _, service := range route.Services {
+			if err := validateService(route, service); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	return errs
+}
+
+// validateTCPProxy returns an error if tcpproxy both delegates and
+// forwards to services.
+func validateTCPProxy(tcpproxy *ingressroutev1.TCPProxy) error {
+	if len(tcpproxy.Services) > 0 && tcpproxy.Delegate != nil {
+		return errors.New("tcpproxy: cannot specify services and delegate in the same tcpproxy")
+	}
+	return nil
+}
+
+// fqdnConflict describes the conflict between irs, which all use fqdn.
+func fqdnConflict(fqdn string, irs []*ingressroutev1.IngressRoute) string {
+	var conflicting []string
+	for _, ir := range irs {
+		conflicting = append(conflicting, fmt.Sprintf("%s/%s", ir.Namespace, ir.Name))
+	}
+	sort.Strings(conflicting) // sort for test stability
+	return fmt.Sprintf("fqdn %q is used in multiple IngressRoutes: %s", fqdn, strings.Join(conflicting, ", "))
+}
+
+// ValidateIngressRoute returns a description of each problem with ir
+// which can be found without looking at other objects; the problems
+// for which Contour marks an IngressRoute invalid, and timeouts in the
+// timeout and retry policies of its routes which cannot be parsed.
+func ValidateIngressRoute(ir *ingressroutev1.IngressRoute) []string {
+	var problems []string
+	if vh := ir.Spec.VirtualHost; vh != nil {
+		if isBlank(vh.Fqdn) {
+			problems = append(problems, fqdnRequired)
+		}
+		if err := validateFqdn(vh.Fqdn); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if vh.Port < 0 || vh.Port > 65535 {
+			problems = append(problems, virtualHostPortRange)
+		}
+	}
+	for _, route := range ir.Spec.Routes {
+		if err := validateRoute(route); err != nil {
+			problems = append(problems, err.Error())
+		}
+		for _, service := range route.Services {
+			if err := validateService(route, service); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+		problems = append(problems, policyWarnings(route)...)
+	}
+	if tcpproxy := ir.Spec.TCPProxy; tcpproxy != nil {
+		if err := validateTCPProxy(tcpproxy); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// ValidateTLSCertificateDelegation returns a description of each
+// delegation of d which Contour would ignore.
+func ValidateTLSCertificateDelegation(d *ingressroutev1.TLSCertificateDelegation) []string {
+	var problems []string
+	for i, cd := range d.Spec.Delegations {
+		problems = append(problems, validateCertificateDelegation(i, cd, d.Namespace)...)
+	}
+	return problems
+}
+
+// validateCertificateDelegation returns a description of each problem
+// with cd, the i'th delegation of a TLSCertificateDelegation in
+// namespace. The builder ignores delegations with problems.
+func validateCertificateDelegation(i int, cd ingressroutev1.CertificateDelegation, namespace string) []string {
+	var problems []string
+	switch {
+	case isBlank(cd.SecretName):
+		problems = append(problems, fmt.Sprintf("Spec.Delegations[%d].SecretName must be specified", i))
+	case strings.Contains(cd.SecretName, "/"):
+		problems = append(problems, fmt.Sprintf("Spec.Delegations[%d].SecretName %q must name a secret in namespace %q", i, cd.SecretName, namespace))
+	}
+	switch {
+	case len(cd.TargetNamespaces) == 0:
+		problems = append(problems, fmt.Sprintf("Spec.Delegations[%d].TargetNamespaces must not be empty", i))
+	case len(cd.TargetNamespaces) > 1 && containsString(cd.TargetNamespaces, "*"):
+		problems = append(problems, fmt.Sprintf("Spec.Delegations[%d].TargetNamespaces must not combine \"*\" with other namespaces", i))
+	}
+	return problems
+}
+
+// FQDNConflict returns a description of the conflict between ir and
+// the other IngressRoutes of the cache which use the same fqdn, or
+// the empty string if there are none.
+func (kc *KubernetesCache) FQDNConflict(ir *ingressroutev1.IngressRoute) string {
+	if ir.Spec.VirtualHost == nil || isBlank(ir.Spec.VirtualHost.Fqdn) {
+		return ""
+	}
+	fqdn := ir.Spec.VirtualHost.Fqdn
+
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+
+	irs := []*ingressroutev1.IngressRoute{ir}
+	for m, other := range kc.ingressroutes {
+		if m.name == ir.Name && m.namespace == ir.Namespace {
+			// ir replaces this ingressroute.
+			continue
+		}
+		if other.Spec.VirtualHost != nil && other.Spec.VirtualHost.Fqdn == fqdn {
+			irs = append(irs, other)
+		}
+	}
+	if len(irs) == 1 {
+		return ""
+	}
+	return fqdnConflict(fqdn, irs)
+}
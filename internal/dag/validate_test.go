@@ -0,0 +1,281 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateIngressRoute(t *testing.T) {
+	tests := map[string]struct {
+		spec ingressroutev1.IngressRouteSpec
+		want []string
+	}{
+		"valid": {
+			spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: "example.com",
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name:   "home",
+						Port:   8080,
+						Weight: 10,
+					}},
+					TimeoutPolicy: &ingressroutev1.TimeoutPolicy{
+						Request: "1m",
+					},
+				}},
+			},
+		},
+		"blank fqdn and port out of range": {
+			spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: " ",
+					Port: 65536,
+				},
+			},
+			want: []string{
+				"Spec.VirtualHost.Fqdn must be specified",
+				"Spec.VirtualHost.Port must be in the range 1-65535",
+			},
+		},
+		"invalid fqdn": {
+			spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: "exa mple.com",
+				},
+			},
+			want: []string{
+				`Spec.VirtualHost.Fqdn "exa mple.com" is not a valid hostname`,
+			},
+		},
+		"invalid routes": {
+			spec: ingressroutev1.IngressRouteSpec{
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name: "home",
+						Port: 0,
+					}, {
+						Name:   "away",
+						Port:   80,
+						Weight: -1,
+					}},
+					Delegate: &ingressroutev1.Delegate{
+						Name: "child",
+					},
+					RetryPolicy: &ingressroutev1.RetryPolicy{
+						PerTryTimeout: "5",
+					},
+				}},
+			},
+			want: []string{
+				`route "/": cannot specify services and delegate in the same route`,
+				`route "/": service "home": port must be in the range 1-65535`,
+				`route "/": service "away": weight must be greater than or equal to zero`,
+				`route "/": retryPolicy.perTryTimeout "5" is not a valid duration`,
+			},
+		},
+		"invalid tcpproxy": {
+			spec: ingressroutev1.IngressRouteSpec{
+				TCPProxy: &ingressroutev1.TCPProxy{
+					Services: []ingressroutev1.Service{{
+						Name: "db",
+						Port: 5432,
+					}},
+					Delegate: &ingressroutev1.Delegate{
+						Name: "child",
+					},
+				},
+			},
+			want: []string{
+				"tcpproxy: cannot specify services and delegate in the same tcpproxy",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ValidateIngressRoute(&ingressroutev1.IngressRoute{Spec: tc.spec})
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestValidateFqdn(t *testing.T) {
+	tests := map[string]bool{
+		"example.com":       true,
+		"localhost":         true,
+		"EXAMPLE.COM":       true,
+		"example.xn--p1ai":  true,
+		"a-b.example.com":   true,
+		"*":                 true,
+		"*.example.com":     true,
+		"":                  true, // reported as fqdnRequired
+		"exa mple.com":      false,
+		"example.com.":      false,
+		"-example.com":      false,
+		"example-.com":      false,
+		"*example.com":      false,
+		"www.*.example.com": false,
+		"example_com":       false,
+	}
+
+	for fqdn, valid := range tests {
+		t.Run(fqdn, func(t *testing.T) {
+			err := validateFqdn(fqdn)
+			if valid != (err == nil) {
+				t.Fatalf("expected valid: %v, got: %v", valid, err)
+			}
+		})
+	}
+}
+
+// TestFqdnPatternMatchesSchema asserts that the CRD validation
+// schema accepts the same fqdns as the builder.
+func TestFqdnPatternMatchesSchema(t *testing.T) {
+	src, err := ioutil.ReadFile("../../apis/contour/v1beta1/ingressroute.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "Fqdn string") {
+			continue
+		}
+		got := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i-1]), "//"))
+		want := "+kubebuilder:validation:Pattern=" + fqdnPattern.String()
+		if got != want {
+			t.Fatalf("expected: %s, got: %s", want, got)
+		}
+		return
+	}
+	t.Fatal("VirtualHost.Fqdn not found")
+}
+
+func TestValidateTLSCertificateDelegation(t *testing.T) {
+	tests := map[string]struct {
+		delegations []ingressroutev1.CertificateDelegation
+		want        []string
+	}{
+		"valid": {
+			delegations: []ingressroutev1.CertificateDelegation{{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"*"},
+			}, {
+				SecretName:       "example",
+				TargetNamespaces: []string{"teama", "teamb"},
+			}},
+		},
+		"invalid": {
+			delegations: []ingressroutev1.CertificateDelegation{{
+				TargetNamespaces: []string{"teama"},
+			}, {
+				SecretName: "default/example",
+			}, {
+				SecretName:       "example",
+				TargetNamespaces: []string{"teama", "*"},
+			}},
+			want: []string{
+				"Spec.Delegations[0].SecretName must be specified",
+				`Spec.Delegations[1].SecretName "default/example" must name a secret in namespace "certs"`,
+				"Spec.Delegations[1].TargetNamespaces must not be empty",
+				`Spec.Delegations[2].TargetNamespaces must not combine "*" with other namespaces`,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ValidateTLSCertificateDelegation(&ingressroutev1.TLSCertificateDelegation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "delegation",
+					Namespace: "certs",
+				},
+				Spec: ingressroutev1.TLSCertificateDelegationSpec{
+					Delegations: tc.delegations,
+				},
+			})
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestKubernetesCacheFQDNConflict(t *testing.T) {
+	ingressroute := func(ns, name, fqdn string) *ingressroutev1.IngressRoute {
+		return &ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: fqdn,
+				},
+			},
+		}
+	}
+
+	var kc KubernetesCache
+	kc.Insert(ingressroute("default", "example", "example.com"))
+	kc.Insert(ingressroute("teama", "example", "example.com"))
+	kc.Insert(ingressroute("default", "other", "other.example.com"))
+
+	tests := map[string]struct {
+		ir   *ingressroutev1.IngressRoute
+		want string
+	}{
+		"new fqdn": {
+			ir:   ingressroute("teamb", "new", "new.example.com"),
+			want: "",
+		},
+		"update of the only ingressroute using the fqdn": {
+			ir:   ingressroute("default", "other", "other.example.com"),
+			want: "",
+		},
+		"conflict": {
+			ir:   ingressroute("teamb", "example", "example.com"),
+			want: `fqdn "example.com" is used in multiple IngressRoutes: default/example, teama/example, teamb/example`,
+		},
+		"update which introduces a conflict": {
+			ir:   ingressroute("default", "other", "example.com"),
+			want: `fqdn "example.com" is used in multiple IngressRoutes: default/example, default/other, teama/example`,
+		},
+		"delegated ingressroute": {
+			ir:   &ingressroutev1.IngressRoute{},
+			want: "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := kc.FQDNConflict(tc.ir)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
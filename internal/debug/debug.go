@@ -12,13 +12,19 @@
 // limitations under the License.
 
 // Package debug provides http endpoints for healthcheck, metrics,
-// and pprof debugging.
+// pprof debugging, and explaining how requests are routed.
 package debug
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"net/url"
+	"strings"
 
+	"github.com/ghodss/yaml"
+	"github.com/heptio/contour/internal/contour"
 	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/httpsvc"
 )
@@ -28,6 +34,14 @@ type Service struct {
 	httpsvc.Service
 
 	*dag.Builder
+
+	// LoadReports, if set, serves the aggregated
+	// Envoy load reports at /debug/loadreports.
+	LoadReports http.Handler
+
+	// XDSStatus, if set, serves the status of each
+	// xDS stream at /debug/xds.
+	XDSStatus http.Handler
 }
 
 // Start fulfills the g.Start contract.
@@ -35,6 +49,13 @@ type Service struct {
 func (svc *Service) Start(stop <-chan struct{}) error {
 	registerProfile(&svc.ServeMux)
 	registerDotWriter(&svc.ServeMux, svc.Builder)
+	registerExplainer(&svc.ServeMux, svc.Builder)
+	if svc.LoadReports != nil {
+		svc.ServeMux.Handle("/debug/loadreports", svc.LoadReports)
+	}
+	if svc.XDSStatus != nil {
+		svc.ServeMux.Handle("/debug/xds", svc.XDSStatus)
+	}
 	return svc.Service.Start(stop)
 }
 
@@ -50,11 +71,76 @@ func registerProfile(mux *http.ServeMux) {
 	mux.Handle("/debug/pprof/threadcreate", pprof.Handler("threadcreate"))
 }
 
+// registerDotWriter serves /debug/dag, the DAG in DOT format by
+// default, or as a tree of JSON or YAML with ?format=json or yaml.
+// The tree may be restricted with the vhost and namespace parameters.
 func registerDotWriter(mux *http.ServeMux, b *dag.Builder) {
 	mux.HandleFunc("/debug/dag", func(w http.ResponseWriter, r *http.Request) {
-		dw := &dotWriter{
-			Builder: b,
+		q := r.URL.Query()
+		switch format := q.Get("format"); format {
+		case "", "dot":
+			dw := &dotWriter{
+				Builder: b,
+			}
+			dw.writeDot(w)
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			enc.Encode(buildTree(b.Build(), newTreeFilter(q)))
+		case "yaml":
+			buf, err := yaml.Marshal(buildTree(b.Build(), newTreeFilter(q)))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/x-yaml")
+			w.Write(buf)
+		default:
+			http.Error(w, fmt.Sprintf("unsupported format %q, expected dot, json, or yaml", format), http.StatusBadRequest)
+		}
+	})
+}
+
+// registerExplainer serves /debug/explain, which explains how Envoy
+// routes the request described by its query parameters; scheme, host,
+// path, listener, and header, which may be repeated, as "Name: value".
+func registerExplainer(mux *http.ServeMux, b *dag.Builder) {
+	mux.HandleFunc("/debug/explain", func(w http.ResponseWriter, r *http.Request) {
+		req, err := explainRequest(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-		dw.writeDot(w)
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(contour.Explain(b.Build(), req))
 	})
 }
+
+// explainRequest returns the contour.ExplainRequest described by q.
+func explainRequest(q url.Values) (contour.ExplainRequest, error) {
+	req := contour.ExplainRequest{
+		Scheme:   q.Get("scheme"),
+		Host:     q.Get("host"),
+		Path:     q.Get("path"),
+		Listener: q.Get("listener"),
+	}
+	switch strings.ToLower(req.Scheme) {
+	case "", "http", "https":
+	default:
+		return req, fmt.Errorf("unsupported scheme %q, expected http or https", req.Scheme)
+	}
+	for _, h := range q["header"] {
+		kv := strings.SplitN(h, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return req, fmt.Errorf("malformed header %q, expected \"Name: value\"", h)
+		}
+		if req.Headers == nil {
+			req.Headers = make(map[string]string)
+		}
+		req.Headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return req, nil
+}
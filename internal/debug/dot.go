@@ -45,6 +45,8 @@ func (c *ctx) writeVertex(v dag.Vertex) {
 	switch v := v.(type) {
 	case *dag.Listener:
 		fmt.Fprintf(c.w, `"%p" [shape=record, label="{listener|%s:%d}"]`+"\n", v, v.Address, v.Port)
+	case *dag.TCPListener:
+		fmt.Fprintf(c.w, `"%p" [shape=record, label="{tcplistener|%s:%d|%s}"]`+"\n", v, v.Address, v.Port, v.Name)
 	case *dag.Secret:
 		fmt.Fprintf(c.w, `"%p" [shape=record, label="{secret|%s/%s}"]`+"\n", v, v.Namespace(), v.Name())
 	case *dag.HTTPService:
@@ -0,0 +1,408 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/heptio/contour/internal/envoy"
+)
+
+// A tree is a stable, human readable, representation of a DAG;
+// listeners, their vhosts, routes, clusters, services, and secrets.
+// Unlike the DOT output it contains no pointers, so the trees of
+// two equivalent DAGs are identical.
+type tree struct {
+	Listeners []*listenerNode `json:"listeners"`
+}
+
+type listenerNode struct {
+	// Name is the name of an additional listener, or the fqdn
+	// of the IngressRoute which declared a TCP listener.
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address,omitempty"`
+	Port    int    `json:"port"`
+
+	Fleets  []string     `json:"fleets,omitempty"`
+	Sources []sourceNode `json:"sources,omitempty"`
+
+	VirtualHosts []*vhostNode `json:"virtual_hosts,omitempty"`
+
+	// TCPProxy is set for TCP listeners.
+	TCPProxy *tcpProxyNode `json:"tcpproxy,omitempty"`
+}
+
+type vhostNode struct {
+	Name string `json:"name"`
+
+	// Secret is set for vhosts which terminate TLS.
+	Secret          *secretNode `json:"secret,omitempty"`
+	MinProtoVersion string      `json:"min_proto_version,omitempty"`
+
+	Fleets  []string     `json:"fleets,omitempty"`
+	Sources []sourceNode `json:"sources,omitempty"`
+
+	// Routes, in the order Envoy tries them.
+	Routes []*routeNode `json:"routes,omitempty"`
+
+	// TCPProxy is set for TLS passthrough vhosts.
+	TCPProxy *tcpProxyNode `json:"tcpproxy,omitempty"`
+}
+
+type routeNode struct {
+	Prefix        string         `json:"prefix"`
+	Sources       []sourceNode   `json:"sources,omitempty"`
+	HTTPSUpgrade  bool           `json:"https_upgrade,omitempty"`
+	Websocket     bool           `json:"websocket,omitempty"`
+	PrefixRewrite string         `json:"prefix_rewrite,omitempty"`
+	Timeout       string         `json:"timeout,omitempty"`
+	RetryPolicy   *retryNode     `json:"retry_policy,omitempty"`
+	Clusters      []*clusterNode `json:"clusters,omitempty"`
+}
+
+type retryNode struct {
+	RetryOn       string `json:"retry_on"`
+	NumRetries    int    `json:"num_retries,omitempty"`
+	PerTryTimeout string `json:"per_try_timeout,omitempty"`
+}
+
+type tcpProxyNode struct {
+	Sources  []sourceNode   `json:"sources,omitempty"`
+	Clusters []*clusterNode `json:"clusters"`
+}
+
+type clusterNode struct {
+	// Name is the name of the Envoy cluster.
+	Name                 string                      `json:"name"`
+	Weight               int                         `json:"weight,omitempty"`
+	LoadBalancerStrategy string                      `json:"load_balancer_strategy,omitempty"`
+	HealthCheck          *ingressroutev1.HealthCheck `json:"health_check,omitempty"`
+	UpstreamValidation   *upstreamValidationNode     `json:"upstream_validation,omitempty"`
+	Service              serviceNode                 `json:"service"`
+}
+
+type upstreamValidationNode struct {
+	CACertificate *secretNode `json:"ca_certificate,omitempty"`
+	SubjectName   string      `json:"subject_name,omitempty"`
+}
+
+type serviceNode struct {
+	Namespace          string `json:"namespace"`
+	Name               string `json:"name"`
+	Port               int32  `json:"port"`
+	Protocol           string `json:"protocol,omitempty"`
+	ExternalName       string `json:"external_name,omitempty"`
+	MaxConnections     int    `json:"max_connections,omitempty"`
+	MaxPendingRequests int    `json:"max_pending_requests,omitempty"`
+	MaxRequests        int    `json:"max_requests,omitempty"`
+	MaxRetries         int    `json:"max_retries,omitempty"`
+	LoadReporting      bool   `json:"load_reporting,omitempty"`
+}
+
+// secretNode identifies a secret. Its key material is never included.
+type secretNode struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	OCSP      *ocspNode `json:"ocsp,omitempty"`
+}
+
+type ocspNode struct {
+	NextUpdate time.Time `json:"next_update,omitempty"`
+	Stale      bool      `json:"stale,omitempty"`
+}
+
+type sourceNode struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// A treeFilter restricts a tree to some vhosts, or to the parts
+// of the DAG declared in, or forwarding to, some namespaces.
+type treeFilter struct {
+	vhosts     map[string]bool
+	namespaces map[string]bool
+}
+
+// newTreeFilter returns the treeFilter described by the vhost and
+// namespace parameters of q. Each may be repeated or comma separated.
+func newTreeFilter(q url.Values) treeFilter {
+	set := func(values []string) map[string]bool {
+		var m map[string]bool
+		for _, v := range values {
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s == "" {
+					continue
+				}
+				if m == nil {
+					m = make(map[string]bool)
+				}
+				m[s] = true
+			}
+		}
+		return m
+	}
+	return treeFilter{
+		vhosts:     set(q["vhost"]),
+		namespaces: set(q["namespace"]),
+	}
+}
+
+// vhost returns true if the vhost called name passes the filter.
+func (f treeFilter) vhost(name string) bool {
+	return f.vhosts == nil || f.vhosts[name]
+}
+
+// namespace returns true if any of sources, or the upstream of any
+// of clusters, is in one of the filter's namespaces.
+func (f treeFilter) namespace(sources []sourceNode, clusters []*clusterNode) bool {
+	if f.namespaces == nil {
+		return true
+	}
+	for _, s := range sources {
+		if f.namespaces[s.Namespace] {
+			return true
+		}
+	}
+	for _, c := range clusters {
+		if f.namespaces[c.Service.Namespace] {
+			return true
+		}
+	}
+	return false
+}
+
+// buildTree returns the tree of d, restricted by f.
+func buildTree(d *dag.DAG, f treeFilter) *tree {
+	t := &tree{
+		Listeners: []*listenerNode{},
+	}
+	d.Visit(func(v dag.Vertex) {
+		switch l := v.(type) {
+		case *dag.Listener:
+			ln := &listenerNode{
+				Name:    l.Name,
+				Address: l.Address,
+				Port:    l.Port,
+			}
+			for _, vh := range l.VirtualHosts {
+				if n := buildVirtualHost(d, vh, f); n != nil {
+					ln.VirtualHosts = append(ln.VirtualHosts, n)
+				}
+			}
+			if len(ln.VirtualHosts) == 0 {
+				return
+			}
+			sort.Slice(ln.VirtualHosts, func(i, j int) bool {
+				return ln.VirtualHosts[i].Name < ln.VirtualHosts[j].Name
+			})
+			t.Listeners = append(t.Listeners, ln)
+		case *dag.TCPListener:
+			if !f.vhost(l.Name) {
+				return
+			}
+			proxy := buildTCPProxy(d, l.TCPProxy)
+			if !f.namespace(proxy.Sources, proxy.Clusters) {
+				return
+			}
+			t.Listeners = append(t.Listeners, &listenerNode{
+				Name:     l.Name,
+				Address:  l.Address,
+				Port:     l.Port,
+				Fleets:   l.Fleets,
+				Sources:  buildSources(d, l),
+				TCPProxy: proxy,
+			})
+		}
+	})
+	sort.Slice(t.Listeners, func(i, j int) bool {
+		a, b := t.Listeners[i], t.Listeners[j]
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Name < b.Name
+	})
+	return t
+}
+
+// buildVirtualHost returns the vhostNode of vertex, or nil if
+// it, or all of its routes, are excluded by f.
+func buildVirtualHost(d *dag.DAG, vertex dag.Vertex, f treeFilter) *vhostNode {
+	var vh *dag.VirtualHost
+	var secure bool
+	n := new(vhostNode)
+	switch v := vertex.(type) {
+	case *dag.VirtualHost:
+		vh = v
+	case *dag.SecureVirtualHost:
+		vh = &v.VirtualHost
+		secure = true
+		if v.Secret != nil {
+			n.Secret = buildSecret(v.Secret)
+		}
+		n.MinProtoVersion = v.MinProtoVersion.String()
+	default:
+		return nil
+	}
+	if !f.vhost(vh.Name) {
+		return nil
+	}
+	n.Name = vh.Name
+	n.Fleets = vh.Fleets
+	n.Sources = buildSources(d, vertex)
+
+	vertex.Visit(func(v dag.Vertex) {
+		switch v := v.(type) {
+		case *dag.Route:
+			r := buildRoute(d, v)
+			// routes are shared by the vhosts of a host, the
+			// upgrade to HTTPS only applies to plain HTTP.
+			r.HTTPSUpgrade = r.HTTPSUpgrade && !secure
+			if f.namespace(r.Sources, r.Clusters) {
+				n.Routes = append(n.Routes, r)
+			}
+		case *dag.TCPProxy:
+			proxy := buildTCPProxy(d, v)
+			if f.namespace(proxy.Sources, proxy.Clusters) {
+				n.TCPProxy = proxy
+			}
+		}
+	})
+	if len(n.Routes) == 0 && n.TCPProxy == nil {
+		return nil
+	}
+	// longest prefix first, the order Envoy tries them.
+	sort.Slice(n.Routes, func(i, j int) bool {
+		return n.Routes[i].Prefix > n.Routes[j].Prefix
+	})
+	return n
+}
+
+func buildRoute(d *dag.DAG, r *dag.Route) *routeNode {
+	n := &routeNode{
+		Prefix:        r.Prefix,
+		Sources:       buildSources(d, r),
+		HTTPSUpgrade:  r.HTTPSUpgrade,
+		Websocket:     r.Websocket,
+		PrefixRewrite: r.PrefixRewrite,
+	}
+	if tp := r.TimeoutPolicy; tp != nil {
+		n.Timeout = formatTimeout(tp.Timeout)
+	}
+	if rp := r.RetryPolicy; rp != nil && rp.RetryOn != "" {
+		n.RetryPolicy = &retryNode{
+			RetryOn:       rp.RetryOn,
+			NumRetries:    rp.NumRetries,
+			PerTryTimeout: formatTimeout(rp.PerTryTimeout),
+		}
+	}
+	for _, c := range r.Clusters {
+		n.Clusters = append(n.Clusters, buildCluster(c))
+	}
+	return n
+}
+
+// formatTimeout formats a timeout where zero means Envoy's default
+// and -1 means no timeout.
+func formatTimeout(d time.Duration) string {
+	switch d {
+	case 0:
+		return ""
+	case -1:
+		return "infinity"
+	default:
+		return d.String()
+	}
+}
+
+func buildTCPProxy(d *dag.DAG, proxy *dag.TCPProxy) *tcpProxyNode {
+	n := &tcpProxyNode{
+		Sources:  buildSources(d, proxy),
+		Clusters: []*clusterNode{},
+	}
+	for _, c := range proxy.Clusters {
+		n.Clusters = append(n.Clusters, buildCluster(c))
+	}
+	return n
+}
+
+func buildCluster(c *dag.Cluster) *clusterNode {
+	n := &clusterNode{
+		Name:                 envoy.Clustername(c),
+		Weight:               c.Weight,
+		LoadBalancerStrategy: c.LoadBalancerStrategy,
+		HealthCheck:          c.HealthCheck,
+	}
+	if uv := c.UpstreamValidation; uv != nil {
+		n.UpstreamValidation = &upstreamValidationNode{
+			SubjectName: uv.SubjectName,
+		}
+		if uv.CACertificate != nil {
+			n.UpstreamValidation.CACertificate = buildSecret(uv.CACertificate)
+		}
+	}
+	var s *dag.TCPService
+	switch u := c.Upstream.(type) {
+	case *dag.HTTPService:
+		s = &u.TCPService
+		n.Service.Protocol = u.Protocol
+	case *dag.TCPService:
+		s = u
+	default:
+		return n
+	}
+	n.Service.Namespace = s.Namespace
+	n.Service.Name = s.Name
+	if s.ServicePort != nil {
+		n.Service.Port = s.Port
+	}
+	n.Service.ExternalName = s.ExternalName
+	n.Service.MaxConnections = s.MaxConnections
+	n.Service.MaxPendingRequests = s.MaxPendingRequests
+	n.Service.MaxRequests = s.MaxRequests
+	n.Service.MaxRetries = s.MaxRetries
+	n.Service.LoadReporting = s.LoadReporting
+	return n
+}
+
+func buildSecret(s *dag.Secret) *secretNode {
+	n := &secretNode{
+		Namespace: s.Namespace(),
+		Name:      s.Name(),
+	}
+	if s.OCSP != nil {
+		n.OCSP = &ocspNode{
+			NextUpdate: s.OCSP.NextUpdate,
+			Stale:      s.OCSP.Stale,
+		}
+	}
+	return n
+}
+
+func buildSources(d *dag.DAG, v dag.Vertex) []sourceNode {
+	var sources []sourceNode
+	for _, s := range d.Sources(v) {
+		sources = append(sources, sourceNode{
+			Kind:      s.Kind,
+			Namespace: s.Namespace,
+			Name:      s.Name,
+		})
+	}
+	return sources
+}
@@ -0,0 +1,252 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/internal/dag"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildTree(t *testing.T) {
+	service := func(ns, name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Protocol: "TCP",
+					Port:     8080,
+				}},
+			},
+		}
+	}
+
+	var b dag.Builder
+	for _, o := range []interface{}{
+		service("default", "kuard"),
+		service("teama", "api"),
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				v1.TLSCertKey:       []byte("certificate"),
+				v1.TLSPrivateKeyKey: []byte("key"),
+			},
+		},
+		&ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "root",
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: "kuard.example.com",
+					TLS: &ingressroutev1.TLS{
+						SecretName: "secret",
+					},
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name:   "kuard",
+						Port:   8080,
+						Weight: 100,
+					}},
+				}, {
+					Match: "/api",
+					Delegate: &ingressroutev1.Delegate{
+						Name:      "api",
+						Namespace: "teama",
+					},
+				}},
+			},
+		},
+		&ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "api",
+				Namespace: "teama",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				Routes: []ingressroutev1.Route{{
+					Match:          "/api",
+					PermitInsecure: true,
+					Services: []ingressroutev1.Service{{
+						Name: "api",
+						Port: 8080,
+					}},
+				}},
+			},
+		},
+		&ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other",
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: "other.example.com",
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				}},
+			},
+		},
+	} {
+		b.Insert(o)
+	}
+	d := b.Build()
+
+	root := sourceNode{Kind: "IngressRoute", Namespace: "default", Name: "root"}
+	api := sourceNode{Kind: "IngressRoute", Namespace: "teama", Name: "api"}
+	kuard := func(weight int) *clusterNode {
+		return &clusterNode{
+			Name:   "default/kuard/8080/da39a3ee5e",
+			Weight: weight,
+			Service: serviceNode{
+				Namespace: "default",
+				Name:      "kuard",
+				Port:      8080,
+			},
+		}
+	}
+	apiRoute := &routeNode{
+		Prefix:  "/api",
+		Sources: []sourceNode{root, api},
+		Clusters: []*clusterNode{{
+			Name: "teama/api/8080/da39a3ee5e",
+			Service: serviceNode{
+				Namespace: "teama",
+				Name:      "api",
+				Port:      8080,
+			},
+		}},
+	}
+	secure := &vhostNode{
+		Name: "kuard.example.com",
+		Secret: &secretNode{
+			Namespace: "default",
+			Name:      "secret",
+		},
+		MinProtoVersion: "TLS_AUTO",
+		Sources:         []sourceNode{root},
+		Routes: []*routeNode{apiRoute, {
+			Prefix:   "/",
+			Sources:  []sourceNode{root},
+			Clusters: []*clusterNode{kuard(100)},
+		}},
+	}
+
+	tests := map[string]struct {
+		query string
+		want  *tree
+	}{
+		"vhost": {
+			query: "vhost=kuard.example.com",
+			want: &tree{
+				Listeners: []*listenerNode{{
+					Port: 80,
+					VirtualHosts: []*vhostNode{{
+						Name:    "kuard.example.com",
+						Sources: []sourceNode{root},
+						Routes: []*routeNode{apiRoute, {
+							Prefix:       "/",
+							Sources:      []sourceNode{root},
+							HTTPSUpgrade: true,
+							Clusters:     []*clusterNode{kuard(100)},
+						}},
+					}},
+				}, {
+					Port:         443,
+					VirtualHosts: []*vhostNode{secure},
+				}},
+			},
+		},
+		"namespace": {
+			query: "namespace=teama",
+			want: &tree{
+				Listeners: []*listenerNode{{
+					Port: 80,
+					VirtualHosts: []*vhostNode{{
+						Name:    "kuard.example.com",
+						Sources: []sourceNode{root},
+						Routes:  []*routeNode{apiRoute},
+					}},
+				}, {
+					Port: 443,
+					VirtualHosts: []*vhostNode{{
+						Name:            "kuard.example.com",
+						Secret:          secure.Secret,
+						MinProtoVersion: "TLS_AUTO",
+						Sources:         []sourceNode{root},
+						Routes:          []*routeNode{apiRoute},
+					}},
+				}},
+			},
+		},
+		"no match": {
+			query: "vhost=other.example.com&namespace=teama",
+			want: &tree{
+				Listeners: []*listenerNode{},
+			},
+		},
+		"comma separated": {
+			query: "vhost=nope.example.com,other.example.com",
+			want: &tree{
+				Listeners: []*listenerNode{{
+					Port: 80,
+					VirtualHosts: []*vhostNode{{
+						Name: "other.example.com",
+						Sources: []sourceNode{
+							{Kind: "IngressRoute", Namespace: "default", Name: "other"},
+						},
+						Routes: []*routeNode{{
+							Prefix: "/",
+							Sources: []sourceNode{
+								{Kind: "IngressRoute", Namespace: "default", Name: "other"},
+							},
+							Clusters: []*clusterNode{kuard(0)},
+						}},
+					}},
+				}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := buildTree(d, newTreeFilter(q))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
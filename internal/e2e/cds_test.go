@@ -24,6 +24,7 @@ import (
 	endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	"github.com/gogo/protobuf/types"
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/internal/contour"
 	"github.com/heptio/contour/internal/envoy"
 	"google.golang.org/grpc"
 	v1 "k8s.io/api/core/v1"
@@ -64,12 +65,10 @@ func TestClusterLongServiceName(t *testing.T) {
 
 	// check that it's been translated correctly.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, cluster("default/kbujbkuh-c83ceb/8080/da39a3ee5e", "default/kbujbkuhdod66gjdmwmijz8xzgsx1nkfbrloezdjiulquzk4x3p0nnvpzi8r", "default_kbujbkuhdod66gjdmwmijz8xzgsx1nkfbrloezdjiulquzk4x3p0nnvpzi8r_8080")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "2",
 	}, streamCDS(t, cc))
 }
 
@@ -126,12 +125,10 @@ func TestClusterAddUpdateDelete(t *testing.T) {
 	rh.OnAdd(s1)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, cluster("default/kuard/80/da39a3ee5e", "default/kuard", "default_kuard_80")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "3",
 	}, streamCDS(t, cc))
 
 	// s2 is the same as s2, but the service port has a name
@@ -147,12 +144,10 @@ func TestClusterAddUpdateDelete(t *testing.T) {
 
 	// check that we get two CDS records because the port is now named.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "4",
 		Resources: []types.Any{
 			any(t, cluster("default/kuard/80/da39a3ee5e", "default/kuard/http", "default_kuard_80")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "4",
 	}, streamCDS(t, cc))
 
 	// s3 is like s2, but has a second named port. The k8s spec
@@ -178,13 +173,11 @@ func TestClusterAddUpdateDelete(t *testing.T) {
 	// check that we get four CDS records. Order is important
 	// because the CDS cache is sorted.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "5",
 		Resources: []types.Any{
 			any(t, cluster("default/kuard/443/da39a3ee5e", "default/kuard/https", "default_kuard_443")),
 			any(t, cluster("default/kuard/80/da39a3ee5e", "default/kuard/http", "default_kuard_80")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "5",
 	}, streamCDS(t, cc))
 
 	// s4 is s3 with the http port removed.
@@ -203,12 +196,10 @@ func TestClusterAddUpdateDelete(t *testing.T) {
 	// check that we get two CDS records only, and that the 80 and http
 	// records have been removed even though the service object remains.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "6",
 		Resources: []types.Any{
 			any(t, cluster("default/kuard/443/da39a3ee5e", "default/kuard/https", "default_kuard_443")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "6",
 	}, streamCDS(t, cc))
 }
 
@@ -263,13 +254,11 @@ func TestClusterRenameUpdateDelete(t *testing.T) {
 
 	rh.OnAdd(s1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, cluster("default/kuard/443/da39a3ee5e", "default/kuard/https", "default_kuard_443")),
 			any(t, cluster("default/kuard/80/da39a3ee5e", "default/kuard/http", "default_kuard_80")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "2",
 	}, streamCDS(t, cc))
 
 	// s2 removes the name on port 80, moves it to port 443 and deletes the https port
@@ -283,33 +272,27 @@ func TestClusterRenameUpdateDelete(t *testing.T) {
 
 	rh.OnUpdate(s1, s2)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, cluster("default/kuard/443/da39a3ee5e", "default/kuard", "default_kuard_443")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "3",
 	}, streamCDS(t, cc))
 
 	// now replace s2 with s1 to check it works in the other direction.
 	rh.OnUpdate(s2, s1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "4",
 		Resources: []types.Any{
 			any(t, cluster("default/kuard/443/da39a3ee5e", "default/kuard/https", "default_kuard_443")),
 			any(t, cluster("default/kuard/80/da39a3ee5e", "default/kuard/http", "default_kuard_80")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "4",
 	}, streamCDS(t, cc))
 
 	// cleanup and check
 	rh.OnDelete(s1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "5",
-		Resources:   []types.Any{},
-		TypeUrl:     clusterType,
-		Nonce:       "5",
+		Resources: []types.Any{},
+		TypeUrl:   clusterType,
 	}, streamCDS(t, cc))
 }
 
@@ -342,12 +325,10 @@ func TestIssue243(t *testing.T) {
 		)
 		rh.OnAdd(s1)
 		assertEqual(t, &v2.DiscoveryResponse{
-			VersionInfo: "2",
 			Resources: []types.Any{
 				any(t, cluster("default/kuard/80/da39a3ee5e", "default/kuard", "default_kuard_80")),
 			},
 			TypeUrl: clusterType,
-			Nonce:   "2",
 		}, streamCDS(t, cc))
 	})
 }
@@ -385,12 +366,10 @@ func TestIssue247(t *testing.T) {
 	)
 	rh.OnAdd(s1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, cluster("default/kuard/80/da39a3ee5e", "default/kuard", "default_kuard_80")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "2",
 	}, streamCDS(t, cc))
 }
 func TestCDSResourceFiltering(t *testing.T) {
@@ -444,31 +423,25 @@ func TestCDSResourceFiltering(t *testing.T) {
 	)
 	rh.OnAdd(s2)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			// note, resources are sorted by Cluster.Name
 			any(t, cluster("default/httpbin/8080/da39a3ee5e", "default/httpbin", "default_httpbin_8080")),
 			any(t, cluster("default/kuard/80/da39a3ee5e", "default/kuard", "default_kuard_80")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "3",
 	}, streamCDS(t, cc))
 
 	// assert we can filter on one resource
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, cluster("default/kuard/80/da39a3ee5e", "default/kuard", "default_kuard_80")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "3",
 	}, streamCDS(t, cc, "default/kuard/80/da39a3ee5e"))
 
 	// assert a non matching filter returns a response with no entries.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
-		TypeUrl:     clusterType,
-		Nonce:       "3",
+		TypeUrl: clusterType,
 	}, streamCDS(t, cc, "default/httpbin/9000"))
 }
 
@@ -509,7 +482,6 @@ func TestClusterCircuitbreakerAnnotations(t *testing.T) {
 
 	// check that it's been translated correctly.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, &v2.Cluster{
 				Name:                 "default/kuard/8080/da39a3ee5e",
@@ -533,7 +505,6 @@ func TestClusterCircuitbreakerAnnotations(t *testing.T) {
 			}),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "2",
 	}, streamCDS(t, cc))
 
 	// update s1 with slightly weird values
@@ -555,7 +526,6 @@ func TestClusterCircuitbreakerAnnotations(t *testing.T) {
 
 	// check that it's been translated correctly.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, &v2.Cluster{
 				Name:                 "default/kuard/8080/da39a3ee5e",
@@ -576,7 +546,6 @@ func TestClusterCircuitbreakerAnnotations(t *testing.T) {
 			}),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "3",
 	}, streamCDS(t, cc))
 }
 
@@ -626,12 +595,10 @@ func TestClusterPerServiceParameters(t *testing.T) {
 	})
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, cluster("default/kuard/80/da39a3ee5e", "default/kuard", "default_kuard_80")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "2",
 	}, streamCDS(t, cc))
 }
 
@@ -681,7 +648,6 @@ func TestClusterLoadBalancerStrategyPerRoute(t *testing.T) {
 	})
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, &v2.Cluster{
 				Name:                 "default/kuard/80/58d888c08a",
@@ -709,7 +675,6 @@ func TestClusterLoadBalancerStrategyPerRoute(t *testing.T) {
 			}),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "2",
 	}, streamCDS(t, cc))
 }
 
@@ -753,12 +718,10 @@ func TestClusterWithHealthChecks(t *testing.T) {
 	})
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, clusterWithHealthCheck("default/kuard/80/bc862a33ca", "default/kuard", "default_kuard_80", "/healthz", true)),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "2",
 	}, streamCDS(t, cc))
 }
 
@@ -804,12 +767,10 @@ func TestClusterServiceTLSBackend(t *testing.T) {
 	want := tlscluster("default/kuard/443/da39a3ee5e", "default/kuard/securebackend", "default_kuard_443", nil, "")
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, want),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "2",
 	}, streamCDS(t, cc))
 }
 
@@ -867,7 +828,6 @@ func TestClusterServiceTLSBackendCAValidation(t *testing.T) {
 	rh.OnAdd(ir1)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, tlscluster(
 				"default/kuard/443/da39a3ee5e",
@@ -877,7 +837,6 @@ func TestClusterServiceTLSBackendCAValidation(t *testing.T) {
 				"")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "3",
 	}, streamCDS(t, cc))
 
 	ir2 := &ingressroutev1.IngressRoute{
@@ -904,7 +863,6 @@ func TestClusterServiceTLSBackendCAValidation(t *testing.T) {
 	rh.OnUpdate(ir1, ir2)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "4",
 		Resources: []types.Any{
 			any(t, tlscluster(
 				"default/kuard/443/98c0f31c72",
@@ -914,7 +872,6 @@ func TestClusterServiceTLSBackendCAValidation(t *testing.T) {
 				"subjname")),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "4",
 	}, streamCDS(t, cc))
 }
 
@@ -946,15 +903,113 @@ func TestExternalNameService(t *testing.T) {
 	rh.OnAdd(s1)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, externalnamecluster("default/kuard/80/da39a3ee5e", "default/kuard/", "default_kuard_80", "foo.io", 80)),
 		},
 		TypeUrl: clusterType,
-		Nonce:   "2",
 	}, streamCDS(t, cc))
 }
 
+func TestExternalNameServiceDNSLookupFamily(t *testing.T) {
+	rh, cc, done := setup(t, func(reh *contour.ResourceEventHandler) {
+		reh.Notifier.(*contour.CacheHandler).DNSLookupFamily = "v6"
+	})
+	defer done()
+
+	i1 := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1beta1.IngressSpec{
+			Backend: &v1beta1.IngressBackend{
+				ServiceName: "kuard",
+				ServicePort: intstr.FromInt(80),
+			},
+		},
+	}
+	rh.OnAdd(i1)
+
+	// s1 is an ExternalName service which resolves to IPv6 addresses.
+	s1 := externalnameservice("default", "kuard", "foo.io", v1.ServicePort{
+		Protocol:   "TCP",
+		Port:       80,
+		TargetPort: intstr.FromInt(8080),
+	})
+	rh.OnAdd(s1)
+
+	c := externalnamecluster("default/kuard/80/da39a3ee5e", "default/kuard/", "default_kuard_80", "foo.io", 80)
+	c.DnsLookupFamily = v2.Cluster_V6_ONLY
+	assertEqual(t, &v2.DiscoveryResponse{
+		Resources: []types.Any{
+			any(t, c),
+		},
+		TypeUrl: clusterType,
+	}, streamCDS(t, cc))
+}
+
+// Contours with the same configuration send responses with the same
+// version, regardless of the order or number of events which led to it.
+func TestVersionInfoIsContentDerived(t *testing.T) {
+	rh1, cc1, done1 := setup(t)
+	defer done1()
+	rh2, cc2, done2 := setup(t)
+	defer done2()
+
+	i1 := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1beta1.IngressSpec{
+			Backend: &v1beta1.IngressBackend{
+				ServiceName: "kuard",
+				ServicePort: intstr.FromInt(80),
+			},
+		},
+	}
+	s1 := service("default", "kuard", v1.ServicePort{
+		Protocol:   "TCP",
+		Port:       80,
+		TargetPort: intstr.FromInt(8080),
+	})
+	s2 := service("default", "httpbin", v1.ServicePort{
+		Protocol:   "TCP",
+		Port:       80,
+		TargetPort: intstr.FromInt(8080),
+	})
+
+	rh1.OnAdd(i1)
+	rh1.OnAdd(s1)
+
+	rh2.OnAdd(s2)
+	rh2.OnAdd(s1)
+	rh2.OnDelete(s2)
+	rh2.OnAdd(i1)
+
+	want := streamCDS(t, cc1).VersionInfo
+	if got := streamCDS(t, cc2).VersionInfo; want != got {
+		t.Fatalf("expected equal clusters to have equal versions, got %q and %q", want, got)
+	}
+
+	// s3 is s1 with a named port.
+	s3 := service("default", "kuard", v1.ServicePort{
+		Name:       "http",
+		Protocol:   "TCP",
+		Port:       80,
+		TargetPort: intstr.FromInt(8080),
+	})
+	rh2.OnUpdate(s1, s3)
+	if got := streamCDS(t, cc2).VersionInfo; want == got {
+		t.Fatalf("expected different clusters to have different versions, got %q", got)
+	}
+
+	rh2.OnUpdate(s3, s1)
+	if got := streamCDS(t, cc2).VersionInfo; want != got {
+		t.Fatalf("expected restored clusters to have the original version %q, got %q", want, got)
+	}
+}
+
 func serviceWithAnnotations(ns, name string, annotations map[string]string, ports ...v1.ServicePort) *v1.Service {
 	return &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
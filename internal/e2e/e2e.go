@@ -15,6 +15,8 @@
 package e2e
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"net"
 	"testing"
 
@@ -97,7 +99,7 @@ func setup(t *testing.T, opts ...func(*contour.ResourceEventHandler)) (cache.Res
 		ch.RouteCache.TypeURL():    &ch.RouteCache,
 		ch.ListenerCache.TypeURL(): &ch.ListenerCache,
 		et.TypeURL():               et,
-	})
+	}, nil, nil, nil)
 
 	done := make(chan error, 1)
 	go func() {
@@ -186,6 +188,19 @@ func stream(t *testing.T, st grpcStream, req *v2.DiscoveryRequest) *v2.Discovery
 
 func assertEqual(t *testing.T, want, got *v2.DiscoveryResponse) {
 	t.Helper()
+	// the version of a response is a hash of its resources, so unless
+	// the test names one, the version is computed from the resources
+	// expected. The nonce is particular to the stream, so only its
+	// presence is checked unless the test names one.
+	if want.VersionInfo == "" {
+		want.VersionInfo = version(want.Resources)
+	}
+	if got.Nonce == "" {
+		t.Fatalf("expected a nonce, got none")
+	}
+	if want.Nonce == "" {
+		want.Nonce = got.Nonce
+	}
 	m := proto.TextMarshaler{Compact: true, ExpandAny: true}
 	a := m.Text(want)
 	b := m.Text(got)
@@ -198,4 +213,17 @@ func assertEqual(t *testing.T, want, got *v2.DiscoveryResponse) {
 	}
 }
 
+// version returns the version_info of a response holding resources,
+// the first 16 hex digits of the sha256 of the length prefixed text
+// form of each resource.
+func version(resources []types.Any) string {
+	m := proto.TextMarshaler{Compact: true, ExpandAny: true}
+	h := sha256.New()
+	for i := range resources {
+		text := m.Text(&resources[i])
+		fmt.Fprintf(h, "%d:%s", len(text), text)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
 func u32(val int) *types.UInt32Value { return &types.UInt32Value{Value: uint32(val)} }
@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	"github.com/gogo/protobuf/types"
 	"github.com/heptio/contour/internal/envoy"
@@ -57,7 +58,6 @@ func TestAddRemoveEndpoints(t *testing.T) {
 
 	// check that it's been translated correctly.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, clusterloadassignment(
 				"super-long-namespace-name-oh-boy/what-a-descriptive-service-name-you-must-be-so-proud/http",
@@ -71,17 +71,79 @@ func TestAddRemoveEndpoints(t *testing.T) {
 			)),
 		},
 		TypeUrl: endpointType,
-		Nonce:   "1",
 	}, streamEDS(t, cc))
 
 	// remove e1 and check that the EDS cache is now empty.
 	rh.OnDelete(e1)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
-		Resources:   []types.Any{},
-		TypeUrl:     endpointType,
-		Nonce:       "2",
+		Resources: []types.Any{},
+		TypeUrl:   endpointType,
+	}, streamEDS(t, cc))
+}
+
+// test that IPv6 and dual-stack endpoints are translated correctly.
+func TestAddIPv6Endpoints(t *testing.T) {
+	rh, cc, done := setup(t)
+	defer done()
+
+	// e1 has both IPv4 and IPv6 addresses, as reported by
+	// a dual-stack cluster.
+	e1 := endpoints(
+		"default",
+		"kuard",
+		v1.EndpointSubset{
+			Addresses: addresses(
+				"172.16.0.1",
+				"fd00:10:244::1",
+				"fd00:10:244::2",
+			),
+			Ports: []v1.EndpointPort{{
+				Port: 8080,
+			}},
+		},
+	)
+
+	rh.OnAdd(e1)
+
+	assertEqual(t, &v2.DiscoveryResponse{
+		Resources: []types.Any{
+			any(t, clusterloadassignment(
+				"default/kuard",
+				lbendpoint("172.16.0.1", 8080),
+				lbendpoint("fd00:10:244::1", 8080),
+				lbendpoint("fd00:10:244::2", 8080),
+			)),
+		},
+		TypeUrl: endpointType,
+	}, streamEDS(t, cc))
+
+	// e2 is e1 on an IPv6 only cluster.
+	e2 := endpoints(
+		"default",
+		"kuard",
+		v1.EndpointSubset{
+			Addresses: addresses(
+				"fd00:10:244::1",
+				"fd00:10:244::2",
+			),
+			Ports: []v1.EndpointPort{{
+				Port: 8080,
+			}},
+		},
+	)
+
+	rh.OnUpdate(e1, e2)
+
+	assertEqual(t, &v2.DiscoveryResponse{
+		Resources: []types.Any{
+			any(t, clusterloadassignment(
+				"default/kuard",
+				lbendpoint("fd00:10:244::1", 8080),
+				lbendpoint("fd00:10:244::2", 8080),
+			)),
+		},
+		TypeUrl: endpointType,
 	}, streamEDS(t, cc))
 }
 
@@ -139,7 +201,6 @@ func TestAddEndpointComplicated(t *testing.T) {
 	rh.OnAdd(e1)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, clusterloadassignment(
 				"default/kuard/admin",
@@ -153,7 +214,6 @@ func TestAddEndpointComplicated(t *testing.T) {
 			)),
 		},
 		TypeUrl: endpointType,
-		Nonce:   "1",
 	}, streamEDS(t, cc))
 }
 
@@ -199,7 +259,6 @@ func TestEndpointFilter(t *testing.T) {
 	rh.OnAdd(e1)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, clusterloadassignment(
 				"default/kuard/foo",
@@ -208,19 +267,15 @@ func TestEndpointFilter(t *testing.T) {
 			)),
 		},
 		TypeUrl: endpointType,
-		Nonce:   "1",
 	}, streamEDS(t, cc, "default/kuard/foo"))
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
-		TypeUrl:     endpointType,
+		TypeUrl: endpointType,
 		Resources: []types.Any{
 			any(t, clusterloadassignment(
 				"default/kuard/bar",
 			)),
 		},
-
-		Nonce: "1",
 	}, streamEDS(t, cc, "default/kuard/bar"))
 
 }
@@ -241,12 +296,10 @@ func TestIssue602(t *testing.T) {
 
 	// Assert endpoint was added
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, clusterloadassignment("default/simple", envoy.LBEndpoint("192.168.183.24", 8080))),
 		},
 		TypeUrl: endpointType,
-		Nonce:   "1",
 	}, streamEDS(t, cc))
 
 	// e2 is the same as e1, but without endpoint subsets
@@ -254,10 +307,8 @@ func TestIssue602(t *testing.T) {
 	rh.OnUpdate(e1, e2)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
-		Resources:   []types.Any{},
-		TypeUrl:     endpointType,
-		Nonce:       "2",
+		Resources: []types.Any{},
+		TypeUrl:   endpointType,
 	}, streamEDS(t, cc))
 }
 
@@ -290,6 +341,28 @@ func addresses(ips ...string) []v1.EndpointAddress {
 	return addrs
 }
 
+// lbendpoint returns an LbEndpoint for addr and port without using
+// envoy.LBEndpoint so that address handling can be verified.
+func lbendpoint(addr string, port int) endpoint.LbEndpoint {
+	return endpoint.LbEndpoint{
+		HostIdentifier: &endpoint.LbEndpoint_Endpoint{
+			Endpoint: &endpoint.Endpoint{
+				Address: &core.Address{
+					Address: &core.Address_SocketAddress{
+						SocketAddress: &core.SocketAddress{
+							Protocol: core.TCP,
+							Address:  addr,
+							PortSpecifier: &core.SocketAddress_PortValue{
+								PortValue: uint32(port),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func clusterloadassignment(name string, lbendpoints ...endpoint.LbEndpoint) *v2.ClusterLoadAssignment {
 	if len(lbendpoints) == 0 {
 		return &v2.ClusterLoadAssignment{ClusterName: name}
@@ -0,0 +1,122 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/gogo/protobuf/types"
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/internal/envoy"
+	"google.golang.org/grpc"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Assert that a root IngressRoute tagged for a fleet is only sent
+// to the Envoys of that fleet.
+func TestRDSRestrictedByFleet(t *testing.T) {
+	rh, cc, done := setup(t)
+	defer done()
+
+	rh.OnAdd(service("default", "kuard", v1.ServicePort{
+		Protocol:   "TCP",
+		Port:       8080,
+		TargetPort: intstr.FromInt(8080),
+	}))
+
+	ingressroute := func(name string, annotations map[string]string) *ingressroutev1.IngressRoute {
+		return &ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: name + ".example.com",
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+	rh.OnAdd(ingressroute("internal", map[string]string{
+		"contour.heptio.com/fleet": "internal",
+	}))
+	rh.OnAdd(ingressroute("public", nil))
+
+	vhost := func(name string) route.VirtualHost {
+		return route.VirtualHost{
+			Name:    name,
+			Domains: domains(name),
+			Routes: []route.Route{{
+				Match:               envoy.PrefixMatch("/"),
+				Action:              routecluster("default/kuard/8080/da39a3ee5e"),
+				RequestHeadersToAdd: envoy.RouteHeaders(),
+			}},
+		}
+	}
+	response := func(vhosts ...route.VirtualHost) *v2.DiscoveryResponse {
+		return &v2.DiscoveryResponse{
+			Resources: []types.Any{
+				any(t, &v2.RouteConfiguration{
+					Name:         "ingress_http",
+					VirtualHosts: vhosts,
+				}),
+				any(t, &v2.RouteConfiguration{
+					Name: "ingress_https",
+				}),
+			},
+			TypeUrl: routeType,
+		}
+	}
+
+	// an Envoy in the internal fleet, by its node metadata.
+	assertEqual(t, response(vhost("internal.example.com"), vhost("public.example.com")), streamRDSNode(t, cc, &core.Node{
+		Cluster: "edge",
+		Metadata: &types.Struct{
+			Fields: map[string]*types.Value{
+				"fleet": {Kind: &types.Value_StringValue{StringValue: "internal"}},
+			},
+		},
+	}))
+
+	// an Envoy in the edge fleet, by its cluster.
+	assertEqual(t, response(vhost("public.example.com")), streamRDSNode(t, cc, &core.Node{
+		Cluster: "edge",
+	}))
+}
+
+func streamRDSNode(t *testing.T, cc *grpc.ClientConn, node *core.Node, rn ...string) *v2.DiscoveryResponse {
+	t.Helper()
+	rds := v2.NewRouteDiscoveryServiceClient(cc)
+	st, err := rds.StreamRoutes(context.TODO())
+	check(t, err)
+	return stream(t, st, &v2.DiscoveryRequest{
+		Node:          node,
+		TypeUrl:       routeType,
+		ResourceNames: rn,
+	})
+}
@@ -22,6 +22,7 @@ import (
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	envoy_config_v2_tcpproxy "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/util"
@@ -46,12 +47,10 @@ func TestNonTLSListener(t *testing.T) {
 	// assert that without any ingress objects registered
 	// there are no active listeners
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "0",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "0",
 	}, streamLDS(t, cc))
 
 	// i1 is a simple ingress, no hostname, no tls.
@@ -68,7 +67,6 @@ func TestNonTLSListener(t *testing.T) {
 	// add it and assert that we now have a ingress_http listener
 	rh.OnAdd(i1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:         "ingress_http",
@@ -78,7 +76,6 @@ func TestNonTLSListener(t *testing.T) {
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "1",
 	}, streamLDS(t, cc))
 
 	// i2 is the same as i1 but has the kubernetes.io/ingress.allow-http: "false" annotation
@@ -98,12 +95,10 @@ func TestNonTLSListener(t *testing.T) {
 	// update i1 to i2 and verify that ingress_http has gone.
 	rh.OnUpdate(i1, i2)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
 	}, streamLDS(t, cc))
 
 	// i3 is similar to i2, but uses the ingress.kubernetes.io/force-ssl-redirect: "true" annotation
@@ -124,7 +119,6 @@ func TestNonTLSListener(t *testing.T) {
 	// update i2 to i3 and check that ingress_http has returned
 	rh.OnUpdate(i2, i3)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:         "ingress_http",
@@ -134,7 +128,6 @@ func TestNonTLSListener(t *testing.T) {
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "3",
 	}, streamLDS(t, cc))
 }
 
@@ -174,18 +167,15 @@ func TestTLSListener(t *testing.T) {
 
 	// assert that there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "1",
 	}, streamLDS(t, cc))
 
 	// add ingress and assert the existence of ingress_http and ingres_https
 	rh.OnAdd(i1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:         "ingress_http",
@@ -203,7 +193,6 @@ func TestTLSListener(t *testing.T) {
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
 	}, streamLDS(t, cc))
 
 	// i2 is the same as i1 but has the kubernetes.io/ingress.allow-http: "false" annotation
@@ -227,7 +216,6 @@ func TestTLSListener(t *testing.T) {
 	// update i1 to i2 and verify that ingress_http has gone.
 	rh.OnUpdate(i1, i2)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:    "ingress_https",
@@ -240,18 +228,15 @@ func TestTLSListener(t *testing.T) {
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "3",
 	}, streamLDS(t, cc))
 
 	// delete secret and assert that ingress_https is removed
 	rh.OnDelete(s1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "4",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "4",
 	}, streamLDS(t, cc))
 }
 
@@ -324,12 +309,10 @@ func TestIngressRouteTLSListener(t *testing.T) {
 
 	// assert that there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "1",
 	}, streamLDS(t, cc))
 
 	l1 := &v2.Listener{
@@ -346,7 +329,6 @@ func TestIngressRouteTLSListener(t *testing.T) {
 	// add ingress and assert the existence of ingress_http and ingres_https
 	rh.OnAdd(i1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:         "ingress_http",
@@ -357,13 +339,11 @@ func TestIngressRouteTLSListener(t *testing.T) {
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
 	}, streamLDS(t, cc))
 
 	// delete secret and assert that ingress_https is removed
 	rh.OnDelete(s1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:         "ingress_http",
@@ -373,7 +353,6 @@ func TestIngressRouteTLSListener(t *testing.T) {
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "3",
 	}, streamLDS(t, cc))
 
 	rh.OnDelete(i1)
@@ -393,7 +372,6 @@ func TestIngressRouteTLSListener(t *testing.T) {
 	// add ingress and assert the existence of ingress_http and ingres_https
 	rh.OnAdd(i2)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "6",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:         "ingress_http",
@@ -404,7 +382,6 @@ func TestIngressRouteTLSListener(t *testing.T) {
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "6",
 	}, streamLDS(t, cc))
 }
 
@@ -445,7 +422,6 @@ func TestLDSFilter(t *testing.T) {
 	// add ingress and fetch ingress_https
 	rh.OnAdd(i1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:    "ingress_https",
@@ -457,12 +433,10 @@ func TestLDSFilter(t *testing.T) {
 			}),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
 	}, streamLDS(t, cc, "ingress_https"))
 
 	// fetch ingress_http
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:         "ingress_http",
@@ -471,14 +445,11 @@ func TestLDSFilter(t *testing.T) {
 			}),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
 	}, streamLDS(t, cc, "ingress_http"))
 
 	// fetch something non existent.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
-		TypeUrl:     listenerType,
-		Nonce:       "2",
+		TypeUrl: listenerType,
 	}, streamLDS(t, cc, "HTTP"))
 }
 
@@ -488,9 +459,7 @@ func TestLDSStreamEmpty(t *testing.T) {
 
 	// assert that streaming LDS with no ingresses does not stall.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "0",
-		TypeUrl:     listenerType,
-		Nonce:       "0",
+		TypeUrl: listenerType,
 	}, streamLDS(t, cc, "HTTP"))
 }
 
@@ -531,7 +500,6 @@ func TestLDSTLSMinimumProtocolVersion(t *testing.T) {
 	// add ingress and fetch ingress_https
 	rh.OnAdd(i1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:    "ingress_https",
@@ -543,7 +511,6 @@ func TestLDSTLSMinimumProtocolVersion(t *testing.T) {
 			}),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "3",
 	}, streamLDS(t, cc, "ingress_https"))
 
 	i2 := &v1beta1.Ingress{
@@ -578,12 +545,10 @@ func TestLDSTLSMinimumProtocolVersion(t *testing.T) {
 	l1.FilterChains[0].TlsContext.CommonTlsContext.TlsParams.TlsMinimumProtocolVersion = auth.TlsParameters_TLSv1_3
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "4",
 		Resources: []types.Any{
 			any(t, l1),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "4",
 	}, streamLDS(t, cc, "ingress_https"))
 }
 
@@ -596,12 +561,10 @@ func TestLDSIngressHTTPUseProxyProtocol(t *testing.T) {
 	// assert that without any ingress objects registered
 	// there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "0",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "0",
 	}, streamLDS(t, cc))
 
 	// i1 is a simple ingress, no hostname, no tls.
@@ -619,7 +582,6 @@ func TestLDSIngressHTTPUseProxyProtocol(t *testing.T) {
 	// the proxy protocol (the true param to filterchain)
 	rh.OnAdd(i1)
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:    "ingress_http",
@@ -632,7 +594,6 @@ func TestLDSIngressHTTPUseProxyProtocol(t *testing.T) {
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "1",
 	}, streamLDS(t, cc))
 }
 
@@ -674,12 +635,10 @@ func TestLDSIngressHTTPSUseProxyProtocol(t *testing.T) {
 
 	// assert that there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "1",
 	}, streamLDS(t, cc))
 
 	// add ingress and assert the existence of ingress_http and ingres_https and both
@@ -696,7 +655,6 @@ func TestLDSIngressHTTPSUseProxyProtocol(t *testing.T) {
 		FilterChains: filterchaintls("kuard.example.com", s1, envoy.HTTPConnectionManager("ingress_https", "/dev/stdout"), "h2", "http/1.1"),
 	}
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:    "ingress_http",
@@ -710,7 +668,6 @@ func TestLDSIngressHTTPSUseProxyProtocol(t *testing.T) {
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
 	}, streamLDS(t, cc))
 }
 
@@ -755,12 +712,10 @@ func TestLDSCustomAddressAndPort(t *testing.T) {
 
 	// assert that there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "1",
 	}, streamLDS(t, cc))
 
 	// add ingress and assert the existence of ingress_http and ingres_https and both
@@ -781,14 +736,96 @@ func TestLDSCustomAddressAndPort(t *testing.T) {
 		FilterChains: filterchaintls("kuard.example.com", s1, envoy.HTTPConnectionManager("ingress_https", "/dev/stdout"), "h2", "http/1.1"),
 	}
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, ingress_http),
 			any(t, ingress_https),
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
+	}, streamLDS(t, cc))
+}
+
+func TestLDSIPv6Address(t *testing.T) {
+	rh, cc, done := setup(t, func(reh *contour.ResourceEventHandler) {
+		reh.Notifier.(*contour.CacheHandler).HTTPAddress = "::"
+		reh.Notifier.(*contour.CacheHandler).HTTPSAddress = "fd00::200"
+	})
+	defer done()
+
+	// s1 is a tls secret
+	s1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			v1.TLSCertKey:       []byte("certificate"),
+			v1.TLSPrivateKeyKey: []byte("key"),
+		},
+	}
+
+	// i1 is a tls ingress
+	i1 := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: "default",
+		},
+		Spec: v1beta1.IngressSpec{
+			Backend: backend("backend", intstr.FromInt(80)),
+			TLS: []v1beta1.IngressTLS{{
+				Hosts:      []string{"kuard.example.com"},
+				SecretName: "secret",
+			}},
+		},
+	}
+
+	rh.OnAdd(s1)
+	rh.OnAdd(i1)
+
+	// assert that ingress_http listens on all IPv6 addresses
+	// and accepts IPv4 connections, and ingress_https listens
+	// on a single IPv6 address.
+	ingress_http := &v2.Listener{
+		Name: "ingress_http",
+		Address: core.Address{
+			Address: &core.Address_SocketAddress{
+				SocketAddress: &core.SocketAddress{
+					Protocol: core.TCP,
+					Address:  "::",
+					PortSpecifier: &core.SocketAddress_PortValue{
+						PortValue: 8080,
+					},
+					Ipv4Compat: true,
+				},
+			},
+		},
+		FilterChains: filterchain(envoy.HTTPConnectionManager("ingress_http", "/dev/stdout")),
+	}
+	ingress_https := &v2.Listener{
+		Name: "ingress_https",
+		Address: core.Address{
+			Address: &core.Address_SocketAddress{
+				SocketAddress: &core.SocketAddress{
+					Protocol: core.TCP,
+					Address:  "fd00::200",
+					PortSpecifier: &core.SocketAddress_PortValue{
+						PortValue: 8443,
+					},
+				},
+			},
+		},
+		ListenerFilters: []listener.ListenerFilter{
+			envoy.TLSInspector(),
+		},
+		FilterChains: filterchaintls("kuard.example.com", s1, envoy.HTTPConnectionManager("ingress_https", "/dev/stdout"), "h2", "http/1.1"),
+	}
+	assertEqual(t, &v2.DiscoveryResponse{
+		Resources: []types.Any{
+			any(t, ingress_http),
+			any(t, ingress_https),
+			any(t, staticListener()),
+		},
+		TypeUrl: listenerType,
 	}, streamLDS(t, cc))
 }
 
@@ -831,12 +868,10 @@ func TestLDSCustomAccessLogPaths(t *testing.T) {
 
 	// assert that there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "1",
 	}, streamLDS(t, cc))
 
 	rh.OnAdd(i1)
@@ -855,14 +890,12 @@ func TestLDSCustomAccessLogPaths(t *testing.T) {
 		FilterChains: filterchaintls("kuard.example.com", s1, envoy.HTTPConnectionManager("ingress_https", "/tmp/https_access.log"), "h2", "http/1.1"),
 	}
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, ingress_http),
 			any(t, ingress_https),
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
 	}, streamLDS(t, cc))
 }
 
@@ -877,12 +910,10 @@ func TestLDSIngressRouteInsideRootNamespaces(t *testing.T) {
 
 	// assert that there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "0",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "0",
 	}, streamLDS(t, cc))
 
 	// ir1 is an ingressroute that is in the root namespace
@@ -908,7 +939,6 @@ func TestLDSIngressRouteInsideRootNamespaces(t *testing.T) {
 
 	// assert there is an active listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, &v2.Listener{
 				Name:         "ingress_http",
@@ -918,7 +948,6 @@ func TestLDSIngressRouteInsideRootNamespaces(t *testing.T) {
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "1",
 	}, streamLDS(t, cc))
 }
 
@@ -933,12 +962,10 @@ func TestLDSIngressRouteOutsideRootNamespaces(t *testing.T) {
 
 	// assert that there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "0",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "0",
 	}, streamLDS(t, cc))
 
 	// ir1 is an ingressroute that is not in the root namespaces
@@ -964,12 +991,10 @@ func TestLDSIngressRouteOutsideRootNamespaces(t *testing.T) {
 
 	// assert that there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "1",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "1",
 	}, streamLDS(t, cc))
 }
 
@@ -984,12 +1009,10 @@ func TestIngressRouteHTTPS(t *testing.T) {
 
 	// assert that there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "0",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "0",
 	}, streamLDS(t, cc))
 
 	// s1 is a tls secret
@@ -1048,14 +1071,12 @@ func TestIngressRouteHTTPS(t *testing.T) {
 		FilterChains: filterchaintls("example.com", s1, envoy.HTTPConnectionManager("ingress_https", "/dev/stdout"), "h2", "http/1.1"),
 	}
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, ingressHTTP),
 			any(t, ingressHTTPS),
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
 	}, streamLDS(t, cc))
 }
 
@@ -1118,13 +1139,11 @@ func TestLDSIngressRouteTCPProxyTLSPassthrough(t *testing.T) {
 	}
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, ingressHTTPS),
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
 	}, streamLDS(t, cc))
 }
 
@@ -1190,13 +1209,60 @@ func TestLDSIngressRouteTCPForward(t *testing.T) {
 	}
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, ingressHTTPS),
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "3",
+	}, streamLDS(t, cc))
+}
+
+// Assert that when spec.virtualhost.port is present a plain TCP
+// listener is created on that port for the tcpproxy.
+func TestLDSIngressRouteTCPListener(t *testing.T) {
+	rh, cc, done := setup(t)
+	defer done()
+
+	i1 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: "default",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &ingressroutev1.VirtualHost{
+				Fqdn: "postgres.example.com",
+				Port: 5432,
+			},
+			TCPProxy: &ingressroutev1.TCPProxy{
+				Services: []ingressroutev1.Service{{
+					Name: "postgres",
+					Port: 5432,
+				}},
+			},
+		},
+	}
+	svc := service("default", "postgres", v1.ServicePort{
+		Protocol:   "TCP",
+		Port:       5432,
+		TargetPort: intstr.FromInt(5432),
+	})
+	rh.OnAdd(svc)
+	rh.OnAdd(i1)
+
+	assertEqual(t, &v2.DiscoveryResponse{
+		Resources: []types.Any{
+			any(t, &v2.Listener{
+				Name:    "ingress_tcp_5432",
+				Address: *envoy.SocketAddress("0.0.0.0", 5432),
+				FilterChains: []listener.FilterChain{{
+					Filters: []listener.Filter{
+						tcpproxy(t, "ingress_tcp_5432", "default/postgres/5432/da39a3ee5e"),
+					},
+				}},
+			}),
+			any(t, staticListener()),
+		},
+		TypeUrl: listenerType,
 	}, streamLDS(t, cc))
 }
 
@@ -1207,12 +1273,10 @@ func TestIngressRouteTLSCertificateDelegation(t *testing.T) {
 
 	// assert that there is only a static listener
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "0",
 		Resources: []types.Any{
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "0",
 	}, streamLDS(t, cc))
 
 	s1 := &v1.Secret{
@@ -1260,13 +1324,11 @@ func TestIngressRouteTLSCertificateDelegation(t *testing.T) {
 
 	// assert there is no ingress_https because there is no matching secret.
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
 		Resources: []types.Any{
 			any(t, ingress_http),
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "2",
 	}, streamLDS(t, cc))
 
 	// t1 is a TLSCertificateDelegation that permits default to access secret/wildcard
@@ -1296,14 +1358,12 @@ func TestIngressRouteTLSCertificateDelegation(t *testing.T) {
 	}
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
 		Resources: []types.Any{
 			any(t, ingress_http),
 			any(t, ingress_https),
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "3",
 	}, streamLDS(t, cc))
 
 	// t2 is a TLSCertificateDelegation that permits access to secret/wildcard from all namespaces.
@@ -1324,14 +1384,12 @@ func TestIngressRouteTLSCertificateDelegation(t *testing.T) {
 	rh.OnUpdate(t1, t2)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "4",
 		Resources: []types.Any{
 			any(t, ingress_http),
 			any(t, ingress_https),
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "4",
 	}, streamLDS(t, cc))
 
 	// t3 is a TLSCertificateDelegation that permits access to secret/different all namespaces.
@@ -1352,13 +1410,11 @@ func TestIngressRouteTLSCertificateDelegation(t *testing.T) {
 	rh.OnUpdate(t2, t3)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "5",
 		Resources: []types.Any{
 			any(t, ingress_http),
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "5",
 	}, streamLDS(t, cc))
 
 	// t4 is a TLSCertificateDelegation that permits access to secret/wildcard from the kube-secret namespace.
@@ -1379,13 +1435,11 @@ func TestIngressRouteTLSCertificateDelegation(t *testing.T) {
 	rh.OnUpdate(t3, t4)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "6",
 		Resources: []types.Any{
 			any(t, ingress_http),
 			any(t, staticListener()),
 		},
 		TypeUrl: listenerType,
-		Nonce:   "6",
 	}, streamLDS(t, cc))
 
 }
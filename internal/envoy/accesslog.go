@@ -14,19 +14,178 @@
 package envoy
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	accesslogv2 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v2"
 	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/types"
 )
 
-// FileAccessLog returns a new file based access log filter.
+// AccessLogFormat describes the format of a file access log.
+// The zero value selects Envoy's default text format.
+type AccessLogFormat struct {
+	// Format is a custom text format string using Envoy's
+	// command operators. Ignored if JSONFields is set.
+	Format string
+
+	// JSONFields maps the name of each field of a JSON
+	// formatted log entry to the Envoy command operator
+	// which supplies its value.
+	JSONFields map[string]string
+}
+
+// JSONAccessLogFields maps the names of the predefined JSON access log
+// fields to the Envoy command operators which supply their values.
+var JSONAccessLogFields = map[string]string{
+	"@timestamp":                "%START_TIME%",
+	"authority":                 "%REQ(:AUTHORITY)%",
+	"bytes_received":            "%BYTES_RECEIVED%",
+	"bytes_sent":                "%BYTES_SENT%",
+	"downstream_local_address":  "%DOWNSTREAM_LOCAL_ADDRESS%",
+	"downstream_remote_address": "%DOWNSTREAM_REMOTE_ADDRESS%",
+	"duration":                  "%DURATION%",
+	"method":                    "%REQ(:METHOD)%",
+	"path":                      "%REQ(X-ENVOY-ORIGINAL-PATH?:PATH)%",
+	"protocol":                  "%PROTOCOL%",
+	"request_id":                "%REQ(X-REQUEST-ID)%",
+	"requested_server_name":     "%REQUESTED_SERVER_NAME%",
+	"response_code":             "%RESPONSE_CODE%",
+	"response_duration":         "%RESPONSE_DURATION%",
+	"response_flags":            "%RESPONSE_FLAGS%",
+	"upstream_cluster":          "%UPSTREAM_CLUSTER%",
+	"upstream_host":             "%UPSTREAM_HOST%",
+	"upstream_local_address":    "%UPSTREAM_LOCAL_ADDRESS%",
+	"upstream_service_time":     "%RESP(X-ENVOY-UPSTREAM-SERVICE-TIME)%",
+	"user_agent":                "%REQ(USER-AGENT)%",
+	"x_forwarded_for":           "%REQ(X-FORWARDED-FOR)%",
+}
+
+// DefaultJSONAccessLogFields are the fields of a JSON formatted
+// access log entry if none are specified.
+var DefaultJSONAccessLogFields = []string{
+	"@timestamp",
+	"authority",
+	"bytes_received",
+	"bytes_sent",
+	"downstream_local_address",
+	"downstream_remote_address",
+	"duration",
+	"method",
+	"path",
+	"protocol",
+	"request_id",
+	"requested_server_name",
+	"response_code",
+	"response_flags",
+	"upstream_cluster",
+	"upstream_host",
+	"upstream_local_address",
+	"upstream_service_time",
+	"user_agent",
+	"x_forwarded_for",
+}
+
+// ParseJSONAccessLogFields returns the JSON access log fields described
+// by fields. Each entry is either the name of a predefined field, or a
+// name=%OPERATOR% pair which adds a field supplied by the command operator.
+func ParseJSONAccessLogFields(fields []string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, f := range fields {
+		kv := strings.SplitN(f, "=", 2)
+		name := strings.TrimSpace(kv[0])
+		if name == "" {
+			return nil, fmt.Errorf("invalid field %q: name is required", f)
+		}
+		if len(kv) == 2 {
+			op := strings.TrimSpace(kv[1])
+			if !strings.HasPrefix(op, "%") || !strings.HasSuffix(op, "%") || len(op) < 3 {
+				return nil, fmt.Errorf("invalid field %q: value must be an Envoy command operator, such as %%START_TIME%%", f)
+			}
+			m[name] = op
+			continue
+		}
+		op, ok := JSONAccessLogFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", name, strings.Join(jsonAccessLogFieldNames(), ", "))
+		}
+		m[name] = op
+	}
+	return m, nil
+}
+
+func jsonAccessLogFieldNames() []string {
+	var names []string
+	for name := range JSONAccessLogFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FileAccessLog returns a new file based access log filter
+// which writes entries in Envoy's default text format.
 func FileAccessLog(path string) []*accesslog.AccessLog {
+	return FormattedFileAccessLog(path, AccessLogFormat{})
+}
+
+// FormattedFileAccessLog returns a new file based access log
+// filter which writes entries in the supplied format.
+func FormattedFileAccessLog(path string, format AccessLogFormat) []*accesslog.AccessLog {
+	fal := &accesslogv2.FileAccessLog{
+		Path: path,
+	}
+	switch {
+	case len(format.JSONFields) > 0:
+		fields := make(map[string]*types.Value)
+		for name, op := range format.JSONFields {
+			fields[name] = &types.Value{
+				Kind: &types.Value_StringValue{StringValue: op},
+			}
+		}
+		fal.AccessLogFormat = &accesslogv2.FileAccessLog_JsonFormat{
+			JsonFormat: &types.Struct{Fields: fields},
+		}
+	case format.Format != "":
+		f := format.Format
+		// Envoy does not terminate text format log entries.
+		if !strings.HasSuffix(f, "\n") {
+			f += "\n"
+		}
+		fal.AccessLogFormat = &accesslogv2.FileAccessLog_Format{
+			Format: f,
+		}
+	}
 	return []*accesslog.AccessLog{{
 		Name: util.FileAccessLog,
 		ConfigType: &accesslog.AccessLog_TypedConfig{
-			TypedConfig: any(&accesslogv2.FileAccessLog{
-				Path: path,
-				// TODO(dfc) FileAccessLog_Format elided.
+			TypedConfig: any(fal),
+		},
+	}}
+}
+
+// GRPCAccessLog returns a new access log filter which streams
+// HTTP access log entries to Contour's access log service.
+// logName identifies the source of the entries, typically the
+// name of the listener.
+func GRPCAccessLog(logName string) []*accesslog.AccessLog {
+	return []*accesslog.AccessLog{{
+		Name: util.HTTPGRPCAccessLog,
+		ConfigType: &accesslog.AccessLog_TypedConfig{
+			TypedConfig: any(&accesslogv2.HttpGrpcAccessLogConfig{
+				CommonConfig: &accesslogv2.CommonGrpcAccessLogConfig{
+					LogName: logName,
+					GrpcService: &core.GrpcService{
+						TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &core.GrpcService_EnvoyGrpc{
+								ClusterName: "contour",
+							},
+						},
+					},
+				},
 			}),
 		},
 	}}
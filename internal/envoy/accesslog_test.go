@@ -16,9 +16,11 @@ package envoy
 import (
 	"testing"
 
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	accesslog_v2 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v2"
 	envoy_accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/types"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -48,3 +50,177 @@ func TestFileAccessLog(t *testing.T) {
 		})
 	}
 }
+
+func TestFormattedFileAccessLog(t *testing.T) {
+	tests := map[string]struct {
+		format AccessLogFormat
+		want   *accesslog_v2.FileAccessLog
+	}{
+		"envoy": {
+			format: AccessLogFormat{},
+			want: &accesslog_v2.FileAccessLog{
+				Path: "/dev/stdout",
+			},
+		},
+		"custom": {
+			format: AccessLogFormat{
+				Format: "%START_TIME% %RESPONSE_CODE%",
+			},
+			want: &accesslog_v2.FileAccessLog{
+				Path: "/dev/stdout",
+				AccessLogFormat: &accesslog_v2.FileAccessLog_Format{
+					Format: "%START_TIME% %RESPONSE_CODE%\n",
+				},
+			},
+		},
+		"custom, already terminated": {
+			format: AccessLogFormat{
+				Format: "%START_TIME% %RESPONSE_CODE%\n",
+			},
+			want: &accesslog_v2.FileAccessLog{
+				Path: "/dev/stdout",
+				AccessLogFormat: &accesslog_v2.FileAccessLog_Format{
+					Format: "%START_TIME% %RESPONSE_CODE%\n",
+				},
+			},
+		},
+		"json": {
+			format: AccessLogFormat{
+				JSONFields: map[string]string{
+					"authority":      "%REQ(:AUTHORITY)%",
+					"response_flags": "%RESPONSE_FLAGS%",
+				},
+			},
+			want: &accesslog_v2.FileAccessLog{
+				Path: "/dev/stdout",
+				AccessLogFormat: &accesslog_v2.FileAccessLog_JsonFormat{
+					JsonFormat: &types.Struct{
+						Fields: map[string]*types.Value{
+							"authority": {
+								Kind: &types.Value_StringValue{StringValue: "%REQ(:AUTHORITY)%"},
+							},
+							"response_flags": {
+								Kind: &types.Value_StringValue{StringValue: "%RESPONSE_FLAGS%"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"json takes precedence over custom": {
+			format: AccessLogFormat{
+				Format: "%START_TIME%",
+				JSONFields: map[string]string{
+					"duration": "%DURATION%",
+				},
+			},
+			want: &accesslog_v2.FileAccessLog{
+				Path: "/dev/stdout",
+				AccessLogFormat: &accesslog_v2.FileAccessLog_JsonFormat{
+					JsonFormat: &types.Struct{
+						Fields: map[string]*types.Value{
+							"duration": {
+								Kind: &types.Value_StringValue{StringValue: "%DURATION%"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := FormattedFileAccessLog("/dev/stdout", tc.format)
+			want := []*envoy_accesslog.AccessLog{{
+				Name: util.FileAccessLog,
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: any(tc.want),
+				},
+			}}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestParseJSONAccessLogFields(t *testing.T) {
+	tests := map[string]struct {
+		fields  []string
+		want    map[string]string
+		wantErr bool
+	}{
+		"predefined fields": {
+			fields: []string{"authority", "upstream_cluster", "request_id"},
+			want: map[string]string{
+				"authority":        "%REQ(:AUTHORITY)%",
+				"upstream_cluster": "%UPSTREAM_CLUSTER%",
+				"request_id":       "%REQ(X-REQUEST-ID)%",
+			},
+		},
+		"custom field": {
+			fields: []string{"duration", "tenant=%REQ(X-TENANT)%"},
+			want: map[string]string{
+				"duration": "%DURATION%",
+				"tenant":   "%REQ(X-TENANT)%",
+			},
+		},
+		"unknown field": {
+			fields:  []string{"colour"},
+			wantErr: true,
+		},
+		"custom field without operator": {
+			fields:  []string{"tenant=acme"},
+			wantErr: true,
+		},
+		"missing name": {
+			fields:  []string{"=%DURATION%"},
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseJSONAccessLogFields(tc.fields)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestDefaultJSONAccessLogFields(t *testing.T) {
+	// every default field must be a predefined field.
+	if _, err := ParseJSONAccessLogFields(DefaultJSONAccessLogFields); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGRPCAccessLog(t *testing.T) {
+	got := GRPCAccessLog("ingress_http")
+	want := []*envoy_accesslog.AccessLog{{
+		Name: util.HTTPGRPCAccessLog,
+		ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+			TypedConfig: any(&accesslog_v2.HttpGrpcAccessLogConfig{
+				CommonConfig: &accesslog_v2.CommonGrpcAccessLogConfig{
+					LogName: "ingress_http",
+					GrpcService: &core.GrpcService{
+						TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &core.GrpcService_EnvoyGrpc{
+								ClusterName: "contour",
+							},
+						},
+					},
+				},
+			}),
+		},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	api "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	clusterv2 "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
@@ -39,6 +40,7 @@ func Bootstrap(c *BootstrapConfig) *bootstrap.Bootstrap {
 				ConnectTimeout:       5 * time.Second,
 				ClusterDiscoveryType: ClusterDiscoveryType(api.Cluster_STRICT_DNS),
 				LbPolicy:             api.Cluster_ROUND_ROBIN,
+				DnsLookupFamily:      DNSLookupFamily(c.DNSLookupFamily),
 				LoadAssignment: &api.ClusterLoadAssignment{
 					ClusterName: "contour",
 					Endpoints: []endpoint.LocalityLbEndpoints{{
@@ -69,6 +71,7 @@ func Bootstrap(c *BootstrapConfig) *bootstrap.Bootstrap {
 				ConnectTimeout:       250 * time.Millisecond,
 				ClusterDiscoveryType: ClusterDiscoveryType(api.Cluster_LOGICAL_DNS),
 				LbPolicy:             api.Cluster_ROUND_ROBIN,
+				DnsLookupFamily:      DNSLookupFamily(c.DNSLookupFamily),
 				LoadAssignment: &api.ClusterLoadAssignment{
 					ClusterName: "service-stats",
 					Endpoints: []endpoint.LocalityLbEndpoints{{
@@ -85,9 +88,60 @@ func Bootstrap(c *BootstrapConfig) *bootstrap.Bootstrap {
 		},
 	}
 
+	if c.ADS {
+		// Envoy fetches clusters and listeners over a single
+		// aggregated stream, which Contour orders to avoid
+		// referring to resources Envoy does not yet have.
+		b.DynamicResources.AdsConfig = ConfigSource("contour").GetApiConfigSource()
+		b.DynamicResources.LdsConfig = ADSConfigSource()
+		b.DynamicResources.CdsConfig = ADSConfigSource()
+	}
+
+	if c.GrpcCABundle != "" || c.GrpcClientCert != "" || c.GrpcClientKey != "" {
+		// Envoy authenticates itself to Contour with its client
+		// certificate, and only trusts a Contour whose certificate
+		// is signed by the CA bundle.
+		b.StaticResources.Clusters[0].TlsContext = upstreamFileTLSContext(c.GrpcCABundle, c.GrpcClientCert, c.GrpcClientKey)
+	}
+
+	if c.LoadReporting {
+		// Envoy only opens a load reporting stream if the
+		// bootstrap configures a load stats server.
+		b.ClusterManager.LoadStatsConfig = ConfigSource("contour").GetApiConfigSource()
+	}
+
 	return b
 }
 
+// upstreamFileTLSContext returns a TLS context which presents the
+// certificate and key in certfile and keyfile, and verifies the
+// peer's certificate against the CA bundle in cafile. Envoy reads
+// the files itself, so they are reloaded when Envoy restarts.
+func upstreamFileTLSContext(cafile, certfile, keyfile string) *auth.UpstreamTlsContext {
+	return &auth.UpstreamTlsContext{
+		CommonTlsContext: &auth.CommonTlsContext{
+			TlsCertificates: []*auth.TlsCertificate{{
+				CertificateChain: filename(certfile),
+				PrivateKey:       filename(keyfile),
+			}},
+			ValidationContextType: &auth.CommonTlsContext_ValidationContext{
+				ValidationContext: &auth.CertificateValidationContext{
+					TrustedCa:            filename(cafile),
+					VerifySubjectAltName: []string{XDSServerName},
+				},
+			},
+		},
+	}
+}
+
+func filename(path string) *core.DataSource {
+	return &core.DataSource{
+		Specifier: &core.DataSource_Filename{
+			Filename: path,
+		},
+	}
+}
+
 func stringOrDefault(s, def string) string {
 	if s == "" {
 		return def
@@ -102,6 +156,10 @@ func intOrDefault(i, def int) int {
 	return i
 }
 
+// XDSServerName is the subject alternative name Envoy and contour cli
+// expect in the certificate of the gRPC XDS management server.
+const XDSServerName = "contour"
+
 // BootstrapConfig holds configuration values for a v2.Bootstrap.
 type BootstrapConfig struct {
 	// AdminAccessLogPath is the path to write the access log for the administration server.
@@ -124,6 +182,34 @@ type BootstrapConfig struct {
 	// Defaults to 8001.
 	XDSGRPCPort int
 
+	// DNSLookupFamily is the DNS IP address resolution policy for
+	// the contour and service-stats clusters; one of auto, v4, or v6.
+	// Defaults to auto.
+	DNSLookupFamily string
+
+	// ADS configures Envoy to fetch its clusters and listeners
+	// over the aggregated discovery service of the gRPC XDS
+	// management server.
+	// Defaults to false.
+	ADS bool
+
+	// LoadReporting configures Envoy to send load reports to
+	// the load reporting service of the gRPC XDS management server.
+	// Defaults to false.
+	LoadReporting bool
+
+	// GrpcCABundle is the path to the CA bundle used to verify
+	// the certificate of the gRPC XDS management server.
+	// Defaults to plaintext connections.
+	GrpcCABundle string
+
+	// GrpcClientCert is the path to the certificate Envoy presents
+	// to the gRPC XDS management server.
+	GrpcClientCert string
+
+	// GrpcClientKey is the path to the private key of GrpcClientCert.
+	GrpcClientKey string
+
 	// Namespace is the namespace where Contour is running
 	Namespace string
 }
@@ -113,6 +113,12 @@ func cluster(cluster *dag.Cluster, service *dag.TCPService) *v2.Cluster {
 			}},
 		}
 	}
+
+	// Envoy reports the load of this cluster to Contour's
+	// load reporting service if asked.
+	if service.LoadReporting {
+		c.LrsServer = ConfigSource("contour")
+	}
 	return c
 }
 
@@ -162,6 +168,20 @@ func edsconfig(cluster string, service *dag.TCPService) *v2.Cluster_EdsClusterCo
 	}
 }
 
+// DNSLookupFamily returns the v2.Cluster_DnsLookupFamily for the
+// supplied family, one of "auto", "v4", or "v6". Any other value
+// selects AUTO, which prefers IPv6 and falls back to IPv4.
+func DNSLookupFamily(family string) v2.Cluster_DnsLookupFamily {
+	switch family {
+	case "v4":
+		return v2.Cluster_V4_ONLY
+	case "v6":
+		return v2.Cluster_V6_ONLY
+	default:
+		return v2.Cluster_AUTO
+	}
+}
+
 func lbPolicy(strategy string) v2.Cluster_LbPolicy {
 	switch strategy {
 	case "WeightedLeastRequest":
@@ -325,6 +345,16 @@ func ConfigSource(cluster string) *core.ConfigSource {
 	}
 }
 
+// ADSConfigSource returns a *core.ConfigSource which fetches
+// resources over Envoy's aggregated discovery stream.
+func ADSConfigSource() *core.ConfigSource {
+	return &core.ConfigSource{
+		ConfigSourceSpecifier: &core.ConfigSource_Ads{
+			Ads: &core.AggregatedConfigSource{},
+		},
+	}
+}
+
 // ClusterDiscoveryType returns the type of a ClusterDiscovery as a Cluster_type.
 func ClusterDiscoveryType(t v2.Cluster_DiscoveryType) *v2.Cluster_Type {
 	return &v2.Cluster_Type{Type: t}
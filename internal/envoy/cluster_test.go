@@ -312,6 +312,30 @@ func TestCluster(t *testing.T) {
 				CommonLbConfig: ClusterCommonLBConfig(),
 			},
 		},
+		"contour.heptio.com/load-reporting": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.HTTPService{
+					TCPService: dag.TCPService{
+						Name: s1.Name, Namespace: s1.Namespace,
+						ServicePort:   &s1.Spec.Ports[0],
+						LoadReporting: true,
+					},
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				ConnectTimeout: 250 * time.Millisecond,
+				LbPolicy:       v2.Cluster_ROUND_ROBIN,
+				CommonLbConfig: ClusterCommonLBConfig(),
+				LrsServer:      ConfigSource("contour"),
+			},
+		},
 		"tcp service": {
 			cluster: &dag.Cluster{
 				Upstream: &dag.TCPService{
@@ -505,6 +529,25 @@ func TestLBPolicy(t *testing.T) {
 	}
 }
 
+func TestDNSLookupFamily(t *testing.T) {
+	tests := map[string]v2.Cluster_DnsLookupFamily{
+		"auto":    v2.Cluster_AUTO,
+		"v4":      v2.Cluster_V4_ONLY,
+		"v6":      v2.Cluster_V6_ONLY,
+		"":        v2.Cluster_AUTO,
+		"unknown": v2.Cluster_AUTO,
+	}
+
+	for family, want := range tests {
+		t.Run(family, func(t *testing.T) {
+			got := DNSLookupFamily(family)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
 func TestHashname(t *testing.T) {
 	tests := []struct {
 		name string
@@ -18,6 +18,7 @@ import (
 )
 
 // LBEndpoint creates a new SocketAddress LbEndpoint.
+// addr may be a hostname, an IPv4 address, or an IPv6 address.
 func LBEndpoint(addr string, port int) endpoint.LbEndpoint {
 	return endpoint.LbEndpoint{
 		HostIdentifier: &endpoint.LbEndpoint_Endpoint{
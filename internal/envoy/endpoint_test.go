@@ -22,30 +22,47 @@ import (
 )
 
 func TestLBEndpoint(t *testing.T) {
-	const (
-		addr = "foo.example.com"
-		port = 8123
-	)
+	tests := map[string]struct {
+		addr string
+		want string
+	}{
+		"hostname": {
+			addr: "foo.example.com",
+			want: "foo.example.com",
+		},
+		"ipv4": {
+			addr: "172.16.0.1",
+			want: "172.16.0.1",
+		},
+		"ipv6": {
+			addr: "fd00:0:0:0:0:0:0:1",
+			want: "fd00::1",
+		},
+	}
 
-	got := LBEndpoint(addr, port)
-	want := endpoint.LbEndpoint{
-		HostIdentifier: &endpoint.LbEndpoint_Endpoint{
-			Endpoint: &endpoint.Endpoint{
-				Address: &core.Address{
-					Address: &core.Address_SocketAddress{
-						SocketAddress: &core.SocketAddress{
-							Protocol: core.TCP,
-							Address:  addr,
-							PortSpecifier: &core.SocketAddress_PortValue{
-								PortValue: port,
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := LBEndpoint(tc.addr, 8123)
+			want := endpoint.LbEndpoint{
+				HostIdentifier: &endpoint.LbEndpoint_Endpoint{
+					Endpoint: &endpoint.Endpoint{
+						Address: &core.Address{
+							Address: &core.Address_SocketAddress{
+								SocketAddress: &core.SocketAddress{
+									Protocol: core.TCP,
+									Address:  tc.want,
+									PortSpecifier: &core.SocketAddress_PortValue{
+										PortValue: 8123,
+									},
+								},
 							},
 						},
 					},
 				},
-			},
-		},
-	}
-	if diff := cmp.Diff(want, got); diff != "" {
-		t.Fatal(diff)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
 	}
 }
@@ -14,12 +14,15 @@
 package envoy
 
 import (
+	"net"
 	"sort"
+	"strings"
 	"time"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/util"
@@ -79,9 +82,38 @@ func idleTimeout(d time.Duration) *time.Duration {
 	return &d
 }
 
+// ClientIPDetection describes how the HTTP Connection Manager
+// determines the address of the client which originated a request.
+// The zero value uses the address of the downstream connection.
+type ClientIPDetection struct {
+	// XffNumTrustedHops is the number of proxies in front of Envoy
+	// whose entries in the X-Forwarded-For header are trusted. The
+	// client address is taken from the X-Forwarded-For header, this
+	// many entries from the right.
+	XffNumTrustedHops uint32
+
+	// SkipXffAppend stops Envoy appending the address of the
+	// downstream connection to the X-Forwarded-For header.
+	SkipXffAppend bool
+}
+
 // HTTPConnectionManager creates a new HTTP Connection Manager filter
 // for the supplied route and access log.
 func HTTPConnectionManager(routename, accessLogPath string) listener.Filter {
+	return ClientIPConnectionManager(routename, FileAccessLog(accessLogPath), ClientIPDetection{})
+}
+
+// ClientIPConnectionManager creates a new HTTP Connection Manager
+// filter for the supplied route and access log which determines the
+// client address as described by cid.
+func ClientIPConnectionManager(routename string, accessLog []*accesslog.AccessLog, cid ClientIPDetection) listener.Filter {
+	return ConnectionManager(routename, accessLog, cid, ConfigSource("contour"))
+}
+
+// ConnectionManager creates a new HTTP Connection Manager filter
+// for the supplied route and access log which fetches its route
+// configuration from rds.
+func ConnectionManager(routename string, accessLog []*accesslog.AccessLog, cid ClientIPDetection, rds *core.ConfigSource) listener.Filter {
 	return listener.Filter{
 		Name: util.HTTPConnectionManager,
 		ConfigType: &listener.Filter_TypedConfig{
@@ -90,20 +122,7 @@ func HTTPConnectionManager(routename, accessLogPath string) listener.Filter {
 				RouteSpecifier: &http.HttpConnectionManager_Rds{
 					Rds: &http.Rds{
 						RouteConfigName: routename,
-						ConfigSource: core.ConfigSource{
-							ConfigSourceSpecifier: &core.ConfigSource_ApiConfigSource{
-								ApiConfigSource: &core.ApiConfigSource{
-									ApiType: core.ApiConfigSource_GRPC,
-									GrpcServices: []*core.GrpcService{{
-										TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
-											EnvoyGrpc: &core.GrpcService_EnvoyGrpc{
-												ClusterName: "contour",
-											},
-										},
-									}},
-								},
-							},
-						},
+						ConfigSource:    *rds,
 					},
 				},
 				HttpFilters: []*http.HttpFilter{{
@@ -118,17 +137,23 @@ func HTTPConnectionManager(routename, accessLogPath string) listener.Filter {
 					// a Host: header. See #537.
 					AcceptHttp_10: true,
 				},
-				AccessLog:        FileAccessLog(accessLogPath),
-				UseRemoteAddress: &types.BoolValue{Value: true}, // TODO(jbeda) should this ever be false?
-				NormalizePath:    &types.BoolValue{Value: true},
-				IdleTimeout:      idleTimeout(HTTPDefaultIdleTimeout),
+				AccessLog: accessLog,
+				// Always use the address of the downstream connection,
+				// or the trusted X-Forwarded-For entries, as the client
+				// address. Trusting whatever X-Forwarded-For header the
+				// client sent would allow the client address to be spoofed.
+				UseRemoteAddress:  &types.BoolValue{Value: true},
+				XffNumTrustedHops: cid.XffNumTrustedHops,
+				SkipXffAppend:     cid.SkipXffAppend,
+				NormalizePath:     &types.BoolValue{Value: true},
+				IdleTimeout:       idleTimeout(HTTPDefaultIdleTimeout),
 			}),
 		},
 	}
 }
 
 // TCPProxy creates a new TCPProxy filter.
-func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accessLogPath string) listener.Filter {
+func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accessLog []*accesslog.AccessLog) listener.Filter {
 	tcpIdleTimeout := idleTimeout(TCPDefaultIdleTimeout)
 	switch len(proxy.Clusters) {
 	case 1:
@@ -140,7 +165,7 @@ func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accessLogPath string) list
 					ClusterSpecifier: &tcp.TcpProxy_Cluster{
 						Cluster: Clustername(proxy.Clusters[0]),
 					},
-					AccessLog:   FileAccessLog(accessLogPath),
+					AccessLog:   accessLog,
 					IdleTimeout: tcpIdleTimeout,
 				}),
 			},
@@ -168,7 +193,7 @@ func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accessLogPath string) list
 							Clusters: clusters,
 						},
 					},
-					AccessLog:   FileAccessLog(accessLogPath),
+					AccessLog:   accessLog,
 					IdleTimeout: tcpIdleTimeout,
 				}),
 			},
@@ -188,7 +213,11 @@ func (c clustersByNameAndWeight) Less(i, j int) bool {
 }
 
 // SocketAddress creates a new TCP core.Address.
+// IPv6 addresses may be supplied with or without enclosing brackets.
+// If address is the IPv6 unspecified address, ::, the socket will
+// also accept IPv4 connections as IPv4-mapped IPv6 addresses.
 func SocketAddress(address string, port int) *core.Address {
+	address = normaliseAddress(address)
 	return &core.Address{
 		Address: &core.Address_SocketAddress{
 			SocketAddress: &core.SocketAddress{
@@ -197,11 +226,38 @@ func SocketAddress(address string, port int) *core.Address {
 				PortSpecifier: &core.SocketAddress_PortValue{
 					PortValue: uint32(port),
 				},
+				Ipv4Compat: address == "::",
 			},
 		},
 	}
 }
 
+// normaliseAddress strips the brackets from a bracketed IPv6 address
+// and returns IP literals in their canonical form. Hostnames are
+// returned unchanged.
+func normaliseAddress(address string) string {
+	if strings.HasPrefix(address, "[") && strings.HasSuffix(address, "]") {
+		address = address[1 : len(address)-1]
+	}
+	if ip := net.ParseIP(address); ip != nil {
+		return ip.String()
+	}
+	return address
+}
+
+// CIDRRanges returns a core.CidrRange for each of the supplied networks.
+func CIDRRanges(networks []*net.IPNet) []*core.CidrRange {
+	var ranges []*core.CidrRange
+	for _, n := range networks {
+		ones, _ := n.Mask.Size()
+		ranges = append(ranges, &core.CidrRange{
+			AddressPrefix: n.IP.String(),
+			PrefixLen:     &types.UInt32Value{Value: uint32(ones)},
+		})
+	}
+	return ranges
+}
+
 func any(pb proto.Message) *types.Any {
 	any, err := types.MarshalAny(pb)
 	if err != nil {
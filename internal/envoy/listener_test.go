@@ -14,6 +14,7 @@
 package envoy
 
 import (
+	"net"
 	"testing"
 	"time"
 
@@ -122,23 +123,100 @@ func TestListener(t *testing.T) {
 }
 
 func TestSocketAddress(t *testing.T) {
-	const (
-		addr = "foo.example.com"
-		port = 8123
-	)
-
-	got := SocketAddress(addr, port)
-	want := &core.Address{
-		Address: &core.Address_SocketAddress{
-			SocketAddress: &core.SocketAddress{
+	tests := map[string]struct {
+		addr string
+		want *core.SocketAddress
+	}{
+		"hostname": {
+			addr: "foo.example.com",
+			want: &core.SocketAddress{
 				Protocol: core.TCP,
-				Address:  addr,
+				Address:  "foo.example.com",
 				PortSpecifier: &core.SocketAddress_PortValue{
-					PortValue: port,
+					PortValue: 8123,
 				},
 			},
 		},
+		"ipv4": {
+			addr: "0.0.0.0",
+			want: &core.SocketAddress{
+				Protocol: core.TCP,
+				Address:  "0.0.0.0",
+				PortSpecifier: &core.SocketAddress_PortValue{
+					PortValue: 8123,
+				},
+			},
+		},
+		"ipv6": {
+			addr: "2001:DB8:0:0::1",
+			want: &core.SocketAddress{
+				Protocol: core.TCP,
+				Address:  "2001:db8::1",
+				PortSpecifier: &core.SocketAddress_PortValue{
+					PortValue: 8123,
+				},
+			},
+		},
+		"bracketed ipv6": {
+			addr: "[fd00::10]",
+			want: &core.SocketAddress{
+				Protocol: core.TCP,
+				Address:  "fd00::10",
+				PortSpecifier: &core.SocketAddress_PortValue{
+					PortValue: 8123,
+				},
+			},
+		},
+		"ipv6 unspecified address accepts ipv4": {
+			addr: "::",
+			want: &core.SocketAddress{
+				Protocol: core.TCP,
+				Address:  "::",
+				PortSpecifier: &core.SocketAddress_PortValue{
+					PortValue: 8123,
+				},
+				Ipv4Compat: true,
+			},
+		},
+		"bracketed ipv6 unspecified address": {
+			addr: "[::]",
+			want: &core.SocketAddress{
+				Protocol: core.TCP,
+				Address:  "::",
+				PortSpecifier: &core.SocketAddress_PortValue{
+					PortValue: 8123,
+				},
+				Ipv4Compat: true,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := SocketAddress(tc.addr, 8123)
+			want := &core.Address{
+				Address: &core.Address_SocketAddress{
+					SocketAddress: tc.want,
+				},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
 	}
+}
+
+func TestCIDRRanges(t *testing.T) {
+	_, v4, _ := net.ParseCIDR("10.0.0.0/8")
+	_, v6, _ := net.ParseCIDR("fd00::/64")
+	got := CIDRRanges([]*net.IPNet{v4, v6})
+	want := []*core.CidrRange{{
+		AddressPrefix: "10.0.0.0",
+		PrefixLen:     &types.UInt32Value{Value: 8},
+	}, {
+		AddressPrefix: "fd00::",
+		PrefixLen:     &types.UInt32Value{Value: 64},
+	}}
 	if diff := cmp.Diff(want, got); diff != "" {
 		t.Fatal(diff)
 	}
@@ -196,6 +274,7 @@ func TestHTTPConnectionManager(t *testing.T) {
 	tests := map[string]struct {
 		routename string
 		accesslog string
+		cid       ClientIPDetection
 		want      listener.Filter
 	}{
 		"default": {
@@ -245,10 +324,112 @@ func TestHTTPConnectionManager(t *testing.T) {
 				},
 			},
 		},
+		"trusted hops": {
+			routename: "default/kuard",
+			accesslog: "/dev/stdout",
+			cid: ClientIPDetection{
+				XffNumTrustedHops: 2,
+			},
+			want: listener.Filter{
+				Name: util.HTTPConnectionManager,
+				ConfigType: &listener.Filter_TypedConfig{
+					TypedConfig: any(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: core.ConfigSource{
+									ConfigSourceSpecifier: &core.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &core.ApiConfigSource{
+											ApiType: core.ApiConfigSource_GRPC,
+											GrpcServices: []*core.GrpcService{{
+												TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &core.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: util.Gzip,
+						}, {
+							Name: util.GRPCWeb,
+						}, {
+							Name: util.Router,
+						}},
+						HttpProtocolOptions: &core.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						AccessLog:         FileAccessLog("/dev/stdout"),
+						UseRemoteAddress:  &types.BoolValue{Value: true},
+						XffNumTrustedHops: 2,
+						NormalizePath:     &types.BoolValue{Value: true},
+						IdleTimeout:       duration(HTTPDefaultIdleTimeout),
+					}),
+				},
+			},
+		},
+		"skip xff append": {
+			routename: "default/kuard",
+			accesslog: "/dev/stdout",
+			cid: ClientIPDetection{
+				SkipXffAppend: true,
+			},
+			want: listener.Filter{
+				Name: util.HTTPConnectionManager,
+				ConfigType: &listener.Filter_TypedConfig{
+					TypedConfig: any(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: core.ConfigSource{
+									ConfigSourceSpecifier: &core.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &core.ApiConfigSource{
+											ApiType: core.ApiConfigSource_GRPC,
+											GrpcServices: []*core.GrpcService{{
+												TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &core.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: util.Gzip,
+						}, {
+							Name: util.GRPCWeb,
+						}, {
+							Name: util.Router,
+						}},
+						HttpProtocolOptions: &core.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						AccessLog:        FileAccessLog("/dev/stdout"),
+						UseRemoteAddress: &types.BoolValue{Value: true},
+						SkipXffAppend:    true,
+						NormalizePath:    &types.BoolValue{Value: true},
+						IdleTimeout:      duration(HTTPDefaultIdleTimeout),
+					}),
+				},
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := HTTPConnectionManager(tc.routename, tc.accesslog)
+			got := ClientIPConnectionManager(tc.routename, FileAccessLog(tc.accesslog), tc.cid)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -338,7 +519,7 @@ func TestTCPProxy(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := TCPProxy(statPrefix, tc.proxy, accessLogPath)
+			got := TCPProxy(statPrefix, tc.proxy, FileAccessLog(accessLogPath))
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
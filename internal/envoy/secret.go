@@ -11,7 +11,12 @@ import (
 
 // Secretname returns the name of the SDS secret for this secret.
 func Secretname(s *dag.Secret) string {
-	hash := sha1.Sum(s.Cert())
+	// the OCSP staple, if present, is included in the hash so that
+	// vhosts sharing a certificate but not a staple are distinct.
+	h := sha1.New()
+	h.Write(s.Cert())
+	h.Write(s.OCSPStaple())
+	hash := h.Sum(nil)
 	ns := s.Namespace()
 	name := s.Name()
 	return hashname(60, ns, name, fmt.Sprintf("%x", hash[:5]))
@@ -33,7 +38,22 @@ func Secret(s *dag.Secret) *auth.Secret {
 						InlineBytes: s.Cert(),
 					},
 				},
+				OcspStaple: ocspStaple(s),
 			},
 		},
 	}
 }
+
+// ocspStaple returns a *core.DataSource containing the OCSP
+// response to staple, or nil if there is no response to staple.
+func ocspStaple(s *dag.Secret) *core.DataSource {
+	staple := s.OCSPStaple()
+	if len(staple) == 0 {
+		return nil
+	}
+	return &core.DataSource{
+		Specifier: &core.DataSource_InlineBytes{
+			InlineBytes: staple,
+		},
+	}
+}
@@ -47,6 +47,45 @@ func TestSecret(t *testing.T) {
 				},
 			},
 		},
+		"stapled secret": {
+			secret: &dag.Secret{
+				Object: &v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						v1.TLSCertKey:       []byte("cert"),
+						v1.TLSPrivateKeyKey: []byte("key"),
+					},
+				},
+				OCSP: &dag.OCSPResponse{
+					Raw: []byte("staple"),
+				},
+			},
+			want: &auth.Secret{
+				Name: "default/simple/3896677cdc",
+				Type: &auth.Secret_TlsCertificate{
+					TlsCertificate: &auth.TlsCertificate{
+						PrivateKey: &core.DataSource{
+							Specifier: &core.DataSource_InlineBytes{
+								InlineBytes: []byte("key"),
+							},
+						},
+						CertificateChain: &core.DataSource{
+							Specifier: &core.DataSource_InlineBytes{
+								InlineBytes: []byte("cert"),
+							},
+						},
+						OcspStaple: &core.DataSource{
+							Specifier: &core.DataSource_InlineBytes{
+								InlineBytes: []byte("staple"),
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -79,6 +118,25 @@ func TestSecretname(t *testing.T) {
 			},
 			want: "default/simple/cd1b506996",
 		},
+		"stale staple is ignored": {
+			secret: &dag.Secret{
+				Object: &v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						v1.TLSCertKey:       []byte("cert"),
+						v1.TLSPrivateKeyKey: []byte("key"),
+					},
+				},
+				OCSP: &dag.OCSPResponse{
+					Raw:   []byte("staple"),
+					Stale: true,
+				},
+			},
+			want: "default/simple/cd1b506996",
+		},
 		"far too long": {
 			secret: &dag.Secret{
 				Object: &v1.Secret{
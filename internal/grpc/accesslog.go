@@ -0,0 +1,78 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"io"
+
+	accesslogdata "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v2"
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// AccessLogHandler receives the access log entries streamed
+// from Envoy to the access log service.
+type AccessLogHandler interface {
+	// HTTPAccessLogs is called with each batch of HTTP access log
+	// entries. logName is the name the stream was opened with.
+	HTTPAccessLogs(logName string, entries []*accesslogdata.HTTPAccessLogEntry)
+
+	// TCPAccessLogs is called with each batch of TCP access log
+	// entries. logName is the name the stream was opened with.
+	TCPAccessLogs(logName string, entries []*accesslogdata.TCPAccessLogEntry)
+}
+
+// accessLogServer implements the Envoy v2 access log service.
+type accessLogServer struct {
+	logrus.FieldLogger
+	connections counter
+	handler     AccessLogHandler
+}
+
+// StreamAccessLogs receives access log entries until Envoy
+// closes the stream.
+func (s *accessLogServer) StreamAccessLogs(st accesslog.AccessLogService_StreamAccessLogsServer) (err error) {
+	log := s.WithField("connection", s.connections.next())
+
+	defer func() {
+		if err != nil {
+			log.WithError(err).Error("access log stream terminated")
+		} else {
+			log.Info("access log stream terminated")
+		}
+	}()
+
+	// Envoy only sends the identifier in the first message of a stream.
+	var logName string
+	for {
+		msg, err := st.Recv()
+		if err == io.EOF {
+			return st.SendAndClose(new(accesslog.StreamAccessLogsResponse))
+		}
+		if err != nil {
+			return err
+		}
+		if id := msg.GetIdentifier(); id != nil {
+			logName = id.LogName
+			log = log.WithField("log_name", logName).WithField("node", id.GetNode().GetId())
+			log.Info("access log stream started")
+		}
+		switch entries := msg.LogEntries.(type) {
+		case *accesslog.StreamAccessLogsMessage_HttpLogs:
+			s.handler.HTTPAccessLogs(logName, entries.HttpLogs.GetLogEntry())
+		case *accesslog.StreamAccessLogsMessage_TcpLogs:
+			s.handler.TCPAccessLogs(logName, entries.TcpLogs.GetLogEntry())
+		}
+	}
+}
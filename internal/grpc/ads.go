@@ -0,0 +1,148 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"sort"
+
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+)
+
+// typeOrder is the order in which the responses of an aggregated
+// stream are sent when more than one type has changed. Clusters are
+// sent before the endpoints which refer to them, and secrets, listeners,
+// and routes follow, so Envoy never sees a route to a cluster it does
+// not yet know.
+var typeOrder = []string{
+	cache.ClusterType,
+	cache.EndpointType,
+	cache.SecretType,
+	cache.ListenerType,
+	cache.RouteType,
+}
+
+// notification is a notification from the Resource of typeURL.
+type notification struct {
+	typeURL string
+	last    int
+}
+
+// watcher multiplexes the notifications of several Resources
+// onto a single channel.
+type watcher struct {
+	ctx    context.Context
+	notify chan notification
+	chs    map[string]chan int
+}
+
+func newWatcher(ctx context.Context) *watcher {
+	return &watcher{
+		ctx:    ctx,
+		notify: make(chan notification),
+		chs:    make(map[string]chan int),
+	}
+}
+
+// register registers interest in the next notification of r
+// after last which concerns the resources named by names, or any
+// resource of r if names is empty. The notification is delivered
+// to w.notify. Registering r again replaces names.
+func (w *watcher) register(r Resource, last int, names []string) {
+	typeURL := r.TypeURL()
+	ch, ok := w.chs[typeURL]
+	if !ok {
+		ch = make(chan int, 1)
+		w.chs[typeURL] = ch
+		go func() {
+			for {
+				select {
+				case last := <-ch:
+					select {
+					case w.notify <- notification{typeURL: typeURL, last: last}:
+					case <-w.ctx.Done():
+						return
+					}
+				case <-w.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	r.Register(ch, last, names...)
+}
+
+// pending returns the typeURLs of n and of any other notifications
+// which are ready, in typeOrder, and the last value of each.
+func (w *watcher) pending(n notification) ([]string, map[string]int) {
+	last := map[string]int{n.typeURL: n.last}
+	for {
+		select {
+		case n := <-w.notify:
+			last[n.typeURL] = n.last
+		default:
+			return ordered(last), last
+		}
+	}
+}
+
+// ordered returns the keys of m in typeOrder. Unknown types
+// follow in lexical order.
+func ordered(m map[string]int) []string {
+	rank := func(typeURL string) int {
+		for i, t := range typeOrder {
+			if t == typeURL {
+				return i
+			}
+		}
+		return len(typeOrder)
+	}
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ri, rj := rank(keys[i]), rank(keys[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// ads processes a stream of DiscoveryRequests for any registered
+// type. Unlike stream, which serves a single type, ads multiplexes
+// every type Envoy requests onto one stream.
+func (xh *xdsHandler) ads(st grpcStream) error {
+	return xh.serve(st, true)
+}
+
+// equalNames returns true if a and b hold the same names in any order.
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int)
+	for _, name := range a {
+		seen[name]++
+	}
+	for _, name := range b {
+		if seen[name] == 0 {
+			return false
+		}
+		seen[name]--
+	}
+	return true
+}
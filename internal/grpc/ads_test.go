@@ -0,0 +1,158 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/google/go-cmp/cmp"
+	"github.com/heptio/contour/internal/contour"
+	"github.com/sirupsen/logrus"
+)
+
+func TestOrdered(t *testing.T) {
+	tests := map[string]struct {
+		pending map[string]int
+		want    []string
+	}{
+		"single type": {
+			pending: map[string]int{cache.RouteType: 1},
+			want:    []string{cache.RouteType},
+		},
+		"routes before clusters": {
+			pending: map[string]int{
+				cache.RouteType:    1,
+				cache.ListenerType: 1,
+				cache.SecretType:   1,
+				cache.EndpointType: 1,
+				cache.ClusterType:  1,
+			},
+			want: []string{
+				cache.ClusterType,
+				cache.EndpointType,
+				cache.SecretType,
+				cache.ListenerType,
+				cache.RouteType,
+			},
+		},
+		"unknown types last": {
+			pending: map[string]int{
+				"type.example.com/b": 1,
+				"type.example.com/a": 1,
+				cache.ListenerType:   1,
+			},
+			want: []string{
+				cache.ListenerType,
+				"type.example.com/a",
+				"type.example.com/b",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ordered(tc.pending)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestXDSHandlerADS(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	var cc contour.ClusterCache
+	var rc contour.RouteCache
+	xh := xdsHandler{
+		FieldLogger: log,
+		resources: map[string]Resource{
+			cc.TypeURL(): &cc,
+			rc.TypeURL(): &rc,
+		},
+	}
+	cc.Update(map[string]*v2.Cluster{
+		"a": {Name: "a"},
+		"b": {Name: "b"},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reqs := make(chan *v2.DiscoveryRequest, 1)
+	resps := make(chan *v2.DiscoveryResponse, 1)
+	st := &mockStream{
+		context: func() context.Context { return ctx },
+		send: func(resp *v2.DiscoveryResponse) error {
+			select {
+			case resps <- resp:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+		recv: func() (*v2.DiscoveryRequest, error) {
+			select {
+			case req := <-reqs:
+				return req, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- xh.ads(st)
+	}()
+
+	check := func(typeURL string, count int) {
+		t.Helper()
+		select {
+		case resp := <-resps:
+			if resp.TypeUrl != typeURL || len(resp.Resources) != count {
+				t.Fatalf("expected %d %s, got %d %s", count, typeURL, len(resp.Resources), resp.TypeUrl)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for response")
+		}
+	}
+
+	// request a named cluster, then routes, on the same stream.
+	reqs <- &v2.DiscoveryRequest{TypeUrl: cache.ClusterType, ResourceNames: []string{"a"}}
+	check(cache.ClusterType, 1)
+	reqs <- &v2.DiscoveryRequest{TypeUrl: cache.RouteType}
+	check(cache.RouteType, 0)
+
+	// an ACK sends nothing, but a change to the names
+	// of interest sends the clusters now of interest.
+	reqs <- &v2.DiscoveryRequest{TypeUrl: cache.ClusterType, ResourceNames: []string{"a"}, VersionInfo: "1"}
+	reqs <- &v2.DiscoveryRequest{TypeUrl: cache.ClusterType, ResourceNames: []string{"b", "a"}, VersionInfo: "1"}
+	check(cache.ClusterType, 2)
+
+	// an update to the routes is sent.
+	rc.Update(map[string]*v2.RouteConfiguration{
+		"ingress_http": {Name: "ingress_http"},
+	})
+	check(cache.RouteType, 1)
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected %v, got: %v", context.Canceled, err)
+	}
+}
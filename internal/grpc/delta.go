@@ -0,0 +1,301 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/sirupsen/logrus"
+)
+
+type deltaStream interface {
+	Context() context.Context
+	Send(*v2.DeltaDiscoveryResponse) error
+	Recv() (*v2.DeltaDiscoveryRequest, error)
+}
+
+// deltaState is the state of a single incremental xDS stream.
+type deltaState struct {
+	// wildcard is true if the client subscribed to every
+	// resource by not naming any in its first request.
+	wildcard bool
+
+	// subscribed holds the names of the resources the client
+	// subscribed to. Unused if wildcard is set.
+	subscribed map[string]bool
+
+	// known holds the version of each resource the client has.
+	known map[string]string
+
+	// nonce is the nonce of the last response.
+	nonce int
+
+	// sent holds the system version of each response which
+	// has not been acknowledged, keyed by nonce.
+	sent map[string]string
+
+	// info identifies the stream to the ResponseHandler.
+	info StreamInfo
+}
+
+// update applies the subscription changes of req to the state.
+func (ds *deltaState) update(req *v2.DeltaDiscoveryRequest) {
+	for _, name := range req.ResourceNamesSubscribe {
+		ds.subscribed[name] = true
+	}
+	for _, name := range req.ResourceNamesUnsubscribe {
+		delete(ds.subscribed, name)
+		delete(ds.known, name)
+	}
+}
+
+// hints returns the names of the resources the client subscribed
+// to, or nil if it subscribed to every resource.
+func (ds *deltaState) hints() []string {
+	if ds.wildcard {
+		return nil
+	}
+	var names []string
+	for name := range ds.subscribed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// interested returns true if the client subscribed to the named resource.
+func (ds *deltaState) interested(name string) bool {
+	return ds.wildcard || ds.subscribed[name]
+}
+
+// changes returns the names of the resources which the client is
+// interested in and whose versions differ from those it has, and
+// the names of the resources which the client has but no longer exist.
+func (ds *deltaState) changes(versions map[string]string) (changed, removed []string) {
+	for name, version := range versions {
+		if ds.interested(name) && ds.known[name] != version {
+			changed = append(changed, name)
+		}
+	}
+	for name := range ds.known {
+		if _, ok := versions[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// delta processes a stream of DeltaDiscoveryRequests. Unlike stream,
+// which sends every resource whenever any of them change, delta sends
+// only the resources which were added or changed, and the names of
+// those which were removed, since the last response.
+func (xh *xdsHandler) delta(st deltaStream) (err error) {
+	// bump connection counter and set it as a field on the logger
+	connection := xh.connections.next()
+	log := xh.WithField("connection", connection).WithField("delta", true)
+
+	var ds *deltaState
+	defer func() {
+		if ds != nil {
+			xh.closed(ds.info)
+		}
+		if err != nil {
+			log.WithError(err).Error("stream terminated")
+		} else {
+			log.Info("stream terminated")
+		}
+	}()
+
+	ctx := st.Context()
+	reqs := make(chan *v2.DeltaDiscoveryRequest)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			req, err := st.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case reqs <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var r Resource
+	ch := make(chan int, 1)
+	last := -1
+	for {
+		select {
+		case req := <-reqs:
+			rlog := log.WithField("type_url", req.TypeUrl).WithField("response_nonce", req.ResponseNonce).WithField("error_detail", req.ErrorDetail)
+			if ds == nil {
+				// the first request fixes the type of the stream
+				// and tells us which resources the client has.
+				var ok bool
+				r, ok = xh.resources[req.TypeUrl]
+				if !ok {
+					return fmt.Errorf("no resource registered for typeURL %q", req.TypeUrl)
+				}
+				ds = &deltaState{
+					wildcard:   len(req.ResourceNamesSubscribe) == 0,
+					subscribed: make(map[string]bool),
+					known:      make(map[string]string),
+					sent:       make(map[string]string),
+					info: StreamInfo{
+						Connection: connection,
+						Node:       req.Node,
+						TypeURL:    req.TypeUrl,
+					},
+				}
+				for name, version := range req.InitialResourceVersions {
+					ds.known[name] = version
+				}
+				ds.update(req)
+				log = log.WithField("type_url", req.TypeUrl)
+				rlog.WithField("resource_names", req.ResourceNamesSubscribe).Info("stream_wait")
+				r.Register(ch, last, ds.hints()...)
+				continue
+			}
+			if req.TypeUrl != r.TypeURL() {
+				return fmt.Errorf("typeURL %q does not match stream typeURL %q", req.TypeUrl, r.TypeURL())
+			}
+			if req.ErrorDetail != nil {
+				rlog.Warn("resources rejected")
+			}
+			version := ds.sent[req.ResponseNonce]
+			delete(ds.sent, req.ResponseNonce)
+			xh.acknowledgedNonce(ds.info, version, req.ResponseNonce, req.ErrorDetail.GetMessage(), req.ErrorDetail != nil)
+			if len(req.ResourceNamesSubscribe) == 0 && len(req.ResourceNamesUnsubscribe) == 0 {
+				// an ACK or NACK of the last response.
+				continue
+			}
+			ds.update(req)
+			if err := xh.sendDelta(rlog, st, ds, r, false); err != nil {
+				return err
+			}
+			// replace the hints of the outstanding registration.
+			r.Register(ch, last, ds.hints()...)
+		case last = <-ch:
+			// the first response is sent even if empty, the
+			// client waits for it to complete its initialisation.
+			if err := xh.sendDelta(log, st, ds, r, ds.nonce == 0); err != nil {
+				return err
+			}
+			r.Register(ch, last, ds.hints()...)
+		case err := <-errs:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sendDelta sends the changes between the contents of r and the
+// resources the client has in ds. If there are no changes, nothing
+// is sent unless force is set.
+func (xh *xdsHandler) sendDelta(log logrus.FieldLogger, st deltaStream, ds *deltaState, r Resource, force bool) error {
+	versions := r.Versions(ds.info.Node)
+	changed, removed := ds.changes(versions)
+	if len(changed) == 0 && len(removed) == 0 && !force {
+		return nil
+	}
+
+	var resources []v2.Resource
+	for _, m := range r.Query(ds.info.Node, changed) {
+		name, err := resourceName(m)
+		if err != nil {
+			return err
+		}
+		v, err := proto.Marshal(m)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, v2.Resource{
+			Name:     name,
+			Version:  versions[name],
+			Resource: &types.Any{TypeUrl: r.TypeURL(), Value: v},
+		})
+		ds.known[name] = versions[name]
+	}
+	for _, name := range removed {
+		delete(ds.known, name)
+	}
+
+	version := systemVersion(ds.known)
+	ds.nonce++
+	resp := &v2.DeltaDiscoveryResponse{
+		SystemVersionInfo: version,
+		Resources:         resources,
+		RemovedResources:  removed,
+		Nonce:             strconv.Itoa(ds.nonce),
+	}
+	if err := st.Send(resp); err != nil {
+		return err
+	}
+	ds.sent[resp.Nonce] = version
+	xh.sent(ds.info, version, resp.Nonce)
+	log.WithField("count", len(resources)).WithField("removed", len(removed)).Info("response")
+	return nil
+}
+
+// systemVersion returns the version of the resources the client
+// has, derived from the name and version of each.
+func systemVersion(known map[string]string) string {
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		_, _ = fmt.Fprintf(h, "%s=%s\n", name, known[name])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+// resourceName returns the name of an xDS resource, or an error
+// if m is not of a type served incrementally.
+func resourceName(m proto.Message) (string, error) {
+	switch m := m.(type) {
+	case *v2.Cluster:
+		return m.Name, nil
+	case *v2.ClusterLoadAssignment:
+		return m.ClusterName, nil
+	case *v2.Listener:
+		return m.Name, nil
+	case *v2.RouteConfiguration:
+		return m.Name, nil
+	case *auth.Secret:
+		return m.Name, nil
+	default:
+		return "", fmt.Errorf("unsupported resource type: %T", m)
+	}
+}
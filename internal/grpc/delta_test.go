@@ -0,0 +1,228 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/gogo/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/heptio/contour/internal/contour"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDeltaStateChanges(t *testing.T) {
+	tests := map[string]struct {
+		ds          deltaState
+		versions    map[string]string
+		wantChanged []string
+		wantRemoved []string
+	}{
+		"wildcard, nothing known": {
+			ds:          deltaState{wildcard: true},
+			versions:    map[string]string{"b": "1", "a": "1"},
+			wantChanged: []string{"a", "b"},
+		},
+		"wildcard, some known": {
+			ds: deltaState{
+				wildcard: true,
+				known:    map[string]string{"a": "1", "b": "1", "c": "1"},
+			},
+			versions:    map[string]string{"a": "1", "b": "2", "d": "1"},
+			wantChanged: []string{"b", "d"},
+			wantRemoved: []string{"c"},
+		},
+		"subscribed": {
+			ds: deltaState{
+				subscribed: map[string]bool{"a": true, "c": true},
+				known:      map[string]string{"c": "1"},
+			},
+			versions:    map[string]string{"a": "1", "b": "1"},
+			wantChanged: []string{"a"},
+			wantRemoved: []string{"c"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			changed, removed := tc.ds.changes(tc.versions)
+			if diff := cmp.Diff(tc.wantChanged, changed); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.wantRemoved, removed); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestXDSHandlerDelta(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	var cc contour.ClusterCache
+	xh := xdsHandler{
+		FieldLogger: log,
+		resources: map[string]Resource{
+			cc.TypeURL(): &cc,
+		},
+	}
+
+	cluster := func(name, servicename string) *v2.Cluster {
+		return &v2.Cluster{
+			Name: name,
+			EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+				ServiceName: servicename,
+			},
+		}
+	}
+	cc.Update(map[string]*v2.Cluster{
+		"a": cluster("a", "default/a"),
+		"b": cluster("b", "default/b"),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st := &mockDeltaStream{
+		ctx:   ctx,
+		reqs:  make(chan *v2.DeltaDiscoveryRequest, 1),
+		resps: make(chan *v2.DeltaDiscoveryResponse, 1),
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- xh.delta(st)
+	}()
+
+	recv := func() (names, removed []string) {
+		t.Helper()
+		select {
+		case resp := <-st.resps:
+			for _, r := range resp.Resources {
+				names = append(names, r.Name)
+			}
+			return names, resp.RemovedResources
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for response")
+			return nil, nil
+		}
+	}
+	check := func(wantNames, wantRemoved []string) {
+		t.Helper()
+		names, removed := recv()
+		if diff := cmp.Diff(wantNames, names); diff != "" {
+			t.Fatal(diff)
+		}
+		if diff := cmp.Diff(wantRemoved, removed); diff != "" {
+			t.Fatal(diff)
+		}
+	}
+
+	// subscribe to every cluster, all are sent.
+	st.reqs <- &v2.DeltaDiscoveryRequest{TypeUrl: cache.ClusterType}
+	check([]string{"a", "b"}, nil)
+
+	// change b, add c, and remove a.
+	cc.Update(map[string]*v2.Cluster{
+		"b": cluster("b", "default/b/http"),
+		"c": cluster("c", "default/c"),
+	})
+	check([]string{"b", "c"}, []string{"a"})
+
+	// an update which changes nothing sends nothing, so the next
+	// response only removes b.
+	cc.Update(map[string]*v2.Cluster{
+		"b": cluster("b", "default/b/http"),
+		"c": cluster("c", "default/c"),
+	})
+	cc.Update(map[string]*v2.Cluster{
+		"c": cluster("c", "default/c"),
+	})
+	check(nil, []string{"b"})
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected %v, got: %v", context.Canceled, err)
+	}
+}
+
+func TestXDSHandlerDeltaUnsupportedType(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	xh := xdsHandler{
+		FieldLogger: log,
+		resources: map[string]Resource{
+			cache.ClusterType: &mockResource{
+				query: func([]string) []proto.Message {
+					// not a resource type served incrementally.
+					return []proto.Message{new(v2.DiscoveryRequest)}
+				},
+				register: func(ch chan int, last int) {
+					select {
+					case ch <- last + 1:
+					default:
+					}
+				},
+				versions: func() map[string]string { return map[string]string{"a": "1"} },
+				typeurl:  func() string { return cache.ClusterType },
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st := &mockDeltaStream{
+		ctx:   ctx,
+		reqs:  make(chan *v2.DeltaDiscoveryRequest, 1),
+		resps: make(chan *v2.DeltaDiscoveryResponse, 1),
+	}
+	st.reqs <- &v2.DeltaDiscoveryRequest{TypeUrl: cache.ClusterType}
+
+	// the stream is closed with an error, the server carries on.
+	want := "unsupported resource type: *v2.DiscoveryRequest"
+	if err := xh.delta(st); err == nil || err.Error() != want {
+		t.Fatalf("expected error %q, got: %v", want, err)
+	}
+}
+
+type mockDeltaStream struct {
+	ctx   context.Context
+	reqs  chan *v2.DeltaDiscoveryRequest
+	resps chan *v2.DeltaDiscoveryResponse
+}
+
+func (m *mockDeltaStream) Context() context.Context { return m.ctx }
+
+func (m *mockDeltaStream) Send(resp *v2.DeltaDiscoveryResponse) error {
+	select {
+	case m.resps <- resp:
+		return nil
+	case <-m.ctx.Done():
+		return m.ctx.Err()
+	}
+}
+
+func (m *mockDeltaStream) Recv() (*v2.DeltaDiscoveryRequest, error) {
+	select {
+	case req := <-m.reqs:
+		return req, nil
+	case <-m.ctx.Done():
+		return nil, m.ctx.Err()
+	}
+}
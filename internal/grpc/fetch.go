@@ -0,0 +1,129 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errNotModified is returned by fetch when the client already
+// has the version of the resources it asked for.
+var errNotModified = errors.New("not modified")
+
+// fetch returns a DiscoveryResponse holding the resources of typeURL
+// named by req, or all of them if req names none. If req.VersionInfo
+// is the version of the response, fetch returns errNotModified.
+func (xh *xdsHandler) fetch(typeURL string, req *v2.DiscoveryRequest) (*v2.DiscoveryResponse, error) {
+	if req.TypeUrl != "" && req.TypeUrl != typeURL {
+		return nil, fmt.Errorf("typeURL %q does not match service typeURL %q", req.TypeUrl, typeURL)
+	}
+	r, ok := xh.resources[typeURL]
+	if !ok {
+		return nil, fmt.Errorf("no resource registered for typeURL %q", typeURL)
+	}
+	resp, err := discoveryResponse(r, req.Node, req.ResourceNames)
+	if err != nil {
+		return nil, err
+	}
+	log := xh.WithField("type_url", typeURL).WithField("resource_names", req.ResourceNames).WithField("version_info", resp.VersionInfo)
+	if req.VersionInfo == resp.VersionInfo {
+		log.Info("fetch not modified")
+		return nil, errNotModified
+	}
+	log.WithField("count", len(resp.Resources)).Info("fetch")
+	return resp, nil
+}
+
+// fetchStatus is fetch for the unary gRPC endpoints. If the client
+// is up to date, fetchStatus returns an AlreadyExists status.
+func (xh *xdsHandler) fetchStatus(typeURL string, req *v2.DiscoveryRequest) (*v2.DiscoveryResponse, error) {
+	resp, err := xh.fetch(typeURL, req)
+	if err == errNotModified {
+		return nil, status.Errorf(codes.AlreadyExists, "version %q of %s is up to date", req.VersionInfo, typeURL)
+	}
+	return resp, err
+}
+
+// restTypes maps the paths of the REST xDS API to their types.
+// Secrets are deliberately absent; their private keys are only
+// served over the gRPC API.
+var restTypes = map[string]string{
+	"/v2/discovery:clusters":  cache.ClusterType,
+	"/v2/discovery:endpoints": cache.EndpointType,
+	"/v2/discovery:listeners": cache.ListenerType,
+	"/v2/discovery:routes":    cache.RouteType,
+}
+
+// NewRESTHandler returns an http.Handler which serves the REST variant
+// of the Envoy v2 xDS API from resources. Envoys configured to poll
+// for resources POST a JSON DiscoveryRequest to /v2/discovery:clusters,
+// /v2/discovery:routes, and so on. If the client is up to date, the
+// handler responds 304 Not Modified. Secrets are not served.
+func NewRESTHandler(log logrus.FieldLogger, resources map[string]Resource) http.Handler {
+	return &restHandler{
+		xdsHandler: xdsHandler{
+			FieldLogger: log,
+			resources:   resources,
+		},
+	}
+}
+
+type restHandler struct {
+	xdsHandler
+}
+
+func (h *restHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	typeURL, ok := restTypes[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req v2.DiscoveryRequest
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := u.Unmarshal(r.Body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.fetch(typeURL, &req)
+	switch err {
+	case nil:
+	case errNotModified:
+		w.WriteHeader(http.StatusNotModified)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	var m jsonpb.Marshaler
+	if err := m.Marshal(w, resp); err != nil {
+		h.WithError(err).Error("failed to write response")
+	}
+}
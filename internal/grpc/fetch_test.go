@@ -0,0 +1,209 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/heptio/contour/internal/contour"
+	"github.com/sirupsen/logrus"
+)
+
+func TestXDSHandlerFetch(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	var cc contour.ClusterCache
+	cc.Update(map[string]*v2.Cluster{
+		"a": {Name: "a"},
+		"b": {Name: "b"},
+	})
+	xh := xdsHandler{
+		FieldLogger: log,
+		resources: map[string]Resource{
+			cc.TypeURL(): &cc,
+		},
+	}
+	version := func(values ...proto.Message) string {
+		resources, err := toAny(cache.ClusterType, values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return responseVersion(resources)
+	}
+	all := version(&v2.Cluster{Name: "a"}, &v2.Cluster{Name: "b"})
+
+	tests := map[string]struct {
+		typeURL     string
+		req         *v2.DiscoveryRequest
+		wantVersion string
+		wantCount   int
+		wantErr     error
+	}{
+		"all": {
+			typeURL:     cache.ClusterType,
+			req:         &v2.DiscoveryRequest{},
+			wantVersion: all,
+			wantCount:   2,
+		},
+		"named": {
+			typeURL: cache.ClusterType,
+			req: &v2.DiscoveryRequest{
+				TypeUrl:       cache.ClusterType,
+				ResourceNames: []string{"b"},
+			},
+			wantVersion: version(&v2.Cluster{Name: "b"}),
+			wantCount:   1,
+		},
+		"out of date": {
+			typeURL: cache.ClusterType,
+			req: &v2.DiscoveryRequest{
+				VersionInfo:   all,
+				ResourceNames: []string{"a"},
+			},
+			wantVersion: version(&v2.Cluster{Name: "a"}),
+			wantCount:   1,
+		},
+		"up to date": {
+			typeURL: cache.ClusterType,
+			req: &v2.DiscoveryRequest{
+				VersionInfo: all,
+			},
+			wantErr: errNotModified,
+		},
+		"typeURL does not match": {
+			typeURL: cache.ClusterType,
+			req: &v2.DiscoveryRequest{
+				TypeUrl: cache.RouteType,
+			},
+			wantErr: fmt.Errorf("typeURL %q does not match service typeURL %q", cache.RouteType, cache.ClusterType),
+		},
+		"no registered typeURL": {
+			typeURL: cache.SecretType,
+			req:     &v2.DiscoveryRequest{},
+			wantErr: fmt.Errorf("no resource registered for typeURL %q", cache.SecretType),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, err := xh.fetch(tc.typeURL, tc.req)
+			if !equalError(tc.wantErr, err) {
+				t.Fatalf("expected: %v, got: %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if resp.VersionInfo != tc.wantVersion || len(resp.Resources) != tc.wantCount {
+				t.Fatalf("expected version %q with %d resources, got version %q with %d resources", tc.wantVersion, tc.wantCount, resp.VersionInfo, len(resp.Resources))
+			}
+			if resp.Nonce != "" {
+				t.Fatalf("expected no nonce, got %q", resp.Nonce)
+			}
+		})
+	}
+}
+
+func TestRESTHandler(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	var cc contour.ClusterCache
+	cc.Update(map[string]*v2.Cluster{
+		"a": {Name: "a"},
+	})
+	var sc contour.SecretCache
+	h := NewRESTHandler(log, map[string]Resource{
+		cc.TypeURL(): &cc,
+		sc.TypeURL(): &sc,
+	})
+	resp, err := discoveryResponse(&cc, nil, nil)
+	check(t, err)
+
+	tests := map[string]struct {
+		method   string
+		path     string
+		body     string
+		wantCode int
+	}{
+		"fetch": {
+			method:   http.MethodPost,
+			path:     "/v2/discovery:clusters",
+			body:     `{"node": {"id": "envoy"}}`,
+			wantCode: http.StatusOK,
+		},
+		"not modified": {
+			method:   http.MethodPost,
+			path:     "/v2/discovery:clusters",
+			body:     `{"version_info": "` + resp.VersionInfo + `"}`,
+			wantCode: http.StatusNotModified,
+		},
+		"no registered typeURL": {
+			method:   http.MethodPost,
+			path:     "/v2/discovery:routes",
+			body:     `{}`,
+			wantCode: http.StatusInternalServerError,
+		},
+		"secrets are not served": {
+			method:   http.MethodPost,
+			path:     "/v2/discovery:secrets",
+			body:     `{}`,
+			wantCode: http.StatusNotFound,
+		},
+		"invalid request": {
+			method:   http.MethodPost,
+			path:     "/v2/discovery:clusters",
+			body:     `{`,
+			wantCode: http.StatusBadRequest,
+		},
+		"not a post": {
+			method:   http.MethodGet,
+			path:     "/v2/discovery:clusters",
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		"unknown type": {
+			method:   http.MethodPost,
+			path:     "/v2/discovery:potatoes",
+			body:     `{}`,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			if rec.Code != tc.wantCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.wantCode, rec.Code, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var got v2.DiscoveryResponse
+			check(t, jsonpb.Unmarshal(rec.Body, &got))
+			if got.VersionInfo != resp.VersionInfo || len(got.Resources) != 1 {
+				t.Fatalf("expected version %q with 1 resource, got version %q with %d resources", resp.VersionInfo, got.VersionInfo, len(got.Resources))
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	loadstats "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v2"
+	"github.com/gogo/protobuf/types"
+	"github.com/sirupsen/logrus"
+)
+
+// loadReportInterval is the interval at which Envoy is asked
+// to send load reports.
+const loadReportInterval = 10 * time.Second
+
+// LoadReportHandler receives the load reports Envoy sends
+// to the load reporting service.
+type LoadReportHandler interface {
+	// LoadReport is called with the cluster stats of each
+	// load report sent by node.
+	LoadReport(node *core.Node, stats []*endpoint.ClusterStats)
+}
+
+// loadStatsServer implements the Envoy v2 load reporting service.
+type loadStatsServer struct {
+	logrus.FieldLogger
+	connections counter
+	clusters    Resource // the CDS resource
+	handler     LoadReportHandler
+}
+
+// StreamLoadStats asks Envoy to report the load of every cluster
+// which has a load reporting server configured, and passes the
+// reports it receives to the handler. The set of clusters is sent
+// again whenever it changes.
+func (s *loadStatsServer) StreamLoadStats(st loadstats.LoadReportingService_StreamLoadStatsServer) (err error) {
+	log := s.WithField("connection", s.connections.next())
+
+	defer func() {
+		if err != nil {
+			log.WithError(err).Error("load report stream terminated")
+		} else {
+			log.Info("load report stream terminated")
+		}
+	}()
+
+	ctx := st.Context()
+	reqs := make(chan *loadstats.LoadStatsRequest)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			req, err := st.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case reqs <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ch := make(chan int, 1)
+	last := -1
+	var node *core.Node
+	var sent []string
+	started := false
+	for {
+		select {
+		case req := <-reqs:
+			if node == nil {
+				// the first request identifies the node and starts
+				// the stream, respond with the clusters to report.
+				node = req.GetNode()
+				log = log.WithField("node", node.GetId())
+				log.Info("load report stream started")
+				s.clusters.Register(ch, last)
+			}
+			if len(req.ClusterStats) > 0 {
+				s.handler.LoadReport(node, req.ClusterStats)
+			}
+		case last = <-ch:
+			clusters := loadReportingClusters(s.clusters)
+			if !started || !reflect.DeepEqual(clusters, sent) {
+				resp := &loadstats.LoadStatsResponse{
+					Clusters:              clusters,
+					LoadReportingInterval: types.DurationProto(loadReportInterval),
+				}
+				if err := st.Send(resp); err != nil {
+					return err
+				}
+				log.WithField("clusters", clusters).Info("response")
+				started, sent = true, clusters
+			}
+			s.clusters.Register(ch, last)
+		case err := <-errs:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// loadReportingClusters returns the names of the clusters in r
+// which have a load reporting server configured.
+func loadReportingClusters(r Resource) []string {
+	var names []string
+	for _, m := range r.Contents(nil) {
+		if c, ok := m.(*v2.Cluster); ok && c.LrsServer != nil {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,79 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+)
+
+// StreamInfo identifies a single type of an xDS stream.
+type StreamInfo struct {
+	// Connection is the number of the stream.
+	Connection uint64
+
+	// Node is the client, as identified by the first
+	// request of the stream.
+	Node *core.Node
+
+	// TypeURL is the type of the resources of the stream.
+	TypeURL string
+}
+
+// ResponseHandler is told of the responses sent on each xDS stream,
+// and whether the client accepted or rejected them.
+type ResponseHandler interface {
+	// Sent is called after a response is sent.
+	Sent(s StreamInfo, version, nonce string)
+
+	// Accepted is called when the client accepts the response
+	// with nonce. version is the version the client now has.
+	Accepted(s StreamInfo, version, nonce string)
+
+	// Rejected is called when the client rejects the response
+	// with nonce, with the error the client reported.
+	Rejected(s StreamInfo, nonce, message string)
+
+	// Closed is called when the stream terminates.
+	Closed(s StreamInfo)
+}
+
+func (xh *xdsHandler) sent(s StreamInfo, version, nonce string) {
+	if xh.responses != nil {
+		xh.responses.Sent(s, version, nonce)
+	}
+}
+
+// acknowledged tells the ResponseHandler whether req accepted
+// or rejected the response it refers to, if any.
+func (xh *xdsHandler) acknowledged(s StreamInfo, req *v2.DiscoveryRequest) {
+	xh.acknowledgedNonce(s, req.VersionInfo, req.ResponseNonce, req.ErrorDetail.GetMessage(), req.ErrorDetail != nil)
+}
+
+func (xh *xdsHandler) acknowledgedNonce(s StreamInfo, version, nonce, message string, rejected bool) {
+	if xh.responses == nil || nonce == "" {
+		return
+	}
+	if rejected {
+		xh.responses.Rejected(s, nonce, message)
+		return
+	}
+	xh.responses.Accepted(s, version, nonce)
+}
+
+func (xh *xdsHandler) closed(s StreamInfo) {
+	if xh.responses != nil {
+		xh.responses.Closed(s)
+	}
+}
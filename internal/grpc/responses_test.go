@@ -0,0 +1,125 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/gogo/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/heptio/contour/internal/contour"
+	"github.com/sirupsen/logrus"
+)
+
+func TestXDSHandlerResponses(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	var cc contour.ClusterCache
+	events := make(chan string, 1)
+	xh := xdsHandler{
+		FieldLogger: log,
+		resources: map[string]Resource{
+			cc.TypeURL(): &cc,
+		},
+		responses: &responseRecorder{events: events},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reqs := make(chan *v2.DiscoveryRequest, 1)
+	st := &mockStream{
+		context: func() context.Context { return ctx },
+		send:    func(*v2.DiscoveryResponse) error { return nil },
+		recv: func() (*v2.DiscoveryRequest, error) {
+			select {
+			case req := <-reqs:
+				return req, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- xh.stream(st)
+	}()
+
+	check := func(want string) {
+		t.Helper()
+		select {
+		case got := <-events:
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for " + want)
+		}
+	}
+
+	// the version of an empty response.
+	const empty = "e3b0c44298fc1c14"
+
+	node := &core.Node{Id: "envoy"}
+	reqs <- &v2.DiscoveryRequest{Node: node, TypeUrl: cache.ClusterType}
+	check("sent envoy 1 version " + empty + " nonce 1")
+
+	reqs <- &v2.DiscoveryRequest{TypeUrl: cache.ClusterType, VersionInfo: empty, ResponseNonce: "1"}
+	check("accepted envoy 1 version " + empty + " nonce 1")
+
+	cc.Update(map[string]*v2.Cluster{
+		"a": {Name: "a"},
+	})
+	resources, err := toAny(cache.ClusterType, []proto.Message{&v2.Cluster{Name: "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("sent envoy 1 version " + responseVersion(resources) + " nonce 2")
+
+	// a NACK carries the error and the version the client
+	// still has.
+	xh.acknowledgedNonce(StreamInfo{Node: node, Connection: 1, TypeURL: cache.ClusterType}, empty, "2", "invalid cluster a", true)
+	check("rejected envoy 1 nonce 2: invalid cluster a")
+
+	cancel()
+	check("closed envoy 1")
+	<-done
+}
+
+type responseRecorder struct {
+	events chan string
+}
+
+func (r *responseRecorder) Sent(s StreamInfo, version, nonce string) {
+	r.events <- fmt.Sprintf("sent %s %d version %s nonce %s", s.Node.GetId(), s.Connection, version, nonce)
+}
+
+func (r *responseRecorder) Accepted(s StreamInfo, version, nonce string) {
+	r.events <- fmt.Sprintf("accepted %s %d version %s nonce %s", s.Node.GetId(), s.Connection, version, nonce)
+}
+
+func (r *responseRecorder) Rejected(s StreamInfo, nonce, message string) {
+	r.events <- fmt.Sprintf("rejected %s %d nonce %s: %s", s.Node.GetId(), s.Connection, nonce, message)
+}
+
+func (r *responseRecorder) Closed(s StreamInfo) {
+	r.events <- fmt.Sprintf("closed %s %d", s.Node.GetId(), s.Connection)
+}
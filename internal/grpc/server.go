@@ -20,8 +20,10 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v2"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	loadstats "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/sirupsen/logrus"
 )
 
@@ -31,7 +33,15 @@ const (
 )
 
 // NewAPI returns a *grpc.Server which responds to the Envoy v2 xDS gRPC API.
-func NewAPI(log logrus.FieldLogger, resources map[string]Resource) *grpc.Server {
+// If alh is not nil, the server also provides the Envoy v2 access log
+// service, passing the entries it receives to alh.
+// If lrh is not nil, the server also provides the Envoy v2 load reporting
+// service, passing the load reports it receives to lrh.
+// If rh is not nil, it is told of every xDS response and whether
+// Envoy accepted or rejected it.
+// Additional options, such as the transport credentials of the
+// server, are passed to grpc.NewServer.
+func NewAPI(log logrus.FieldLogger, resources map[string]Resource, alh AccessLogHandler, lrh LoadReportHandler, rh ResponseHandler, options ...grpc.ServerOption) *grpc.Server {
 	opts := []grpc.ServerOption{
 		// By default the Go grpc library defaults to a value of ~100 streams per
 		// connection. This number is likely derived from the HTTP/2 spec:
@@ -41,11 +51,12 @@ func NewAPI(log logrus.FieldLogger, resources map[string]Resource) *grpc.Server
 		// so set it the limit similar to envoyproxy/go-control-plane#70.
 		grpc.MaxConcurrentStreams(grpcMaxConcurrentStreams),
 	}
-	g := grpc.NewServer(opts...)
+	g := grpc.NewServer(append(opts, options...)...)
 	s := &grpcServer{
 		xdsHandler{
 			FieldLogger: log,
 			resources:   resources,
+			responses:   rh,
 		},
 	}
 
@@ -54,32 +65,51 @@ func NewAPI(log logrus.FieldLogger, resources map[string]Resource) *grpc.Server
 	v2.RegisterListenerDiscoveryServiceServer(g, s)
 	v2.RegisterRouteDiscoveryServiceServer(g, s)
 	discovery.RegisterSecretDiscoveryServiceServer(g, s)
+	discovery.RegisterAggregatedDiscoveryServiceServer(g, s)
+	if alh != nil {
+		accesslog.RegisterAccessLogServiceServer(g, &accessLogServer{
+			FieldLogger: log,
+			handler:     alh,
+		})
+	}
+	if lrh != nil {
+		loadstats.RegisterLoadReportingServiceServer(g, &loadStatsServer{
+			FieldLogger: log,
+			clusters:    resources[cache.ClusterType],
+			handler:     lrh,
+		})
+	}
 	return g
 }
 
-// grpcServer implements the LDS, RDS, CDS, and EDS, gRPC endpoints.
+// grpcServer implements the LDS, RDS, CDS, EDS, SDS, and ADS gRPC endpoints.
+// The resources of LDS, RDS, CDS, EDS, and SDS may also be fetched once
+// rather than streamed, and those of CDS and RDS may be streamed
+// incrementally. The EDS, LDS, and SDS services of go-control-plane
+// v0.8.0 have no incremental variants, and its incremental ADS responses
+// do not identify their type, so cannot be multiplexed.
 type grpcServer struct {
 	xdsHandler
 }
 
 func (s *grpcServer) FetchClusters(_ context.Context, req *v2.DiscoveryRequest) (*v2.DiscoveryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "FetchClusters unimplemented")
+	return s.fetchStatus(cache.ClusterType, req)
 }
 
 func (s *grpcServer) FetchEndpoints(_ context.Context, req *v2.DiscoveryRequest) (*v2.DiscoveryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "FetchEndpoints unimplemented")
+	return s.fetchStatus(cache.EndpointType, req)
 }
 
 func (s *grpcServer) FetchListeners(_ context.Context, req *v2.DiscoveryRequest) (*v2.DiscoveryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "FetchListeners unimplemented")
+	return s.fetchStatus(cache.ListenerType, req)
 }
 
 func (s *grpcServer) FetchRoutes(_ context.Context, req *v2.DiscoveryRequest) (*v2.DiscoveryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "FetchRoutes unimplemented")
+	return s.fetchStatus(cache.RouteType, req)
 }
 
 func (s *grpcServer) FetchSecrets(_ context.Context, req *v2.DiscoveryRequest) (*v2.DiscoveryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "FetchSecrets unimplemented")
+	return s.fetchStatus(cache.SecretType, req)
 }
 
 func (s *grpcServer) StreamClusters(srv v2.ClusterDiscoveryService_StreamClustersServer) error {
@@ -90,16 +120,12 @@ func (s *grpcServer) StreamEndpoints(srv v2.EndpointDiscoveryService_StreamEndpo
 	return s.stream(srv)
 }
 
-func (s *grpcServer) StreamLoadStats(srv loadstats.LoadReportingService_StreamLoadStatsServer) error {
-	return status.Errorf(codes.Unimplemented, "StreamLoadStats unimplemented")
-}
-
-func (s *grpcServer) DeltaClusters(v2.ClusterDiscoveryService_DeltaClustersServer) error {
-	return status.Errorf(codes.Unimplemented, "IncrementalClusters unimplemented")
+func (s *grpcServer) DeltaClusters(srv v2.ClusterDiscoveryService_DeltaClustersServer) error {
+	return s.delta(srv)
 }
 
-func (s *grpcServer) DeltaRoutes(v2.RouteDiscoveryService_DeltaRoutesServer) error {
-	return status.Errorf(codes.Unimplemented, "IncrementalRoutes unimplemented")
+func (s *grpcServer) DeltaRoutes(srv v2.RouteDiscoveryService_DeltaRoutesServer) error {
+	return s.delta(srv)
 }
 
 func (s *grpcServer) StreamListeners(srv v2.ListenerDiscoveryService_StreamListenersServer) error {
@@ -113,3 +139,11 @@ func (s *grpcServer) StreamRoutes(srv v2.RouteDiscoveryService_StreamRoutesServe
 func (s *grpcServer) StreamSecrets(srv discovery.SecretDiscoveryService_StreamSecretsServer) error {
 	return s.stream(srv)
 }
+
+func (s *grpcServer) StreamAggregatedResources(srv discovery.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	return s.ads(srv)
+}
+
+func (s *grpcServer) DeltaAggregatedResources(srv discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	return status.Errorf(codes.Unimplemented, "DeltaAggregatedResources unimplemented, use DeltaClusters and DeltaRoutes")
+}
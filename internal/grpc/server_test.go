@@ -17,11 +17,18 @@ import (
 	"context"
 	"io/ioutil"
 	"net"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	accesslogdata "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v2"
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v2"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	loadstats "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/heptio/contour/internal/contour"
 	"github.com/heptio/contour/internal/metrics"
@@ -40,6 +47,8 @@ func TestGRPC(t *testing.T) {
 	// tr and et is recreated before the start of each test.
 	var et *contour.EndpointsTranslator
 	var reh *contour.ResourceEventHandler
+	var alh *accessLogRecorder
+	var lrh *loadReportRecorder
 
 	tests := map[string]func(*testing.T, *grpc.ClientConn){
 		"StreamClusters": func(t *testing.T, cc *grpc.ClientConn) {
@@ -69,6 +78,27 @@ func TestGRPC(t *testing.T) {
 			checkrecv(t, stream)                  // check we receive one notification
 			checktimeout(t, stream)               // check that the second receive times out
 		},
+		"FetchClusters": func(t *testing.T, cc *grpc.ClientConn) {
+			cds := v2.NewClusterDiscoveryServiceClient(cc)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			resp, err := cds.FetchClusters(ctx, &v2.DiscoveryRequest{
+				TypeUrl: cache.ClusterType,
+			})
+			check(t, err)
+			if resp.VersionInfo == "" {
+				t.Fatal("expected a version_info")
+			}
+
+			// fetching the version the client has is not modified.
+			_, err = cds.FetchClusters(ctx, &v2.DiscoveryRequest{
+				TypeUrl:     cache.ClusterType,
+				VersionInfo: resp.VersionInfo,
+			})
+			if got := status.Code(err); got != codes.AlreadyExists {
+				t.Fatalf("expected: %v, got: %v", codes.AlreadyExists, err)
+			}
+		},
 		"StreamEndpoints": func(t *testing.T, cc *grpc.ClientConn) {
 			et.OnAdd(&v1.Endpoints{
 				ObjectMeta: metav1.ObjectMeta{
@@ -182,6 +212,135 @@ func TestGRPC(t *testing.T) {
 			checkrecv(t, stream)                 // check we receive one notification
 			checktimeout(t, stream)              // check that the second receive times out
 		},
+		"StreamAggregatedResources": func(t *testing.T, cc *grpc.ClientConn) {
+			reh.OnAdd(&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "simple",
+					Namespace: "default",
+				},
+				Spec: v1.ServiceSpec{
+					Selector: map[string]string{
+						"app": "simple",
+					},
+					Ports: []v1.ServicePort{{
+						Protocol:   "TCP",
+						Port:       80,
+						TargetPort: intstr.FromInt(6502),
+					}},
+				},
+			})
+
+			ads := discovery.NewAggregatedDiscoveryServiceClient(cc)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stream, err := ads.StreamAggregatedResources(ctx)
+			check(t, err)
+			sendreq(t, stream, cache.ClusterType)  // send initial notification
+			checkrecv(t, stream)                   // check we receive one notification
+			sendreq(t, stream, cache.ListenerType) // request another type on the same stream
+			checkrecv(t, stream)                   // check we receive one notification
+			checktimeout(t, stream)                // check that the third receive times out
+		},
+		"StreamAccessLogs": func(t *testing.T, cc *grpc.ClientConn) {
+			als := accesslog.NewAccessLogServiceClient(cc)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stream, err := als.StreamAccessLogs(ctx)
+			check(t, err)
+			err = stream.Send(&accesslog.StreamAccessLogsMessage{
+				Identifier: &accesslog.StreamAccessLogsMessage_Identifier{
+					LogName: "ingress_http",
+				},
+				LogEntries: &accesslog.StreamAccessLogsMessage_HttpLogs{
+					HttpLogs: &accesslog.StreamAccessLogsMessage_HTTPAccessLogEntries{
+						LogEntry: []*accesslogdata.HTTPAccessLogEntry{{
+							Request: &accesslogdata.HTTPRequestProperties{
+								Authority: "httpbin.org",
+							},
+						}},
+					},
+				},
+			})
+			check(t, err)
+			// the identifier is only sent in the first message.
+			err = stream.Send(&accesslog.StreamAccessLogsMessage{
+				LogEntries: &accesslog.StreamAccessLogsMessage_TcpLogs{
+					TcpLogs: &accesslog.StreamAccessLogsMessage_TCPAccessLogEntries{
+						LogEntry: []*accesslogdata.TCPAccessLogEntry{{}},
+					},
+				},
+			})
+			check(t, err)
+			_, err = stream.CloseAndRecv()
+			check(t, err)
+
+			want := []string{"http ingress_http httpbin.org", "tcp ingress_http"}
+			if got := alh.summary(); !reflect.DeepEqual(want, got) {
+				t.Fatalf("expected: %q, got: %q", want, got)
+			}
+		},
+		"StreamLoadStats": func(t *testing.T, cc *grpc.ClientConn) {
+			reh.OnAdd(&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kuard",
+					Namespace: "default",
+					Annotations: map[string]string{
+						"contour.heptio.com/load-reporting": "true",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{{
+						Protocol:   "TCP",
+						Port:       80,
+						TargetPort: intstr.FromInt(8080),
+					}},
+				},
+			})
+			reh.OnAdd(&v1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kuard",
+					Namespace: "default",
+				},
+				Spec: v1beta1.IngressSpec{
+					Backend: &v1beta1.IngressBackend{
+						ServiceName: "kuard",
+						ServicePort: intstr.FromInt(80),
+					},
+				},
+			})
+
+			lrs := loadstats.NewLoadReportingServiceClient(cc)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stream, err := lrs.StreamLoadStats(ctx)
+			check(t, err)
+			err = stream.Send(&loadstats.LoadStatsRequest{
+				Node: &core.Node{Id: "envoy"},
+			})
+			check(t, err)
+			resp, err := stream.Recv()
+			check(t, err)
+			want := []string{"default/kuard/80/da39a3ee5e"}
+			if !reflect.DeepEqual(want, resp.Clusters) {
+				t.Fatalf("expected: %q, got: %q", want, resp.Clusters)
+			}
+
+			err = stream.Send(&loadstats.LoadStatsRequest{
+				Node: &core.Node{Id: "envoy"},
+				ClusterStats: []*endpoint.ClusterStats{{
+					ClusterName: "default/kuard/80/da39a3ee5e",
+				}},
+			})
+			check(t, err)
+			select {
+			case got := <-lrh.reports:
+				if want := "envoy default/kuard/80/da39a3ee5e"; got != want {
+					t.Fatalf("expected: %q, got: %q", want, got)
+				}
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for load report")
+			}
+		},
 	}
 
 	log := logrus.New()
@@ -191,6 +350,10 @@ func TestGRPC(t *testing.T) {
 			et = &contour.EndpointsTranslator{
 				FieldLogger: log,
 			}
+			alh = new(accessLogRecorder)
+			lrh = &loadReportRecorder{
+				reports: make(chan string, 1),
+			}
 			ch := contour.CacheHandler{
 				Metrics: metrics.NewMetrics(prometheus.NewRegistry()),
 			}
@@ -205,7 +368,7 @@ func TestGRPC(t *testing.T) {
 				ch.ListenerCache.TypeURL(): &ch.ListenerCache,
 				ch.SecretCache.TypeURL():   &ch.SecretCache,
 				et.TypeURL():               et,
-			})
+			}, alh, lrh, nil)
 			l, err := net.Listen("tcp", "127.0.0.1:0")
 			check(t, err)
 			done := make(chan error, 1)
@@ -268,3 +431,42 @@ func checktimeout(t *testing.T, stream interface {
 		t.Fatalf("expected %q, got %q %T %v", codes.DeadlineExceeded, s.Code(), err, err)
 	}
 }
+
+// accessLogRecorder records a summary of each access log entry it receives.
+type accessLogRecorder struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (r *accessLogRecorder) summary() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.entries
+}
+
+func (r *accessLogRecorder) HTTPAccessLogs(logName string, entries []*accesslogdata.HTTPAccessLogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range entries {
+		r.entries = append(r.entries, "http "+logName+" "+e.GetRequest().GetAuthority())
+	}
+}
+
+func (r *accessLogRecorder) TCPAccessLogs(logName string, entries []*accesslogdata.TCPAccessLogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for range entries {
+		r.entries = append(r.entries, "tcp "+logName)
+	}
+}
+
+// loadReportRecorder sends a summary of each cluster stats it receives.
+type loadReportRecorder struct {
+	reports chan string
+}
+
+func (r *loadReportRecorder) LoadReport(node *core.Node, stats []*endpoint.ClusterStats) {
+	for _, s := range stats {
+		r.reports <- node.GetId() + " " + s.ClusterName
+	}
+}
@@ -15,11 +15,14 @@ package grpc
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"reflect"
 	"strconv"
 	"sync/atomic"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	"github.com/sirupsen/logrus"
@@ -28,14 +31,23 @@ import (
 // Resource represents a source of proto.Messages that can be registered
 // for interest.
 type Resource interface {
-	// Contents returns the contents of this resource.
-	Contents() []proto.Message
+	// Contents returns the contents of this resource sent to node.
+	// If node is nil the contents are not restricted to any node.
+	Contents(node *core.Node) []proto.Message
 
-	// Query returns an entry for each resource name supplied.
-	Query(names []string) []proto.Message
+	// Query returns an entry for each resource name supplied,
+	// as sent to node.
+	Query(node *core.Node, names []string) []proto.Message
 
 	// Register registers ch to receive a value when Notify is called.
-	Register(chan int, int)
+	// If hints are supplied, ch is only notified when one of the named
+	// resources changes. Registering ch again replaces its hints.
+	Register(ch chan int, last int, hints ...string)
+
+	// Versions returns the version of each resource sent to node,
+	// keyed by name. A resource's version changes when its contents
+	// change. The returned map must not be modified.
+	Versions(node *core.Node) map[string]string
 
 	// TypeURL returns the typeURL of messages returned from Values.
 	TypeURL() string
@@ -46,6 +58,7 @@ type xdsHandler struct {
 	logrus.FieldLogger
 	connections counter
 	resources   map[string]Resource // registered resource types
+	responses   ResponseHandler     // optional
 }
 
 type grpcStream interface {
@@ -54,14 +67,31 @@ type grpcStream interface {
 	Recv() (*v2.DiscoveryRequest, error)
 }
 
-// stream processes a stream of DiscoveryRequests.
-func (xh *xdsHandler) stream(st grpcStream) (err error) {
+// stream processes a stream of DiscoveryRequests for a single type.
+func (xh *xdsHandler) stream(st grpcStream) error {
+	return xh.serve(st, false)
+}
+
+// serve processes a stream of DiscoveryRequests. If aggregated is
+// false, every request must be for the type of the first. Otherwise
+// every type the client requests is multiplexed onto the stream, and
+// the responses of each set of changes are sent in typeOrder.
+func (xh *xdsHandler) serve(st grpcStream, aggregated bool) (err error) {
 	// bump connection counter and set it as a field on the logger
-	log := xh.WithField("connection", xh.connections.next())
+	connection := xh.connections.next()
+	log := xh.WithField("connection", connection)
+	if aggregated {
+		log = log.WithField("ads", true)
+	}
+
+	watches := make(map[string]*watch)
 
 	// set up some nice function exit handling which notifies if the
 	// stream terminated on error or not.
 	defer func() {
+		for _, w := range watches {
+			xh.closed(w.info)
+		}
 		if err != nil {
 			log.WithError(err).Error("stream terminated")
 		} else {
@@ -69,81 +99,216 @@ func (xh *xdsHandler) stream(st grpcStream) (err error) {
 		}
 	}()
 
-	ch := make(chan int, 1)
+	ctx, cancel := context.WithCancel(st.Context())
+	defer cancel()
 
-	// internally all registration values start at zero so sending
-	// a last that is less than zero will guarantee that each stream
-	// will generate a response immediately, then wait.
-	last := -1
-	ctx := st.Context()
+	// requests are received in the background so that the
+	// acknowledgements of responses are seen as they arrive.
+	reqs := make(chan *v2.DiscoveryRequest)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			req, err := st.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case reqs <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
 
-	// now stick in this loop until the client disconnects.
-	for {
-		// first we wait for the request from Envoy, this is part of
-		// the xDS protocol.
-		req, err := st.Recv()
+	// each response of the stream has a nonce of its own, whereas
+	// its version is derived from the resources it holds.
+	nonce := 0
+	send := func(w *watch) error {
+		// the versions are read before the resources, if the
+		// resources change in between they are sent again.
+		versions := versions(w.r, w.info.Node, w.names)
+		resp, err := discoveryResponse(w.r, w.info.Node, w.names)
 		if err != nil {
 			return err
 		}
-
-		// from the request we derive the resource to stream which have
-		// been registered according to the typeURL.
-		r, ok := xh.resources[req.TypeUrl]
-		if !ok {
-			return fmt.Errorf("no resource registered for typeURL %q", req.TypeUrl)
+		nonce++
+		resp.Nonce = strconv.Itoa(nonce)
+		if err := st.Send(resp); err != nil {
+			return err
 		}
+		w.versions = versions
+		xh.sent(w.info, resp.VersionInfo, resp.Nonce)
+		log.WithField("type_url", resp.TypeUrl).WithField("version_info", resp.VersionInfo).WithField("nonce", resp.Nonce).WithField("count", len(resp.Resources)).Info("response")
+		return nil
+	}
 
-		// stick some debugging details on the logger, not that we redeclare log in this scope
-		// so the next time around the loop all is forgotten.
-		log := log.WithField("version_info", req.VersionInfo).WithField("resource_names", req.ResourceNames).WithField("type_url", req.TypeUrl).WithField("response_nonce", req.ResponseNonce).WithField("error_detail", req.ErrorDetail)
-
-		for {
-			log.Info("stream_wait")
+	var node *core.Node
+	wt := newWatcher(ctx)
+	for {
+		select {
+		case req := <-reqs:
+			// stick some debugging details on the logger, not that we redeclare log in this scope
+			// so the next time around the loop all is forgotten.
+			log := log.WithField("version_info", req.VersionInfo).WithField("resource_names", req.ResourceNames).WithField("type_url", req.TypeUrl).WithField("response_nonce", req.ResponseNonce).WithField("error_detail", req.ErrorDetail)
 
-			// now we wait for a notification, if this is the first time through the loop
-			// then last will be less than zero and that will trigger a notification immediately.
-			r.Register(ch, last)
-			select {
-			case last = <-ch:
-				// boom, something in the cache has changed.
-				// TODO(dfc) the thing that has changed may not be in the scope of the filter
-				// so we're going to be sending an update that is a no-op. See #426
-
-				var resources []proto.Message
-				switch len(req.ResourceNames) {
-				case 0:
-					// no resource hints supplied, return the full
-					// contents of the resource
-					resources = r.Contents()
-				default:
-					// resource hints supplied, return exactly those
-					resources = r.Query(req.ResourceNames)
-				}
+			if node == nil {
+				// only the first request of a stream
+				// need identify the client.
+				node = req.Node
+			}
 
-				any, err := toAny(r.TypeURL(), resources)
-				if err != nil {
-					return err
+			w, ok := watches[req.TypeUrl]
+			if !ok {
+				if !aggregated && len(watches) > 0 {
+					return fmt.Errorf("typeURL %q does not match stream typeURL %q", req.TypeUrl, typeURL(watches))
 				}
 
-				resp := &v2.DiscoveryResponse{
-					VersionInfo: strconv.Itoa(last),
-					Resources:   any,
-					TypeUrl:     r.TypeURL(),
-					Nonce:       strconv.Itoa(last),
+				// from the request we derive the resource to stream which have
+				// been registered according to the typeURL.
+				r, ok := xh.resources[req.TypeUrl]
+				if !ok {
+					return fmt.Errorf("no resource registered for typeURL %q", req.TypeUrl)
 				}
-				if err := st.Send(resp); err != nil {
-					return err
+				watches[req.TypeUrl] = &watch{
+					r:     r,
+					names: req.ResourceNames,
+					last:  -1,
+					info: StreamInfo{
+						Connection: connection,
+						Node:       node,
+						TypeURL:    req.TypeUrl,
+					},
 				}
-				log.WithField("count", len(resources)).Info("response")
 
-				// ok, the client hung up, return any error stored in the context and we're done.
-			case <-ctx.Done():
-				return ctx.Err()
+				log.Info("stream_wait")
+
+				// internally all registration values start at zero so
+				// registering a last that is less than zero will
+				// trigger a response immediately.
+				wt.register(r, -1, req.ResourceNames)
+				continue
+			}
+
+			if req.ErrorDetail != nil {
+				log.Warn("resources rejected")
+			}
+			xh.acknowledged(w.info, req)
+			if equalNames(w.names, req.ResourceNames) {
+				// an ACK or NACK of the last response.
+				continue
+			}
+
+			// the resources of interest have changed, the
+			// client waits for a response holding the new ones.
+			w.names = req.ResourceNames
+			if w.last < 0 {
+				// the first response has not been sent yet.
+				continue
+			}
+			if err := send(w); err != nil {
+				return err
+			}
+			// replace the hints of the outstanding registration
+			// so changes to the new resources wake the stream.
+			wt.register(w.r, w.last, w.names)
+		case n := <-wt.notify:
+			// boom, something the stream subscribed to has changed.
+			typeURLs, last := wt.pending(n)
+			for _, typeURL := range typeURLs {
+				w := watches[typeURL]
+				w.last = last[typeURL]
+				// don't send the client a no-op update if the
+				// resources of the last response are unchanged. See #426
+				if w.versions == nil || !reflect.DeepEqual(w.versions, versions(w.r, w.info.Node, w.names)) {
+					if err := send(w); err != nil {
+						return err
+					}
+				}
+				wt.register(w.r, w.last, w.names)
 			}
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			// ok, the client hung up, return any error stored in the context and we're done.
+			return ctx.Err()
 		}
 	}
 }
 
+// typeURL returns the type URL of the single watch in watches.
+func typeURL(watches map[string]*watch) string {
+	for typeURL := range watches {
+		return typeURL
+	}
+	return ""
+}
+
+// watch is the state of a single type of a stream.
+type watch struct {
+	r        Resource
+	names    []string          // the resource names of the last request
+	last     int               // the last notification of r, -1 if none
+	versions map[string]string // the versions of the resources of the last response
+	info     StreamInfo
+}
+
+// versions returns the versions of the resources of r sent to node
+// named by names, or of every resource of r sent to node if names is
+// empty. Names which r does not hold have an empty version.
+func versions(r Resource, node *core.Node, names []string) map[string]string {
+	all := r.Versions(node)
+	if len(names) == 0 {
+		return all
+	}
+	versions := make(map[string]string, len(names))
+	for _, name := range names {
+		versions[name] = all[name]
+	}
+	return versions
+}
+
+// discoveryResponse returns a DiscoveryResponse holding the named
+// resources of r sent to node. The response has no nonce.
+func discoveryResponse(r Resource, node *core.Node, names []string) (*v2.DiscoveryResponse, error) {
+	var resources []proto.Message
+	switch len(names) {
+	case 0:
+		// no resource hints supplied, return the full
+		// contents of the resource
+		resources = r.Contents(node)
+	default:
+		// resource hints supplied, return exactly those
+		resources = r.Query(node, names)
+	}
+
+	any, err := toAny(r.TypeURL(), resources)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v2.DiscoveryResponse{
+		VersionInfo: responseVersion(any),
+		Resources:   any,
+		TypeUrl:     r.TypeURL(),
+	}, nil
+}
+
+// responseVersion returns the version of a response holding resources.
+// The version is derived from the contents of the resources alone, so
+// equal responses have equal versions no matter which Contour sent them.
+// The text form of each resource is hashed, rather than its encoding,
+// as it prints map fields, and the contents of Anys, in a stable order.
+func responseVersion(resources []types.Any) string {
+	m := proto.TextMarshaler{Compact: true, ExpandAny: true}
+	h := sha256.New()
+	for i := range resources {
+		text := m.Text(&resources[i])
+		_, _ = fmt.Fprintf(h, "%d:%s", len(text), text)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
 // toAny converts the contents of a resourcer's Values to the
 // respective slice of types.Any.
 func toAny(typeURL string, values []proto.Message) ([]types.Any, error) {
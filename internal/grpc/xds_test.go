@@ -18,11 +18,21 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/gogo/protobuf/proto"
+	"github.com/heptio/contour/internal/contour"
+	"github.com/heptio/contour/internal/envoy"
 	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestXDSHandlerStream(t *testing.T) {
@@ -164,13 +174,81 @@ type mockResource struct {
 	contents func() []proto.Message
 	query    func([]string) []proto.Message
 	register func(chan int, int)
+	versions func() map[string]string
 	typeurl  func() string
 }
 
-func (m *mockResource) Contents() []proto.Message            { return m.contents() }
-func (m *mockResource) Query(names []string) []proto.Message { return m.query(names) }
-func (m *mockResource) Register(ch chan int, last int)       { m.register(ch, last) }
-func (m *mockResource) TypeURL() string                      { return m.typeurl() }
+func (m *mockResource) Contents(*core.Node) []proto.Message { return m.contents() }
+func (m *mockResource) Query(_ *core.Node, names []string) []proto.Message {
+	return m.query(names)
+}
+func (m *mockResource) Register(ch chan int, last int, hints ...string) {
+	m.register(ch, last)
+}
+func (m *mockResource) TypeURL() string { return m.typeurl() }
+
+func (m *mockResource) Versions(*core.Node) map[string]string {
+	if m.versions == nil {
+		// responses of a resource without versions
+		// are never skipped.
+		return nil
+	}
+	return m.versions()
+}
+
+func TestResponseVersion(t *testing.T) {
+	cla := func(name string, port int) proto.Message {
+		return &v2.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints: []endpoint.LocalityLbEndpoints{{
+				LbEndpoints: []endpoint.LbEndpoint{
+					envoy.LBEndpoint("192.168.183.24", port),
+				},
+			}},
+		}
+	}
+	version := func(values ...proto.Message) string {
+		resources, err := toAny(cache.EndpointType, values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return responseVersion(resources)
+	}
+
+	tests := map[string]struct {
+		a, b  []proto.Message
+		equal bool
+	}{
+		"empty": {
+			equal: true,
+		},
+		"equal contents": {
+			a:     []proto.Message{cla("default/kuard", 8080), cla("default/httpbin", 80)},
+			b:     []proto.Message{cla("default/kuard", 8080), cla("default/httpbin", 80)},
+			equal: true,
+		},
+		"different contents": {
+			a: []proto.Message{cla("default/kuard", 8080)},
+			b: []proto.Message{cla("default/kuard", 9000)},
+		},
+		"more resources": {
+			a: []proto.Message{cla("default/kuard", 8080)},
+			b: []proto.Message{cla("default/kuard", 8080), cla("default/httpbin", 80)},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, b := version(tc.a...), version(tc.b...)
+			if len(a) != 16 {
+				t.Fatalf("expected a 16 character version, got %q", a)
+			}
+			if (a == b) != tc.equal {
+				t.Fatalf("versions %q and %q: expected equal %v", a, b, tc.equal)
+			}
+		})
+	}
+}
 
 func TestCounterNext(t *testing.T) {
 	var c counter
@@ -207,3 +285,150 @@ func equalError(a, b error) bool {
 	}
 	return a.Error() == b.Error()
 }
+
+func TestXDSHandlerEndpointChurn(t *testing.T) {
+	const services, updates = 10, 50
+
+	// each change to the endpoints of a service sends a single
+	// response, to the stream subscribed to that service.
+	got := edsChurn(t, changedResources, services, updates)
+	if got != updates {
+		t.Fatalf("expected %d responses, got %d", updates, got)
+	}
+}
+
+func BenchmarkEndpointChurn(b *testing.B) {
+	const services = 100
+	for name, r := range map[string]func(*contour.EndpointsTranslator) Resource{
+		"every change":      everyChange,
+		"changed resources": changedResources,
+	} {
+		b.Run(name, func(b *testing.B) {
+			sent := edsChurn(b, r, services, b.N)
+			b.Logf("%d streams, %d updates, %d responses", services, b.N, sent)
+		})
+	}
+}
+
+func changedResources(et *contour.EndpointsTranslator) Resource { return et }
+
+func everyChange(et *contour.EndpointsTranslator) Resource { return unhinted{et} }
+
+// unhinted is a Resource which wakes every stream on every change,
+// and has no versions so that no response is skipped.
+type unhinted struct {
+	Resource
+}
+
+func (u unhinted) Register(ch chan int, last int, hints ...string) { u.Resource.Register(ch, last) }
+func (unhinted) Versions(*core.Node) map[string]string             { return nil }
+
+// edsChurn opens an EDS stream per service, each subscribed to the
+// endpoints of its service. Then the endpoints of each service change
+// in turn, updates times, and each change is awaited on the stream of
+// its service. edsChurn returns the number of responses sent after the
+// first response of each stream.
+func edsChurn(tb testing.TB, resource func(*contour.EndpointsTranslator) Resource, services, updates int) int {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	endpoints := func(i, port int) *v1.Endpoints {
+		return &v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("service%d", i),
+				Namespace: "default",
+			},
+			Subsets: []v1.EndpointSubset{{
+				Addresses: []v1.EndpointAddress{{IP: "192.168.183.24"}},
+				Ports:     []v1.EndpointPort{{Port: int32(port)}},
+			}},
+		}
+	}
+
+	et := &contour.EndpointsTranslator{FieldLogger: log}
+	eps := make([]*v1.Endpoints, services)
+	for i := range eps {
+		eps[i] = endpoints(i, 1)
+		et.OnAdd(eps[i])
+	}
+	xh := xdsHandler{
+		FieldLogger: log,
+		resources: map[string]Resource{
+			et.TypeURL(): resource(et),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var sent int64
+	var wg sync.WaitGroup
+	ports := make([]chan uint32, services)
+	for i := range ports {
+		// a stream sends at most one response per update.
+		recvd := make(chan uint32, updates+1)
+		ports[i] = recvd
+		reqs := make(chan *v2.DiscoveryRequest, 1)
+		reqs <- &v2.DiscoveryRequest{
+			TypeUrl:       et.TypeURL(),
+			ResourceNames: []string{fmt.Sprintf("default/service%d", i)},
+		}
+		st := &mockStream{
+			context: func() context.Context { return ctx },
+			send: func(resp *v2.DiscoveryResponse) error {
+				var cla v2.ClusterLoadAssignment
+				if err := proto.Unmarshal(resp.Resources[0].Value, &cla); err != nil {
+					return err
+				}
+				atomic.AddInt64(&sent, 1)
+				recvd <- cla.Endpoints[0].LbEndpoints[0].GetEndpoint().GetAddress().GetSocketAddress().GetPortValue()
+				return nil
+			},
+			recv: func() (*v2.DiscoveryRequest, error) {
+				select {
+				case req := <-reqs:
+					return req, nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			},
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = xh.stream(st)
+		}()
+	}
+
+	// wait waits for the stream of service i to receive port.
+	wait := func(i, port int) {
+		for {
+			select {
+			case got := <-ports[i]:
+				if got == uint32(port) {
+					return
+				}
+			case <-ctx.Done():
+				tb.Fatalf("timed out waiting for service%d port %d", i, port)
+			}
+		}
+	}
+	for i := range eps {
+		wait(i, 1)
+	}
+	initial := atomic.LoadInt64(&sent)
+
+	if b, ok := tb.(*testing.B); ok {
+		b.ResetTimer()
+	}
+	for u := 0; u < updates; u++ {
+		i := u % services
+		ep := endpoints(i, u+2)
+		et.OnUpdate(eps[i], ep)
+		eps[i] = ep
+		wait(i, u+2)
+	}
+
+	cancel()
+	wg.Wait()
+	return int(atomic.LoadInt64(&sent) - initial)
+}
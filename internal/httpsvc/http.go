@@ -17,6 +17,7 @@ package httpsvc
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"time"
@@ -29,6 +30,14 @@ type Service struct {
 	Addr string
 	Port int
 
+	// CertFile and KeyFile, if set, are the certificate
+	// and key used to serve HTTPS rather than HTTP.
+	CertFile string
+	KeyFile  string
+
+	// TLSConfig, if set, is used to serve HTTPS rather than HTTP.
+	TLSConfig *tls.Config
+
 	logrus.FieldLogger
 	http.ServeMux
 }
@@ -50,6 +59,7 @@ func (svc *Service) Start(stop <-chan struct{}) (err error) {
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   5 * time.Minute, // allow for long trace requests
 		MaxHeaderBytes: 1 << 11,         // 8kb should be enough for anyone
+		TLSConfig:      svc.TLSConfig,
 	}
 
 	go func() {
@@ -64,5 +74,8 @@ func (svc *Service) Start(stop <-chan struct{}) (err error) {
 	}()
 
 	svc.WithField("address", s.Addr).Info("started")
+	if svc.CertFile != "" || svc.KeyFile != "" || svc.TLSConfig != nil {
+		return s.ListenAndServeTLS(svc.CertFile, svc.KeyFile)
+	}
 	return s.ListenAndServe()
 }
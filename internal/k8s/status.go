@@ -20,6 +20,8 @@ import (
 	jsonpatch "github.com/evanphx/json-patch"
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
 	clientset "github.com/heptio/contour/apis/generated/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -28,18 +30,37 @@ type IngressRouteStatus struct {
 	Client clientset.Interface
 }
 
-// SetStatus sets the IngressRoute status field to an Valid or Invalid status
-func (irs *IngressRouteStatus) SetStatus(status, desc string, existing *ingressroutev1.IngressRoute) error {
-	// Check if update needed by comparing status & desc
-	if existing.CurrentStatus != status || existing.Description != desc {
-		updated := existing.DeepCopy()
-		updated.Status = ingressroutev1.Status{
-			CurrentStatus: status,
-			Description:   desc,
+// SetStatus sets the status of existing to status, stamped with the
+// generation of existing. The status is only patched if it has changed.
+func (irs *IngressRouteStatus) SetStatus(status ingressroutev1.Status, existing *ingressroutev1.IngressRoute) error {
+	status = nextStatus(existing.Status, status, existing.Generation, metav1.Now())
+	if equality.Semantic.DeepEqual(existing.Status, status) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Status = status
+	return irs.setStatus(existing, updated)
+}
+
+// nextStatus returns status, the new status of an IngressRoute of
+// generation whose status is current, with its observed generation
+// and the last transition times of its conditions filled in. The
+// time of a condition whose status has not changed is preserved,
+// otherwise it is now.
+func nextStatus(current, status ingressroutev1.Status, generation int64, now metav1.Time) ingressroutev1.Status {
+	status.ObservedGeneration = generation
+	var conditions []ingressroutev1.Condition
+	for _, c := range status.Conditions {
+		c.LastTransitionTime = now
+		for _, prev := range current.Conditions {
+			if prev.Type == c.Type && prev.Status == c.Status {
+				c.LastTransitionTime = prev.LastTransitionTime
+			}
 		}
-		return irs.setStatus(existing, updated)
+		conditions = append(conditions, c)
 	}
-	return nil
+	status.Conditions = conditions
+	return status
 }
 
 func (irs *IngressRouteStatus) setStatus(existing, updated *ingressroutev1.IngressRoute) error {
@@ -60,6 +81,8 @@ func (irs *IngressRouteStatus) setStatus(existing, updated *ingressroutev1.Ingre
 		return err
 	}
 
-	_, err = irs.Client.ContourV1beta1().IngressRoutes(existing.GetNamespace()).Patch(existing.GetName(), types.MergePatchType, patchBytes)
+	// patch the status subresource; a write to the main resource would
+	// bump its generation, and so the observed generation, forever.
+	_, err = irs.Client.ContourV1beta1().IngressRoutes(existing.GetNamespace()).Patch(existing.GetName(), types.MergePatchType, patchBytes, "status")
 	return err
 }
@@ -14,9 +14,13 @@
 package k8s
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/go-cmp/cmp"
 	ingressroutev1beta1 "github.com/heptio/contour/apis/contour/v1beta1"
 	"github.com/heptio/contour/apis/generated/clientset/versioned/fake"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,15 +30,16 @@ import (
 
 func TestSetStatus(t *testing.T) {
 	tests := map[string]struct {
-		msg           string
-		desc          string
+		status        ingressroutev1beta1.Status
 		existing      *ingressroutev1beta1.IngressRoute
 		expectedPatch string
 		expectedVerbs []string
 	}{
 		"simple update": {
-			msg:  "valid",
-			desc: "this is a valid IR",
+			status: ingressroutev1beta1.Status{
+				CurrentStatus: "valid",
+				Description:   "this is a valid IR",
+			},
 			existing: &ingressroutev1beta1.IngressRoute{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "test",
@@ -49,8 +54,10 @@ func TestSetStatus(t *testing.T) {
 			expectedVerbs: []string{"patch"},
 		},
 		"no update": {
-			msg:  "valid",
-			desc: "this is a valid IR",
+			status: ingressroutev1beta1.Status{
+				CurrentStatus: "valid",
+				Description:   "this is a valid IR",
+			},
 			existing: &ingressroutev1beta1.IngressRoute{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "test",
@@ -65,8 +72,10 @@ func TestSetStatus(t *testing.T) {
 			expectedVerbs: []string{},
 		},
 		"replace existing status": {
-			msg:  "valid",
-			desc: "this is a valid IR",
+			status: ingressroutev1beta1.Status{
+				CurrentStatus: "valid",
+				Description:   "this is a valid IR",
+			},
 			existing: &ingressroutev1beta1.IngressRoute{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "test",
@@ -80,6 +89,59 @@ func TestSetStatus(t *testing.T) {
 			expectedPatch: `{"status":{"currentStatus":"valid","description":"this is a valid IR"}}`,
 			expectedVerbs: []string{"patch"},
 		},
+		"new generation": {
+			status: ingressroutev1beta1.Status{
+				CurrentStatus: "valid",
+				Description:   "this is a valid IR",
+			},
+			existing: &ingressroutev1beta1.IngressRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test",
+					Namespace:  "default",
+					Generation: 2,
+				},
+				Status: ingressroutev1beta1.Status{
+					CurrentStatus:      "valid",
+					Description:        "this is a valid IR",
+					ObservedGeneration: 1,
+				},
+			},
+			expectedPatch: `{"status":{"observedGeneration":2}}`,
+			expectedVerbs: []string{"patch"},
+		},
+		"unchanged conditions": {
+			status: ingressroutev1beta1.Status{
+				CurrentStatus: "invalid",
+				Description:   "boo hiss",
+				Conditions: []ingressroutev1beta1.Condition{{
+					Type:    "Valid",
+					Status:  "False",
+					Reason:  "Invalid",
+					Message: "boo hiss",
+				}},
+				Errors: []string{"boo hiss"},
+			},
+			existing: &ingressroutev1beta1.IngressRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "default",
+				},
+				Status: ingressroutev1beta1.Status{
+					CurrentStatus: "invalid",
+					Description:   "boo hiss",
+					Conditions: []ingressroutev1beta1.Condition{{
+						Type:               "Valid",
+						Status:             "False",
+						Reason:             "Invalid",
+						Message:            "boo hiss",
+						LastTransitionTime: metav1.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC),
+					}},
+					Errors: []string{"boo hiss"},
+				},
+			},
+			expectedPatch: ``,
+			expectedVerbs: []string{},
+		},
 	}
 
 	for name, tc := range tests {
@@ -91,6 +153,9 @@ func TestSetStatus(t *testing.T) {
 				default:
 					return true, nil, fmt.Errorf("got unexpected action of type: %T", action)
 				case k8stesting.PatchActionImpl:
+					if sub := patchAction.GetSubresource(); sub != "status" {
+						return true, nil, fmt.Errorf("expected a patch of the status subresource, got: %q", sub)
+					}
 					gotPatchBytes = patchAction.GetPatch()
 					return true, tc.existing, nil
 				}
@@ -98,7 +163,7 @@ func TestSetStatus(t *testing.T) {
 			irs := IngressRouteStatus{
 				Client: client,
 			}
-			if err := irs.SetStatus(tc.msg, tc.desc, tc.existing); err != nil {
+			if err := irs.SetStatus(tc.status, tc.existing); err != nil {
 				t.Fatal(err)
 			}
 
@@ -112,3 +177,145 @@ func TestSetStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestSetStatusSettles(t *testing.T) {
+	// the ingressroute has been edited since its status was written.
+	existing := &ingressroutev1beta1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Namespace:  "default",
+			Generation: 2,
+		},
+		Status: ingressroutev1beta1.Status{
+			CurrentStatus:      "valid",
+			Description:        "this is a valid IR",
+			ObservedGeneration: 1,
+		},
+	}
+	status := ingressroutev1beta1.Status{
+		CurrentStatus: "valid",
+		Description:   "this is a valid IR",
+	}
+
+	var updated *ingressroutev1beta1.IngressRoute
+	client := fake.NewSimpleClientset(existing)
+	client.PrependReactor("patch", "ingressroutes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchActionImpl)
+		if sub := patchAction.GetSubresource(); sub != "status" {
+			return true, nil, fmt.Errorf("expected a patch of the status subresource, got: %q", sub)
+		}
+		current, err := json.Marshal(existing)
+		if err != nil {
+			return true, nil, err
+		}
+		patched, err := jsonpatch.MergePatch(current, patchAction.GetPatch())
+		if err != nil {
+			return true, nil, err
+		}
+		// writes of the status subresource leave the generation alone.
+		updated = new(ingressroutev1beta1.IngressRoute)
+		return true, updated, json.Unmarshal(patched, updated)
+	})
+	irs := IngressRouteStatus{
+		Client: client,
+	}
+
+	if err := irs.SetStatus(status, existing); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.Actions()) != 1 || updated == nil {
+		t.Fatalf("expected a single patch, got: %v", client.Actions())
+	}
+	if updated.Status.ObservedGeneration != 2 {
+		t.Fatalf("expected observed generation 2, got: %d", updated.Status.ObservedGeneration)
+	}
+
+	client.ClearActions()
+	if err := irs.SetStatus(status, updated); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.Actions()) != 0 {
+		t.Fatalf("expected no further writes, got: %v", client.Actions())
+	}
+}
+
+func TestNextStatus(t *testing.T) {
+	then := metav1.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := metav1.Date(2019, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	current := ingressroutev1beta1.Status{
+		CurrentStatus: "valid",
+		Description:   "valid IngressRoute",
+		Conditions: []ingressroutev1beta1.Condition{{
+			Type:               "Valid",
+			Status:             "True",
+			LastTransitionTime: then,
+		}, {
+			Type:               "ServicesResolved",
+			Status:             "True",
+			LastTransitionTime: then,
+		}},
+	}
+
+	tests := map[string]struct {
+		status ingressroutev1beta1.Status
+		want   ingressroutev1beta1.Status
+	}{
+		"no conditions": {
+			status: ingressroutev1beta1.Status{
+				CurrentStatus: "orphaned",
+			},
+			want: ingressroutev1beta1.Status{
+				CurrentStatus:      "orphaned",
+				ObservedGeneration: 3,
+			},
+		},
+		"one condition changed": {
+			status: ingressroutev1beta1.Status{
+				CurrentStatus: "valid",
+				Description:   "valid IngressRoute",
+				Conditions: []ingressroutev1beta1.Condition{{
+					Type:   "Valid",
+					Status: "True",
+				}, {
+					Type:    "ServicesResolved",
+					Status:  "False",
+					Reason:  "ServiceNotFound",
+					Message: `route "/": service default/kuard/8080: not found`,
+				}, {
+					Type:   "TLSReady",
+					Status: "True",
+				}},
+			},
+			want: ingressroutev1beta1.Status{
+				CurrentStatus:      "valid",
+				Description:        "valid IngressRoute",
+				ObservedGeneration: 3,
+				Conditions: []ingressroutev1beta1.Condition{{
+					Type:               "Valid",
+					Status:             "True",
+					LastTransitionTime: then,
+				}, {
+					Type:               "ServicesResolved",
+					Status:             "False",
+					Reason:             "ServiceNotFound",
+					Message:            `route "/": service default/kuard/8080: not found`,
+					LastTransitionTime: now,
+				}, {
+					Type:               "TLSReady",
+					Status:             "True",
+					LastTransitionTime: now,
+				}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := nextStatus(current, tc.status, 3, now)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
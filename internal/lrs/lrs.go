@@ -0,0 +1,193 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lrs aggregates the load reports Envoy sends to
+// Contour's load reporting service.
+package lrs
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/heptio/contour/internal/metrics"
+)
+
+// ClusterLoad is the aggregated load of an upstream cluster
+// reported by every Envoy.
+type ClusterLoad struct {
+	Name string `json:"name"`
+
+	// DroppedRequests is the number of requests Envoy
+	// dropped rather than sending them to the cluster.
+	DroppedRequests uint64 `json:"dropped_requests"`
+
+	// Localities holds the load of each locality of the
+	// cluster, sorted by locality.
+	Localities []LocalityLoad `json:"localities"`
+
+	// Reports is the number of load reports for the cluster.
+	Reports uint64 `json:"reports"`
+
+	// LastReport is the time of the last load report
+	// for the cluster.
+	LastReport time.Time `json:"last_report"`
+}
+
+// LocalityLoad is the aggregated load of an upstream locality.
+type LocalityLoad struct {
+	// Locality is the region, zone, and sub zone of the
+	// locality joined by slashes.
+	Locality string `json:"locality"`
+
+	SuccessfulRequests uint64 `json:"successful_requests"`
+	ErrorRequests      uint64 `json:"error_requests"`
+
+	// Metrics holds the named load metrics of the locality,
+	// such as request latency, sorted by name.
+	Metrics []MetricLoad `json:"metrics,omitempty"`
+}
+
+// MetricLoad is the aggregate of a named load metric of an upstream
+// locality. Envoy's load reports carry no latencies of their own,
+// upstreams report them, and any other load, as named metrics.
+type MetricLoad struct {
+	Name string `json:"name"`
+
+	// Requests is the number of requests which reported the metric.
+	Requests uint64 `json:"requests"`
+
+	// Total is the sum of the values of the metric.
+	Total float64 `json:"total"`
+
+	// Mean is Total divided by Requests.
+	Mean float64 `json:"mean"`
+}
+
+// Aggregator aggregates load reports by cluster and locality,
+// recording them as Prometheus metrics. Aggregator serves the
+// aggregated load as JSON.
+type Aggregator struct {
+	*metrics.Metrics
+
+	mu       sync.Mutex
+	clusters map[string]*ClusterLoad
+}
+
+// LoadReport implements grpc.LoadReportHandler.
+func (a *Aggregator) LoadReport(_ *core.Node, stats []*endpoint.ClusterStats) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.clusters == nil {
+		a.clusters = make(map[string]*ClusterLoad)
+	}
+
+	now := time.Now()
+	for _, cs := range stats {
+		cl, ok := a.clusters[cs.ClusterName]
+		if !ok {
+			cl = &ClusterLoad{Name: cs.ClusterName}
+			a.clusters[cs.ClusterName] = cl
+		}
+		cl.Reports++
+		cl.LastReport = now
+		cl.DroppedRequests += cs.TotalDroppedRequests
+		a.AddLoadReportDropped(cs.ClusterName, cs.TotalDroppedRequests)
+
+		for _, ls := range cs.UpstreamLocalityStats {
+			locality := localityName(ls.Locality)
+			ll := cl.locality(locality)
+			ll.SuccessfulRequests += ls.TotalSuccessfulRequests
+			ll.ErrorRequests += ls.TotalErrorRequests
+			a.AddLoadReport(cs.ClusterName, locality, ls.TotalSuccessfulRequests, ls.TotalErrorRequests)
+
+			for _, ms := range ls.LoadMetricStats {
+				ml := ll.metric(ms.MetricName)
+				ml.Requests += ms.NumRequestsFinishedWithMetric
+				ml.Total += ms.TotalMetricValue
+				if ml.Requests > 0 {
+					ml.Mean = ml.Total / float64(ml.Requests)
+				}
+				a.AddLoadReportMetric(cs.ClusterName, locality, ms.MetricName, ms.NumRequestsFinishedWithMetric, ms.TotalMetricValue)
+			}
+		}
+	}
+}
+
+// locality returns the load of the named locality, adding it if needed.
+func (cl *ClusterLoad) locality(name string) *LocalityLoad {
+	i := sort.Search(len(cl.Localities), func(i int) bool {
+		return cl.Localities[i].Locality >= name
+	})
+	if i == len(cl.Localities) || cl.Localities[i].Locality != name {
+		cl.Localities = append(cl.Localities, LocalityLoad{})
+		copy(cl.Localities[i+1:], cl.Localities[i:])
+		cl.Localities[i] = LocalityLoad{Locality: name}
+	}
+	return &cl.Localities[i]
+}
+
+// metric returns the load of the named metric, adding it if needed.
+func (ll *LocalityLoad) metric(name string) *MetricLoad {
+	i := sort.Search(len(ll.Metrics), func(i int) bool {
+		return ll.Metrics[i].Name >= name
+	})
+	if i == len(ll.Metrics) || ll.Metrics[i].Name != name {
+		ll.Metrics = append(ll.Metrics, MetricLoad{})
+		copy(ll.Metrics[i+1:], ll.Metrics[i:])
+		ll.Metrics[i] = MetricLoad{Name: name}
+	}
+	return &ll.Metrics[i]
+}
+
+// Load returns the aggregated load of each cluster, sorted by name.
+func (a *Aggregator) Load() []ClusterLoad {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	load := make([]ClusterLoad, 0, len(a.clusters))
+	for _, cl := range a.clusters {
+		c := *cl
+		c.Localities = nil
+		for _, ll := range cl.Localities {
+			ll.Metrics = append([]MetricLoad(nil), ll.Metrics...)
+			c.Localities = append(c.Localities, ll)
+		}
+		load = append(load, c)
+	}
+	sort.Slice(load, func(i, j int) bool {
+		return load[i].Name < load[j].Name
+	})
+	return load
+}
+
+// ServeHTTP writes the aggregated load of each cluster as JSON.
+func (a *Aggregator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(a.Load())
+}
+
+// localityName returns the name of l, or the empty string if
+// the locality is not set.
+func localityName(l *core.Locality) string {
+	if l.GetRegion() == "" && l.GetZone() == "" && l.GetSubZone() == "" {
+		return ""
+	}
+	return strings.Join([]string{l.Region, l.Zone, l.SubZone}, "/")
+}
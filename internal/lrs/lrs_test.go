@@ -0,0 +1,157 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lrs
+
+import (
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/heptio/contour/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestAggregatorLoadReport(t *testing.T) {
+	tests := map[string]struct {
+		reports [][]*endpoint.ClusterStats
+		want    []ClusterLoad
+	}{
+		"nothing": {
+			want: []ClusterLoad{},
+		},
+		"one cluster, no locality": {
+			reports: [][]*endpoint.ClusterStats{{{
+				ClusterName:          "default/kuard/80/da39a3ee5e",
+				TotalDroppedRequests: 2,
+				UpstreamLocalityStats: []*endpoint.UpstreamLocalityStats{{
+					TotalSuccessfulRequests: 10,
+					TotalErrorRequests:      1,
+				}},
+			}}},
+			want: []ClusterLoad{{
+				Name:            "default/kuard/80/da39a3ee5e",
+				DroppedRequests: 2,
+				Localities: []LocalityLoad{{
+					SuccessfulRequests: 10,
+					ErrorRequests:      1,
+				}},
+				Reports: 1,
+			}},
+		},
+		"load metrics": {
+			reports: [][]*endpoint.ClusterStats{{{
+				ClusterName: "default/kuard/80/da39a3ee5e",
+				UpstreamLocalityStats: []*endpoint.UpstreamLocalityStats{{
+					TotalSuccessfulRequests: 10,
+					LoadMetricStats: []*endpoint.EndpointLoadMetricStats{{
+						MetricName:                    "latency_ms",
+						NumRequestsFinishedWithMetric: 4,
+						TotalMetricValue:              100,
+					}, {
+						MetricName:                    "cpu_utilization",
+						NumRequestsFinishedWithMetric: 10,
+						TotalMetricValue:              5,
+					}},
+				}},
+			}}, {{
+				ClusterName: "default/kuard/80/da39a3ee5e",
+				UpstreamLocalityStats: []*endpoint.UpstreamLocalityStats{{
+					TotalSuccessfulRequests: 6,
+					LoadMetricStats: []*endpoint.EndpointLoadMetricStats{{
+						MetricName:                    "latency_ms",
+						NumRequestsFinishedWithMetric: 6,
+						TotalMetricValue:              200,
+					}},
+				}},
+			}}},
+			want: []ClusterLoad{{
+				Name: "default/kuard/80/da39a3ee5e",
+				Localities: []LocalityLoad{{
+					SuccessfulRequests: 16,
+					Metrics: []MetricLoad{{
+						Name:     "cpu_utilization",
+						Requests: 10,
+						Total:    5,
+						Mean:     0.5,
+					}, {
+						Name:     "latency_ms",
+						Requests: 10,
+						Total:    300,
+						Mean:     30,
+					}},
+				}},
+				Reports: 2,
+			}},
+		},
+		"reports from two envoys are summed": {
+			reports: [][]*endpoint.ClusterStats{{{
+				ClusterName: "default/kuard/80/da39a3ee5e",
+				UpstreamLocalityStats: []*endpoint.UpstreamLocalityStats{{
+					Locality:                &core.Locality{Region: "us-east-1", Zone: "b"},
+					TotalSuccessfulRequests: 10,
+				}, {
+					Locality:                &core.Locality{Region: "us-east-1", Zone: "a"},
+					TotalSuccessfulRequests: 5,
+				}},
+			}}, {{
+				ClusterName: "default/kuard/80/da39a3ee5e",
+				UpstreamLocalityStats: []*endpoint.UpstreamLocalityStats{{
+					Locality:                &core.Locality{Region: "us-east-1", Zone: "a"},
+					TotalSuccessfulRequests: 7,
+					TotalErrorRequests:      3,
+				}},
+			}, {
+				ClusterName: "default/httpbin/80/da39a3ee5e",
+				UpstreamLocalityStats: []*endpoint.UpstreamLocalityStats{{
+					TotalErrorRequests: 4,
+				}},
+			}}},
+			want: []ClusterLoad{{
+				Name: "default/httpbin/80/da39a3ee5e",
+				Localities: []LocalityLoad{{
+					ErrorRequests: 4,
+				}},
+				Reports: 1,
+			}, {
+				Name: "default/kuard/80/da39a3ee5e",
+				Localities: []LocalityLoad{{
+					Locality:           "us-east-1/a/",
+					SuccessfulRequests: 12,
+					ErrorRequests:      3,
+				}, {
+					Locality:           "us-east-1/b/",
+					SuccessfulRequests: 10,
+				}},
+				Reports: 2,
+			}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := &Aggregator{
+				Metrics: metrics.NewMetrics(prometheus.NewRegistry()),
+			}
+			for _, r := range tc.reports {
+				a.LoadReport(&core.Node{Id: "envoy"}, r)
+			}
+			got := a.Load()
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(ClusterLoad{}, "LastReport")); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
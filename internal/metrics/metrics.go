@@ -17,6 +17,8 @@ package metrics
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/heptio/contour/internal/httpsvc"
 	"github.com/prometheus/client_golang/prometheus"
@@ -31,12 +33,26 @@ type Metrics struct {
 	ingressRouteValidGauge      *prometheus.GaugeVec
 	ingressRouteOrphanedGauge   *prometheus.GaugeVec
 	ingressRouteDAGRebuildGauge *prometheus.GaugeVec
+	certificateExpiryGauge      *prometheus.GaugeVec
+	ocspResponseExpiryGauge     *prometheus.GaugeVec
+	ocspResponseStaleGauge      *prometheus.GaugeVec
+	accessLogRequestsCounter    *prometheus.CounterVec
+	accessLogDurationHistogram  *prometheus.HistogramVec
+	loadReportRequestsCounter   *prometheus.CounterVec
+	loadReportDroppedCounter    *prometheus.CounterVec
+	loadReportMetricCounter     *prometheus.CounterVec
+	loadReportMetricValue       *prometheus.CounterVec
+	xdsRejectedCounter          *prometheus.CounterVec
+	xdsRejectingGauge           *prometheus.GaugeVec
+	xdsVersionGauge             *prometheus.GaugeVec
 
 	CacheHandlerOnUpdateSummary prometheus.Summary
 	ResourceEventHandlerSummary *prometheus.SummaryVec
 
 	// Keep a local cache of metrics for comparison on updates
-	metricCache *IngressRouteMetric
+	metricCache      *IngressRouteMetric
+	certificateCache map[CertificateMeta]int64
+	ocspCache        map[CertificateMeta]OCSPResponseMetric
 }
 
 // IngressRouteMetric stores various metrics for IngressRoute objects
@@ -53,6 +69,19 @@ type Meta struct {
 	VHost, Namespace string
 }
 
+// OCSPResponseMetric holds the next update time, in seconds since the
+// epoch, and staleness of an OCSP response.
+type OCSPResponseMetric struct {
+	NextUpdate int64
+	Stale      bool
+}
+
+// CertificateMeta holds the secret name, namespace, and vhost of
+// a certificate metric.
+type CertificateMeta struct {
+	Name, Namespace, VHost string
+}
+
 const (
 	IngressRouteTotalGauge      = "contour_ingressroute_total"
 	IngressRouteRootTotalGauge  = "contour_ingressroute_root_total"
@@ -60,6 +89,18 @@ const (
 	IngressRouteValidGauge      = "contour_ingressroute_valid_total"
 	IngressRouteOrphanedGauge   = "contour_ingressroute_orphaned_total"
 	IngressRouteDAGRebuildGauge = "contour_ingressroute_dagrebuild_timestamp"
+	CertificateExpiryGauge      = "contour_certificate_expiry_timestamp"
+	OCSPResponseExpiryGauge     = "contour_ocsp_response_expiry_timestamp"
+	OCSPResponseStaleGauge      = "contour_ocsp_response_stale"
+	AccessLogRequestsCounter    = "contour_accesslog_requests_total"
+	AccessLogDurationHistogram  = "contour_accesslog_request_duration_seconds"
+	LoadReportRequestsCounter   = "contour_loadreport_requests_total"
+	LoadReportDroppedCounter    = "contour_loadreport_dropped_requests_total"
+	LoadReportMetricCounter     = "contour_loadreport_metric_requests_total"
+	LoadReportMetricValue       = "contour_loadreport_metric_value_total"
+	XDSRejectedCounter          = "contour_xds_rejected_total"
+	XDSRejectingGauge           = "contour_xds_rejecting"
+	XDSVersionGauge             = "contour_xds_version"
 
 	cacheHandlerOnUpdateSummary = "contour_cachehandler_onupdate_duration_seconds"
 	resourceEventHandlerSummary = "contour_resourceeventhandler_duration_seconds"
@@ -112,6 +153,90 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 			},
 			[]string{},
 		),
+		certificateExpiryGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: CertificateExpiryGauge,
+				Help: "Timestamp at which the certificate served for a vhost expires",
+			},
+			[]string{"namespace", "name", "vhost"},
+		),
+		ocspResponseExpiryGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: OCSPResponseExpiryGauge,
+				Help: "Timestamp at which the OCSP response for a vhost's certificate becomes stale",
+			},
+			[]string{"namespace", "name", "vhost"},
+		),
+		ocspResponseStaleGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: OCSPResponseStaleGauge,
+				Help: "Set to 1 if the OCSP response for a vhost's certificate is stale and is not being stapled",
+			},
+			[]string{"namespace", "name", "vhost"},
+		),
+		accessLogRequestsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: AccessLogRequestsCounter,
+				Help: "Total number of requests reported by Envoy's access log service",
+			},
+			[]string{"vhost", "upstream_cluster", "code"},
+		),
+		accessLogDurationHistogram: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: AccessLogDurationHistogram,
+				Help: "Histogram of request durations reported by Envoy's access log service",
+			},
+			[]string{"vhost", "upstream_cluster"},
+		),
+		loadReportRequestsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: LoadReportRequestsCounter,
+				Help: "Total number of completed requests to an upstream cluster reported by Envoy's load reports",
+			},
+			[]string{"cluster", "locality", "result"},
+		),
+		loadReportDroppedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: LoadReportDroppedCounter,
+				Help: "Total number of requests to an upstream cluster dropped by Envoy reported by Envoy's load reports",
+			},
+			[]string{"cluster"},
+		),
+		loadReportMetricCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: LoadReportMetricCounter,
+				Help: "Total number of requests to an upstream cluster which reported a load metric, such as latency, in Envoy's load reports",
+			},
+			[]string{"cluster", "locality", "metric"},
+		),
+		loadReportMetricValue: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: LoadReportMetricValue,
+				Help: "Sum of the values of a load metric, such as latency, of requests to an upstream cluster reported by Envoy's load reports",
+			},
+			[]string{"cluster", "locality", "metric"},
+		),
+		xdsRejectedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: XDSRejectedCounter,
+				Help: "Total number of xDS responses rejected by Envoy",
+			},
+			[]string{"node", "type_url"},
+		),
+		xdsRejectingGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: XDSRejectingGauge,
+				Help: "Number of xDS streams whose last response was rejected by Envoy",
+			},
+			[]string{"node", "type_url"},
+		),
+		xdsVersionGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: XDSVersionGauge,
+				Help: "Number of xDS streams whose last accepted response had this version",
+			},
+			[]string{"node", "type_url", "version"},
+		),
 		CacheHandlerOnUpdateSummary: prometheus.NewSummary(prometheus.SummaryOpts{
 			Name:       cacheHandlerOnUpdateSummary,
 			Help:       "Histogram for the runtime of xDS cache regeneration",
@@ -138,6 +263,18 @@ func (m *Metrics) register(registry *prometheus.Registry) {
 		m.ingressRouteValidGauge,
 		m.ingressRouteOrphanedGauge,
 		m.ingressRouteDAGRebuildGauge,
+		m.certificateExpiryGauge,
+		m.ocspResponseExpiryGauge,
+		m.ocspResponseStaleGauge,
+		m.accessLogRequestsCounter,
+		m.accessLogDurationHistogram,
+		m.loadReportRequestsCounter,
+		m.loadReportDroppedCounter,
+		m.loadReportMetricCounter,
+		m.loadReportMetricValue,
+		m.xdsRejectedCounter,
+		m.xdsRejectingGauge,
+		m.xdsVersionGauge,
 		m.CacheHandlerOnUpdateSummary,
 		m.ResourceEventHandlerSummary,
 	)
@@ -199,6 +336,100 @@ func (m *Metrics) SetIngressRouteMetric(metrics IngressRouteMetric) {
 	}
 }
 
+// SetCertificateExpiryMetric sets the expiry timestamp of each certificate
+// served by Envoy, removing metrics for certificates no longer present.
+func (m *Metrics) SetCertificateExpiryMetric(expiry map[CertificateMeta]int64) {
+	for meta, value := range expiry {
+		m.certificateExpiryGauge.WithLabelValues(meta.Namespace, meta.Name, meta.VHost).Set(float64(value))
+		delete(m.certificateCache, meta)
+	}
+
+	// remove any certificates which are no longer served
+	for meta := range m.certificateCache {
+		m.certificateExpiryGauge.DeleteLabelValues(meta.Namespace, meta.Name, meta.VHost)
+	}
+
+	m.certificateCache = expiry
+}
+
+// SetOCSPResponseMetric sets the next update timestamp and staleness of
+// each OCSP response, removing metrics for responses no longer present.
+func (m *Metrics) SetOCSPResponseMetric(responses map[CertificateMeta]OCSPResponseMetric) {
+	for meta, value := range responses {
+		m.ocspResponseExpiryGauge.WithLabelValues(meta.Namespace, meta.Name, meta.VHost).Set(float64(value.NextUpdate))
+		stale := 0.0
+		if value.Stale {
+			stale = 1
+		}
+		m.ocspResponseStaleGauge.WithLabelValues(meta.Namespace, meta.Name, meta.VHost).Set(stale)
+		delete(m.ocspCache, meta)
+	}
+
+	// remove any responses which are no longer present
+	for meta := range m.ocspCache {
+		m.ocspResponseExpiryGauge.DeleteLabelValues(meta.Namespace, meta.Name, meta.VHost)
+		m.ocspResponseStaleGauge.DeleteLabelValues(meta.Namespace, meta.Name, meta.VHost)
+	}
+
+	m.ocspCache = responses
+}
+
+// ObserveAccessLogRequest records a request, its response code, and
+// its duration, reported by Envoy's access log service.
+func (m *Metrics) ObserveAccessLogRequest(vhost, upstreamCluster string, code uint32, duration time.Duration) {
+	m.accessLogRequestsCounter.WithLabelValues(vhost, upstreamCluster, strconv.FormatUint(uint64(code), 10)).Inc()
+	m.accessLogDurationHistogram.WithLabelValues(vhost, upstreamCluster).Observe(duration.Seconds())
+}
+
+// AddLoadReport adds the successful and error requests to cluster,
+// from locality, reported by an Envoy load report.
+func (m *Metrics) AddLoadReport(cluster, locality string, successful, errors uint64) {
+	m.loadReportRequestsCounter.WithLabelValues(cluster, locality, "success").Add(float64(successful))
+	m.loadReportRequestsCounter.WithLabelValues(cluster, locality, "error").Add(float64(errors))
+}
+
+// AddLoadReportDropped adds the requests to cluster dropped by
+// Envoy, reported by an Envoy load report.
+func (m *Metrics) AddLoadReportDropped(cluster string, dropped uint64) {
+	m.loadReportDroppedCounter.WithLabelValues(cluster).Add(float64(dropped))
+}
+
+// AddLoadReportMetric adds the requests to cluster, from locality, which
+// reported the named load metric, and the sum of its values, reported by
+// an Envoy load report.
+func (m *Metrics) AddLoadReportMetric(cluster, locality, metric string, requests uint64, total float64) {
+	m.loadReportMetricCounter.WithLabelValues(cluster, locality, metric).Add(float64(requests))
+	if total >= 0 {
+		// counters cannot go down; a negative load metric is not a total.
+		m.loadReportMetricValue.WithLabelValues(cluster, locality, metric).Add(total)
+	}
+}
+
+// AddXDSRejected records an xDS response of typeURL rejected by node.
+func (m *Metrics) AddXDSRejected(node, typeURL string) {
+	m.xdsRejectedCounter.WithLabelValues(node, typeURL).Inc()
+}
+
+// SetXDSRejecting records the number of xDS streams of typeURL
+// to node whose last response node rejected.
+func (m *Metrics) SetXDSRejecting(node, typeURL string, streams int) {
+	if streams == 0 {
+		m.xdsRejectingGauge.DeleteLabelValues(node, typeURL)
+		return
+	}
+	m.xdsRejectingGauge.WithLabelValues(node, typeURL).Set(float64(streams))
+}
+
+// SetXDSVersion records the number of xDS streams of typeURL
+// on node whose last accepted response had version.
+func (m *Metrics) SetXDSVersion(node, typeURL, version string, streams int) {
+	if streams == 0 {
+		m.xdsVersionGauge.DeleteLabelValues(node, typeURL, version)
+		return
+	}
+	m.xdsVersionGauge.WithLabelValues(node, typeURL, version).Set(float64(streams))
+}
+
 // Service serves various metric and health checking endpoints
 type Service struct {
 	httpsvc.Service
@@ -722,3 +722,339 @@ func TestRemoveMetric(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteCertificateExpiryMetric(t *testing.T) {
+	label := func(name, value string) *io_prometheus_client.LabelPair {
+		return &io_prometheus_client.LabelPair{
+			Name:  func() *string { i := name; return &i }(),
+			Value: func() *string { i := value; return &i }(),
+		}
+	}
+
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+
+	gather := func() []*io_prometheus_client.Metric {
+		t.Helper()
+		gathering, err := r.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []*io_prometheus_client.Metric{}
+		for _, mf := range gathering {
+			if mf.GetName() == CertificateExpiryGauge {
+				got = mf.Metric
+			}
+		}
+		return got
+	}
+
+	m.SetCertificateExpiryMetric(map[CertificateMeta]int64{
+		{Name: "secret", Namespace: "default", VHost: "example.com"}: 4945934439,
+		{Name: "secret", Namespace: "default", VHost: "example.org"}: 4945934439,
+	})
+
+	want := []*io_prometheus_client.Metric{{
+		Label: []*io_prometheus_client.LabelPair{
+			label("name", "secret"),
+			label("namespace", "default"),
+			label("vhost", "example.com"),
+		},
+		Gauge: &io_prometheus_client.Gauge{
+			Value: func() *float64 { i := float64(4945934439); return &i }(),
+		},
+	}, {
+		Label: []*io_prometheus_client.LabelPair{
+			label("name", "secret"),
+			label("namespace", "default"),
+			label("vhost", "example.org"),
+		},
+		Gauge: &io_prometheus_client.Gauge{
+			Value: func() *float64 { i := float64(4945934439); return &i }(),
+		},
+	}}
+	if got := gather(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("write certificate expiry metric failed, want: %v got: %v", want, got)
+	}
+
+	// remove the example.org vhost, its metric should be removed.
+	m.SetCertificateExpiryMetric(map[CertificateMeta]int64{
+		{Name: "secret", Namespace: "default", VHost: "example.com"}: 4945934439,
+	})
+	if got := gather(); !reflect.DeepEqual(want[:1], got) {
+		t.Fatalf("write certificate expiry metric failed, want: %v got: %v", want[:1], got)
+	}
+}
+
+func TestWriteOCSPResponseMetric(t *testing.T) {
+	label := func(name, value string) *io_prometheus_client.LabelPair {
+		return &io_prometheus_client.LabelPair{
+			Name:  func() *string { i := name; return &i }(),
+			Value: func() *string { i := value; return &i }(),
+		}
+	}
+	gauge := func(v float64) *io_prometheus_client.Gauge {
+		return &io_prometheus_client.Gauge{Value: &v}
+	}
+
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+
+	gather := func(name string) []*io_prometheus_client.Metric {
+		t.Helper()
+		gathering, err := r.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []*io_prometheus_client.Metric{}
+		for _, mf := range gathering {
+			if mf.GetName() == name {
+				got = mf.Metric
+			}
+		}
+		return got
+	}
+
+	m.SetOCSPResponseMetric(map[CertificateMeta]OCSPResponseMetric{
+		{Name: "secret", Namespace: "default", VHost: "example.com"}: {NextUpdate: 4922812800},
+		{Name: "secret", Namespace: "default", VHost: "example.org"}: {NextUpdate: 1546387200, Stale: true},
+	})
+
+	labels := func(vhost string) []*io_prometheus_client.LabelPair {
+		return []*io_prometheus_client.LabelPair{
+			label("name", "secret"),
+			label("namespace", "default"),
+			label("vhost", vhost),
+		}
+	}
+	wantExpiry := []*io_prometheus_client.Metric{
+		{Label: labels("example.com"), Gauge: gauge(4922812800)},
+		{Label: labels("example.org"), Gauge: gauge(1546387200)},
+	}
+	wantStale := []*io_prometheus_client.Metric{
+		{Label: labels("example.com"), Gauge: gauge(0)},
+		{Label: labels("example.org"), Gauge: gauge(1)},
+	}
+	if got := gather(OCSPResponseExpiryGauge); !reflect.DeepEqual(wantExpiry, got) {
+		t.Fatalf("write ocsp response expiry metric failed, want: %v got: %v", wantExpiry, got)
+	}
+	if got := gather(OCSPResponseStaleGauge); !reflect.DeepEqual(wantStale, got) {
+		t.Fatalf("write ocsp response stale metric failed, want: %v got: %v", wantStale, got)
+	}
+
+	// remove the example.org vhost, its metrics should be removed.
+	m.SetOCSPResponseMetric(map[CertificateMeta]OCSPResponseMetric{
+		{Name: "secret", Namespace: "default", VHost: "example.com"}: {NextUpdate: 4922812800},
+	})
+	if got := gather(OCSPResponseExpiryGauge); !reflect.DeepEqual(wantExpiry[:1], got) {
+		t.Fatalf("write ocsp response expiry metric failed, want: %v got: %v", wantExpiry[:1], got)
+	}
+	if got := gather(OCSPResponseStaleGauge); !reflect.DeepEqual(wantStale[:1], got) {
+		t.Fatalf("write ocsp response stale metric failed, want: %v got: %v", wantStale[:1], got)
+	}
+}
+
+func TestObserveAccessLogRequest(t *testing.T) {
+	label := func(name, value string) *io_prometheus_client.LabelPair {
+		return &io_prometheus_client.LabelPair{
+			Name:  func() *string { i := name; return &i }(),
+			Value: func() *string { i := value; return &i }(),
+		}
+	}
+	counter := func(v float64) *io_prometheus_client.Counter {
+		return &io_prometheus_client.Counter{Value: &v}
+	}
+
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+
+	m.ObserveAccessLogRequest("example.com", "default/kuard/80/da39a3ee5e", 200, 20*time.Millisecond)
+	m.ObserveAccessLogRequest("example.com", "default/kuard/80/da39a3ee5e", 200, 40*time.Millisecond)
+	m.ObserveAccessLogRequest("example.com", "default/kuard/80/da39a3ee5e", 503, 2*time.Second)
+
+	gathering, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests, durations []*io_prometheus_client.Metric
+	for _, mf := range gathering {
+		switch mf.GetName() {
+		case AccessLogRequestsCounter:
+			requests = mf.Metric
+		case AccessLogDurationHistogram:
+			durations = mf.Metric
+		}
+	}
+
+	labels := func(code string) []*io_prometheus_client.LabelPair {
+		return []*io_prometheus_client.LabelPair{
+			label("code", code),
+			label("upstream_cluster", "default/kuard/80/da39a3ee5e"),
+			label("vhost", "example.com"),
+		}
+	}
+	wantRequests := []*io_prometheus_client.Metric{
+		{Label: labels("200"), Counter: counter(2)},
+		{Label: labels("503"), Counter: counter(1)},
+	}
+	if !reflect.DeepEqual(wantRequests, requests) {
+		t.Fatalf("write access log requests metric failed, want: %v got: %v", wantRequests, requests)
+	}
+
+	if len(durations) != 1 {
+		t.Fatalf("expected one access log duration metric, got: %v", durations)
+	}
+	h := durations[0].GetHistogram()
+	if h.GetSampleCount() != 3 {
+		t.Fatalf("expected 3 samples, got: %d", h.GetSampleCount())
+	}
+	if got, want := h.GetSampleSum(), 2.06; got < want-0.0001 || got > want+0.0001 {
+		t.Fatalf("expected sample sum %v, got: %v", want, got)
+	}
+}
+
+func TestAddLoadReport(t *testing.T) {
+	label := func(name, value string) *io_prometheus_client.LabelPair {
+		return &io_prometheus_client.LabelPair{
+			Name:  func() *string { i := name; return &i }(),
+			Value: func() *string { i := value; return &i }(),
+		}
+	}
+	counter := func(v float64) *io_prometheus_client.Counter {
+		return &io_prometheus_client.Counter{Value: &v}
+	}
+
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+
+	m.AddLoadReport("default/kuard/80/da39a3ee5e", "us-east-1/a/", 10, 1)
+	m.AddLoadReport("default/kuard/80/da39a3ee5e", "us-east-1/a/", 5, 0)
+	m.AddLoadReportDropped("default/kuard/80/da39a3ee5e", 3)
+
+	gathering, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests, dropped []*io_prometheus_client.Metric
+	for _, mf := range gathering {
+		switch mf.GetName() {
+		case LoadReportRequestsCounter:
+			requests = mf.Metric
+		case LoadReportDroppedCounter:
+			dropped = mf.Metric
+		}
+	}
+
+	labels := func(result string) []*io_prometheus_client.LabelPair {
+		return []*io_prometheus_client.LabelPair{
+			label("cluster", "default/kuard/80/da39a3ee5e"),
+			label("locality", "us-east-1/a/"),
+			label("result", result),
+		}
+	}
+	wantRequests := []*io_prometheus_client.Metric{
+		{Label: labels("error"), Counter: counter(1)},
+		{Label: labels("success"), Counter: counter(15)},
+	}
+	if !reflect.DeepEqual(wantRequests, requests) {
+		t.Fatalf("write load report requests metric failed, want: %v got: %v", wantRequests, requests)
+	}
+	wantDropped := []*io_prometheus_client.Metric{{
+		Label:   []*io_prometheus_client.LabelPair{label("cluster", "default/kuard/80/da39a3ee5e")},
+		Counter: counter(3),
+	}}
+	if !reflect.DeepEqual(wantDropped, dropped) {
+		t.Fatalf("write load report dropped metric failed, want: %v got: %v", wantDropped, dropped)
+	}
+}
+
+func TestXDSRejected(t *testing.T) {
+	label := func(name, value string) *io_prometheus_client.LabelPair {
+		return &io_prometheus_client.LabelPair{
+			Name:  func() *string { i := name; return &i }(),
+			Value: func() *string { i := value; return &i }(),
+		}
+	}
+
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+
+	m.AddXDSRejected("envoy-1", "type.googleapis.com/envoy.api.v2.Listener")
+	m.AddXDSRejected("envoy-1", "type.googleapis.com/envoy.api.v2.Listener")
+	m.SetXDSRejecting("envoy-1", "type.googleapis.com/envoy.api.v2.Listener", 1)
+	m.SetXDSRejecting("envoy-2", "type.googleapis.com/envoy.api.v2.Listener", 1)
+	m.SetXDSRejecting("envoy-2", "type.googleapis.com/envoy.api.v2.Listener", 0)
+
+	gathering, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rejected, rejecting []*io_prometheus_client.Metric
+	for _, mf := range gathering {
+		switch mf.GetName() {
+		case XDSRejectedCounter:
+			rejected = mf.Metric
+		case XDSRejectingGauge:
+			rejecting = mf.Metric
+		}
+	}
+
+	labels := []*io_prometheus_client.LabelPair{
+		label("node", "envoy-1"),
+		label("type_url", "type.googleapis.com/envoy.api.v2.Listener"),
+	}
+	wantRejected := []*io_prometheus_client.Metric{{
+		Label:   labels,
+		Counter: &io_prometheus_client.Counter{Value: func() *float64 { v := 2.0; return &v }()},
+	}}
+	if !reflect.DeepEqual(wantRejected, rejected) {
+		t.Fatalf("write xds rejected metric failed, want: %v got: %v", wantRejected, rejected)
+	}
+	wantRejecting := []*io_prometheus_client.Metric{{
+		Label: labels,
+		Gauge: &io_prometheus_client.Gauge{Value: func() *float64 { v := 1.0; return &v }()},
+	}}
+	if !reflect.DeepEqual(wantRejecting, rejecting) {
+		t.Fatalf("write xds rejecting metric failed, want: %v got: %v", wantRejecting, rejecting)
+	}
+}
+
+func TestXDSVersion(t *testing.T) {
+	label := func(name, value string) *io_prometheus_client.LabelPair {
+		return &io_prometheus_client.LabelPair{
+			Name:  func() *string { i := name; return &i }(),
+			Value: func() *string { i := value; return &i }(),
+		}
+	}
+
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+
+	m.SetXDSVersion("envoy-1", "type.googleapis.com/envoy.api.v2.Cluster", "0123456789abcdef", 1)
+	m.SetXDSVersion("envoy-1", "type.googleapis.com/envoy.api.v2.Cluster", "0123456789abcdef", 2)
+	m.SetXDSVersion("envoy-1", "type.googleapis.com/envoy.api.v2.Cluster", "fedcba9876543210", 1)
+	m.SetXDSVersion("envoy-1", "type.googleapis.com/envoy.api.v2.Cluster", "fedcba9876543210", 0)
+
+	gathering, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []*io_prometheus_client.Metric
+	for _, mf := range gathering {
+		if mf.GetName() == XDSVersionGauge {
+			got = mf.Metric
+		}
+	}
+
+	want := []*io_prometheus_client.Metric{{
+		Label: []*io_prometheus_client.LabelPair{
+			label("node", "envoy-1"),
+			label("type_url", "type.googleapis.com/envoy.api.v2.Cluster"),
+			label("version", "0123456789abcdef"),
+		},
+		Gauge: &io_prometheus_client.Gauge{Value: func() *float64 { v := 2.0; return &v }()},
+	}}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("write xds version metric failed, want: %v got: %v", want, got)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook provides a validating admission webhook which rejects
+// IngressRoutes and TLSCertificateDelegations Contour would not accept.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/sirupsen/logrus"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Handler serves AdmissionReview requests for IngressRoutes and
+// TLSCertificateDelegations.
+type Handler struct {
+	// Cache, if set, holds the IngressRoutes admitted
+	// IngressRoutes must not share an fqdn with.
+	Cache *dag.KubernetesCache
+
+	// ValidIngressClass, if set, returns false for objects
+	// which belong to another ingress class. Such objects
+	// are admitted unchecked.
+	ValidIngressClass func(obj interface{}) bool
+
+	logrus.FieldLogger
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var review admissionv1beta1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "AdmissionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	resp := h.review(review.Request)
+	resp.UID = review.Request.UID
+	review.Request = nil
+	review.Response = resp
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		h.WithError(err).Error("failed to write AdmissionReview response")
+	}
+}
+
+// review returns the response to req.
+func (h *Handler) review(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Operation == admissionv1beta1.Delete {
+		return allowed()
+	}
+
+	var problems []string
+	switch req.Kind.Kind {
+	case "IngressRoute":
+		var ir ingressroutev1.IngressRoute
+		if err := json.Unmarshal(req.Object.Raw, &ir); err != nil {
+			return denied(fmt.Sprintf("IngressRoute could not be decoded: %v", err))
+		}
+		if ir.Namespace == "" {
+			// the namespace of created objects may not be set yet.
+			ir.Namespace = req.Namespace
+		}
+		if h.ValidIngressClass != nil && !h.ValidIngressClass(&ir) {
+			return allowed()
+		}
+		if req.Operation == admissionv1beta1.Update {
+			// Contour writes status by updating the IngressRoute
+			// itself, updates which leave the spec alone must be
+			// admitted, or IngressRoutes which were invalid before
+			// the webhook was installed could never be told so.
+			var old ingressroutev1.IngressRoute
+			if err := json.Unmarshal(req.OldObject.Raw, &old); err == nil && unchanged(&old.ObjectMeta, &ir.ObjectMeta, old.Spec, ir.Spec) {
+				return allowed()
+			}
+		}
+		problems = dag.ValidateIngressRoute(&ir)
+		if h.Cache != nil {
+			if conflict := h.Cache.FQDNConflict(&ir); conflict != "" {
+				problems = append(problems, conflict)
+			}
+		}
+	case "TLSCertificateDelegation":
+		var d ingressroutev1.TLSCertificateDelegation
+		if err := json.Unmarshal(req.Object.Raw, &d); err != nil {
+			return denied(fmt.Sprintf("TLSCertificateDelegation could not be decoded: %v", err))
+		}
+		if d.Namespace == "" {
+			d.Namespace = req.Namespace
+		}
+		if req.Operation == admissionv1beta1.Update {
+			var old ingressroutev1.TLSCertificateDelegation
+			if err := json.Unmarshal(req.OldObject.Raw, &old); err == nil && unchanged(&old.ObjectMeta, &d.ObjectMeta, old.Spec, d.Spec) {
+				return allowed()
+			}
+		}
+		problems = dag.ValidateTLSCertificateDelegation(&d)
+	default:
+		// not a kind this webhook validates.
+		return allowed()
+	}
+
+	if len(problems) == 0 {
+		return allowed()
+	}
+	h.WithField("kind", req.Kind.Kind).
+		WithField("namespace", req.Namespace).
+		WithField("name", req.Name).
+		WithField("operation", req.Operation).
+		Infof("denied: %s", strings.Join(problems, ", "))
+	return denied(strings.Join(problems, ", "))
+}
+
+// unchanged returns true if an update changed neither the spec nor
+// the annotations, which select the ingress class, of an object.
+func unchanged(oldMeta, newMeta *metav1.ObjectMeta, oldSpec, newSpec interface{}) bool {
+	return equality.Semantic.DeepEqual(oldSpec, newSpec) &&
+		equality.Semantic.DeepEqual(oldMeta.Annotations, newMeta.Annotations)
+}
+
+func allowed() *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{Allowed: true}
+}
+
+func denied(msg string) *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: msg,
+		},
+	}
+}
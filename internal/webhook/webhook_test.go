@@ -0,0 +1,262 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/sirupsen/logrus"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestHandler(t *testing.T) {
+	ingressroute := func(name, fqdn string, port int) *ingressroutev1.IngressRoute {
+		return &ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: &ingressroutev1.VirtualHost{
+					Fqdn: fqdn,
+				},
+				Routes: []ingressroutev1.Route{{
+					Match: "/",
+					Services: []ingressroutev1.Service{{
+						Name: "kuard",
+						Port: port,
+					}},
+				}},
+			},
+		}
+	}
+
+	var cache dag.KubernetesCache
+	cache.Insert(ingressroute("existing", "existing.example.com", 8080))
+
+	// conflicting was created before the webhook was installed.
+	conflicting := ingressroute("conflicting", "conflict.example.com", 8080)
+	cache.Insert(ingressroute("conflicted", "conflict.example.com", 8080))
+	cache.Insert(conflicting)
+
+	conflictingStatus := ingressroute("conflicting", "conflict.example.com", 8080)
+	conflictingStatus.Status = ingressroutev1.Status{
+		CurrentStatus: "invalid",
+		Description:   `fqdn "conflict.example.com" is used in multiple IngressRoutes: default/conflicted, default/conflicting`,
+	}
+
+	otherClass := ingressroute("other", "example.com", 0)
+	otherClass.Annotations = map[string]string{
+		"kubernetes.io/ingress.class": "nginx",
+	}
+
+	tests := map[string]struct {
+		kind      string
+		operation admissionv1beta1.Operation
+		old       interface{}
+		obj       interface{}
+		want      *admissionv1beta1.AdmissionResponse
+	}{
+		"valid ingressroute": {
+			kind: "IngressRoute",
+			obj:  ingressroute("example", "example.com", 8080),
+			want: &admissionv1beta1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: true,
+			},
+		},
+		"invalid ingressroute": {
+			kind: "IngressRoute",
+			obj:  ingressroute("example", "example.com", 0),
+			want: denied(`route "/": service "kuard": port must be in the range 1-65535`),
+		},
+		"fqdn conflict": {
+			kind: "IngressRoute",
+			obj:  ingressroute("example", "existing.example.com", 8080),
+			want: denied(`fqdn "existing.example.com" is used in multiple IngressRoutes: default/example, default/existing`),
+		},
+		"update of an existing ingressroute": {
+			kind:      "IngressRoute",
+			operation: admissionv1beta1.Update,
+			obj:       ingressroute("existing", "existing.example.com", 8081),
+			want: &admissionv1beta1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: true,
+			},
+		},
+		"status update of a conflicting ingressroute": {
+			kind:      "IngressRoute",
+			operation: admissionv1beta1.Update,
+			old:       conflicting,
+			obj:       conflictingStatus,
+			want: &admissionv1beta1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: true,
+			},
+		},
+		"spec update of a conflicting ingressroute": {
+			kind:      "IngressRoute",
+			operation: admissionv1beta1.Update,
+			old:       conflicting,
+			obj:       ingressroute("conflicting", "conflict.example.com", 8081),
+			want:      denied(`fqdn "conflict.example.com" is used in multiple IngressRoutes: default/conflicted, default/conflicting`),
+		},
+		"ingressroute of another ingress class": {
+			kind: "IngressRoute",
+			obj:  otherClass,
+			want: &admissionv1beta1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: true,
+			},
+		},
+		"delete": {
+			kind:      "IngressRoute",
+			operation: admissionv1beta1.Delete,
+			want: &admissionv1beta1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: true,
+			},
+		},
+		"invalid tlscertificatedelegation": {
+			kind: "TLSCertificateDelegation",
+			obj: &ingressroutev1.TLSCertificateDelegation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "delegation",
+				},
+				Spec: ingressroutev1.TLSCertificateDelegationSpec{
+					Delegations: []ingressroutev1.CertificateDelegation{{
+						SecretName: "example",
+					}},
+				},
+			},
+			want: denied("Spec.Delegations[0].TargetNamespaces must not be empty"),
+		},
+		"other kind": {
+			kind: "Service",
+			obj:  map[string]string{},
+			want: &admissionv1beta1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: true,
+			},
+		},
+	}
+
+	h := Handler{
+		Cache: &cache,
+		ValidIngressClass: func(obj interface{}) bool {
+			ir := obj.(*ingressroutev1.IngressRoute)
+			return ir.Annotations["kubernetes.io/ingress.class"] == ""
+		},
+		FieldLogger: logrus.New(),
+	}
+	h.FieldLogger.(*logrus.Logger).Out = ioutil.Discard
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			operation := tc.operation
+			if operation == "" {
+				operation = admissionv1beta1.Create
+			}
+			var raw, oldRaw []byte
+			if tc.obj != nil {
+				var err error
+				raw, err = json.Marshal(tc.obj)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.old != nil {
+				var err error
+				oldRaw, err = json.Marshal(tc.old)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			body, err := json.Marshal(&admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:       "uid",
+					Kind:      metav1.GroupVersionKind{Group: "contour.heptio.com", Version: "v1beta1", Kind: tc.kind},
+					Namespace: "default",
+					Operation: operation,
+					Object:    runtime.RawExtension{Raw: raw},
+					OldObject: runtime.RawExtension{Raw: oldRaw},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+			}
+
+			var got admissionv1beta1.AdmissionReview
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			want := tc.want
+			want.UID = "uid"
+			if diff := cmp.Diff(want, got.Response); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestHandlerBadRequest(t *testing.T) {
+	h := Handler{FieldLogger: logrus.New()}
+
+	tests := map[string]struct {
+		method string
+		body   string
+		want   int
+	}{
+		"get": {
+			method: http.MethodGet,
+			want:   http.StatusMethodNotAllowed,
+		},
+		"malformed": {
+			method: http.MethodPost,
+			body:   "{",
+			want:   http.StatusBadRequest,
+		},
+		"no request": {
+			method: http.MethodPost,
+			body:   "{}",
+			want:   http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tc.method, "/validate", bytes.NewBufferString(tc.body)))
+			if rec.Code != tc.want {
+				t.Fatalf("expected %d, got %d", tc.want, rec.Code)
+			}
+		})
+	}
+}
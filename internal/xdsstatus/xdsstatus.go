@@ -0,0 +1,250 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package xdsstatus tracks the responses Contour sends on each
+// xDS stream, and whether Envoy accepted or rejected them.
+package xdsstatus
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/heptio/contour/internal/grpc"
+	"github.com/heptio/contour/internal/metrics"
+)
+
+// Reporter is told when Envoy rejects a response, and when
+// the rejection is resolved.
+type Reporter interface {
+	// Rejected is called when a stream of typeURL rejects
+	// a response with message.
+	Rejected(typeURL, message string)
+
+	// Resolved is called when a stream which rejected a
+	// response of typeURL with message accepts a later
+	// response, rejects one with a different message, or
+	// terminates.
+	Resolved(typeURL, message string)
+}
+
+// StreamStatus is the status of a single type of an xDS stream.
+type StreamStatus struct {
+	Node       string `json:"node"`
+	TypeURL    string `json:"type_url"`
+	Connection uint64 `json:"connection"`
+
+	// SentVersion and SentNonce are the version and nonce
+	// of the last response sent on the stream.
+	SentVersion string `json:"sent_version"`
+	SentNonce   string `json:"sent_nonce"`
+
+	// AcceptedVersion is the version of the last response
+	// Envoy accepted.
+	AcceptedVersion string `json:"accepted_version"`
+
+	// Error is the error Envoy reported when it rejected the
+	// last response. It is empty if the response was accepted,
+	// or is not yet acknowledged.
+	Error string `json:"error,omitempty"`
+}
+
+type key struct {
+	connection uint64
+	typeURL    string
+}
+
+// Tracker tracks the status of every xDS stream, recording
+// rejected responses, and the versions each node accepted, as
+// Prometheus metrics. Tracker serves the status of each stream
+// as JSON.
+type Tracker struct {
+	*metrics.Metrics
+
+	// Reporter, if not nil, is told of rejected responses.
+	Reporter Reporter
+
+	mu      sync.Mutex
+	streams map[key]*StreamStatus
+}
+
+// Sent implements grpc.ResponseHandler.
+func (t *Tracker) Sent(s grpc.StreamInfo, version, nonce string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := t.stream(s)
+	st.SentVersion = version
+	st.SentNonce = nonce
+}
+
+// Accepted implements grpc.ResponseHandler. Acknowledgements of
+// responses other than the last one sent on the stream are ignored.
+func (t *Tracker) Accepted(s grpc.StreamInfo, version, nonce string) {
+	t.mu.Lock()
+	st := t.current(s, nonce)
+	if st == nil {
+		t.mu.Unlock()
+		return
+	}
+	previous := st.AcceptedVersion
+	st.AcceptedVersion = version
+	resolved := st.Error
+	st.Error = ""
+	t.setRejecting(st)
+	t.setVersion(st, previous)
+	t.setVersion(st, version)
+	t.mu.Unlock()
+
+	t.resolved(s.TypeURL, resolved)
+}
+
+// Rejected implements grpc.ResponseHandler. Rejections of responses
+// other than the last one sent on the stream are ignored.
+func (t *Tracker) Rejected(s grpc.StreamInfo, nonce, message string) {
+	t.mu.Lock()
+	st := t.current(s, nonce)
+	if st == nil {
+		t.mu.Unlock()
+		return
+	}
+	previous := st.Error
+	st.Error = message
+	t.AddXDSRejected(st.Node, st.TypeURL)
+	t.setRejecting(st)
+	t.mu.Unlock()
+
+	if previous == message {
+		// Envoy rejected a later response for the same reason.
+		return
+	}
+	t.resolved(s.TypeURL, previous)
+	if t.Reporter != nil {
+		t.Reporter.Rejected(s.TypeURL, message)
+	}
+}
+
+// Closed implements grpc.ResponseHandler.
+func (t *Tracker) Closed(s grpc.StreamInfo) {
+	t.mu.Lock()
+	k := key{connection: s.Connection, typeURL: s.TypeURL}
+	st, ok := t.streams[k]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	delete(t.streams, k)
+	t.setRejecting(st)
+	t.setVersion(st, st.AcceptedVersion)
+	t.mu.Unlock()
+
+	t.resolved(s.TypeURL, st.Error)
+}
+
+// stream returns the status of s, adding it if needed.
+// t.mu must be held.
+func (t *Tracker) stream(s grpc.StreamInfo) *StreamStatus {
+	if t.streams == nil {
+		t.streams = make(map[key]*StreamStatus)
+	}
+	k := key{connection: s.Connection, typeURL: s.TypeURL}
+	st, ok := t.streams[k]
+	if !ok {
+		st = &StreamStatus{
+			Node:       s.Node.GetId(),
+			TypeURL:    s.TypeURL,
+			Connection: s.Connection,
+		}
+		t.streams[k] = st
+	}
+	return st
+}
+
+// current returns the status of s if nonce is that of the last
+// response sent on s, otherwise the acknowledgement is of a response
+// which has been superseded, and current returns nil.
+// t.mu must be held.
+func (t *Tracker) current(s grpc.StreamInfo, nonce string) *StreamStatus {
+	st, ok := t.streams[key{connection: s.Connection, typeURL: s.TypeURL}]
+	if !ok || st.SentNonce != nonce {
+		return nil
+	}
+	return st
+}
+
+// setRejecting records the number of streams of the node and
+// type of st which are rejecting their last response.
+// t.mu must be held.
+func (t *Tracker) setRejecting(st *StreamStatus) {
+	n := 0
+	for _, other := range t.streams {
+		if other.Node == st.Node && other.TypeURL == st.TypeURL && other.Error != "" {
+			n++
+		}
+	}
+	t.SetXDSRejecting(st.Node, st.TypeURL, n)
+}
+
+// setVersion records the number of streams of the node and type
+// of st whose last accepted response had version.
+// t.mu must be held.
+func (t *Tracker) setVersion(st *StreamStatus, version string) {
+	if version == "" {
+		return
+	}
+	n := 0
+	for _, other := range t.streams {
+		if other.Node == st.Node && other.TypeURL == st.TypeURL && other.AcceptedVersion == version {
+			n++
+		}
+	}
+	t.SetXDSVersion(st.Node, st.TypeURL, version, n)
+}
+
+// resolved tells the Reporter, if any, that the rejection
+// with message is resolved.
+func (t *Tracker) resolved(typeURL, message string) {
+	if t.Reporter != nil && message != "" {
+		t.Reporter.Resolved(typeURL, message)
+	}
+}
+
+// Status returns the status of each stream, sorted by node,
+// type, and connection.
+func (t *Tracker) Status() []StreamStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := make([]StreamStatus, 0, len(t.streams))
+	for _, st := range t.streams {
+		status = append(status, *st)
+	}
+	sort.Slice(status, func(i, j int) bool {
+		switch {
+		case status[i].Node != status[j].Node:
+			return status[i].Node < status[j].Node
+		case status[i].TypeURL != status[j].TypeURL:
+			return status[i].TypeURL < status[j].TypeURL
+		default:
+			return status[i].Connection < status[j].Connection
+		}
+	})
+	return status
+}
+
+// ServeHTTP writes the status of each stream as JSON.
+func (t *Tracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(t.Status())
+}
@@ -0,0 +1,232 @@
+// Copyright © 2019 Heptio
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xdsstatus
+
+import (
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/google/go-cmp/cmp"
+	"github.com/heptio/contour/internal/grpc"
+	"github.com/heptio/contour/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTracker(t *testing.T) {
+	lds := grpc.StreamInfo{
+		Connection: 1,
+		Node:       &core.Node{Id: "envoy"},
+		TypeURL:    cache.ListenerType,
+	}
+	cds := grpc.StreamInfo{
+		Connection: 2,
+		Node:       &core.Node{Id: "envoy"},
+		TypeURL:    cache.ClusterType,
+	}
+
+	tests := map[string]struct {
+		events       func(*Tracker)
+		want         []StreamStatus
+		wantReported []string
+	}{
+		"sent": {
+			events: func(tr *Tracker) {
+				tr.Sent(lds, "1", "1")
+			},
+			want: []StreamStatus{{
+				Node:        "envoy",
+				TypeURL:     cache.ListenerType,
+				Connection:  1,
+				SentVersion: "1",
+				SentNonce:   "1",
+			}},
+		},
+		"accepted": {
+			events: func(tr *Tracker) {
+				tr.Sent(lds, "1", "1")
+				tr.Accepted(lds, "1", "1")
+				tr.Sent(cds, "3", "3")
+			},
+			want: []StreamStatus{{
+				Node:        "envoy",
+				TypeURL:     cache.ClusterType,
+				Connection:  2,
+				SentVersion: "3",
+				SentNonce:   "3",
+			}, {
+				Node:            "envoy",
+				TypeURL:         cache.ListenerType,
+				Connection:      1,
+				SentVersion:     "1",
+				SentNonce:       "1",
+				AcceptedVersion: "1",
+			}},
+		},
+		"rejected": {
+			events: func(tr *Tracker) {
+				tr.Sent(lds, "1", "1")
+				tr.Accepted(lds, "1", "1")
+				tr.Sent(lds, "2", "2")
+				tr.Rejected(lds, "2", "duplicate domain")
+				tr.Sent(lds, "3", "3")
+				tr.Rejected(lds, "3", "duplicate domain")
+			},
+			want: []StreamStatus{{
+				Node:            "envoy",
+				TypeURL:         cache.ListenerType,
+				Connection:      1,
+				SentVersion:     "3",
+				SentNonce:       "3",
+				AcceptedVersion: "1",
+				Error:           "duplicate domain",
+			}},
+			wantReported: []string{
+				"rejected " + cache.ListenerType + ": duplicate domain",
+			},
+		},
+		"rejected then accepted": {
+			events: func(tr *Tracker) {
+				tr.Sent(lds, "1", "1")
+				tr.Rejected(lds, "1", "duplicate domain")
+				tr.Sent(lds, "2", "2")
+				tr.Accepted(lds, "2", "2")
+			},
+			want: []StreamStatus{{
+				Node:            "envoy",
+				TypeURL:         cache.ListenerType,
+				Connection:      1,
+				SentVersion:     "2",
+				SentNonce:       "2",
+				AcceptedVersion: "2",
+			}},
+			wantReported: []string{
+				"rejected " + cache.ListenerType + ": duplicate domain",
+				"resolved " + cache.ListenerType + ": duplicate domain",
+			},
+		},
+		"stale acknowledgements are ignored": {
+			events: func(tr *Tracker) {
+				tr.Sent(lds, "1", "1")
+				tr.Sent(lds, "2", "2")
+				// Envoy rejects the first response after
+				// the second has been sent.
+				tr.Rejected(lds, "1", "duplicate domain")
+				tr.Accepted(lds, "2", "2")
+				tr.Sent(lds, "3", "3")
+				tr.Accepted(lds, "1", "1")
+			},
+			want: []StreamStatus{{
+				Node:            "envoy",
+				TypeURL:         cache.ListenerType,
+				Connection:      1,
+				SentVersion:     "3",
+				SentNonce:       "3",
+				AcceptedVersion: "2",
+			}},
+		},
+		"acknowledgement of an unknown stream": {
+			events: func(tr *Tracker) {
+				tr.Rejected(lds, "1", "duplicate domain")
+			},
+			want: []StreamStatus{},
+		},
+		"rejected then closed": {
+			events: func(tr *Tracker) {
+				tr.Sent(lds, "1", "1")
+				tr.Rejected(lds, "1", "duplicate domain")
+				tr.Closed(lds)
+			},
+			want: []StreamStatus{},
+			wantReported: []string{
+				"rejected " + cache.ListenerType + ": duplicate domain",
+				"resolved " + cache.ListenerType + ": duplicate domain",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var r reporter
+			tr := &Tracker{
+				Metrics:  metrics.NewMetrics(prometheus.NewRegistry()),
+				Reporter: &r,
+			}
+			tc.events(tr)
+			if diff := cmp.Diff(tc.want, tr.Status()); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.wantReported, r.events); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+type reporter struct {
+	events []string
+}
+
+func (r *reporter) Rejected(typeURL, message string) {
+	r.events = append(r.events, "rejected "+typeURL+": "+message)
+}
+
+func (r *reporter) Resolved(typeURL, message string) {
+	r.events = append(r.events, "resolved "+typeURL+": "+message)
+}
+
+func TestTrackerVersions(t *testing.T) {
+	stream := func(connection uint64) grpc.StreamInfo {
+		return grpc.StreamInfo{
+			Connection: connection,
+			Node:       &core.Node{Id: "envoy"},
+			TypeURL:    cache.ClusterType,
+		}
+	}
+
+	registry := prometheus.NewRegistry()
+	tr := &Tracker{Metrics: metrics.NewMetrics(registry)}
+	for _, s := range []grpc.StreamInfo{stream(1), stream(2), stream(3)} {
+		tr.Sent(s, "e3b0c44298fc1c14", "1")
+		tr.Accepted(s, "e3b0c44298fc1c14", "1")
+	}
+	tr.Sent(stream(1), "0123456789abcdef", "2")
+	tr.Accepted(stream(1), "0123456789abcdef", "2")
+	tr.Closed(stream(3))
+
+	gathering, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]float64)
+	for _, mf := range gathering {
+		if mf.GetName() != metrics.XDSVersionGauge {
+			continue
+		}
+		for _, m := range mf.Metric {
+			for _, l := range m.Label {
+				if l.GetName() == "version" {
+					got[l.GetValue()] = m.Gauge.GetValue()
+				}
+			}
+		}
+	}
+	want := map[string]float64{
+		"e3b0c44298fc1c14": 1,
+		"0123456789abcdef": 1,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}